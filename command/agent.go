@@ -0,0 +1,152 @@
+package command
+
+import (
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/agent"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+// agentCertsMaxAge is how long the etcd client certificates of the agent are
+// used before they are read again.
+const agentCertsMaxAge = 5 * time.Minute
+
+type agentCommand struct {
+	logger micrologger.Logger
+
+	address      string
+	cert         string
+	key          string
+	clientCACert string
+	etcdEndpoint string
+	etcdCACert   string
+	etcdCert     string
+	etcdKey      string
+}
+
+func newAgentCommand(logger micrologger.Logger) *cobra.Command {
+	c := &agentCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Serve snapshots of the local etcd member over mTLS.",
+		Long: `Serve snapshots of the local etcd member over mTLS.
+
+This runs on the control plane nodes of workload clusters whose etcd is
+backed up with the agent access mode, e.g. as a DaemonSet in the host network.
+It serves the status of the etcd member at --etcd-endpoint and streams
+snapshots of it to clients presenting a certificate signed by
+--client-cacert. The defaults are the certificates kubeadm puts on control
+plane nodes.
+
+When AGENT_SIGNING_KEY is set, the agent also runs the backup jobs the
+operator signs with the same key for clusters with the agent-upload access
+mode. It takes the backup, encrypts it with the passphrase generated for the
+job and uploads it to the URL presigned in the job. The agent needs neither
+credentials of the object storage nor the encryption password.`,
+		Hidden: true,
+		RunE:   c.execute,
+	}
+
+	cmd.Flags().StringVar(&c.address, "listen-address", ":"+strconv.Itoa(agent.DefaultPort), "Address the agent listens on.")
+	cmd.Flags().StringVar(&c.cert, "cert", "/etc/kubernetes/pki/etcd/server.crt", "Server certificate of the agent.")
+	cmd.Flags().StringVar(&c.key, "key", "/etc/kubernetes/pki/etcd/server.key", "Server private key of the agent.")
+	cmd.Flags().StringVar(&c.clientCACert, "client-cacert", "/etc/kubernetes/pki/etcd/ca.crt", "CA the client certificates are verified against.")
+	cmd.Flags().StringVar(&c.etcdEndpoint, "etcd-endpoint", "https://127.0.0.1:2379", "Endpoint of the local etcd member.")
+	cmd.Flags().StringVar(&c.etcdCACert, "etcd-cacert", "/etc/kubernetes/pki/etcd/ca.crt", "Client CA certificate for the etcd connection.")
+	cmd.Flags().StringVar(&c.etcdCert, "etcd-cert", "/etc/kubernetes/pki/etcd/healthcheck-client.crt", "Client certificate for the etcd connection.")
+	cmd.Flags().StringVar(&c.etcdKey, "etcd-key", "/etc/kubernetes/pki/etcd/healthcheck-client.key", "Client private key for the etcd connection.")
+
+	return cmd
+}
+
+func (c *agentCommand) execute(cmd *cobra.Command, args []string) error {
+	host, err := etcd.EndpointHost(c.etcdEndpoint)
+	if err != nil {
+		return microerror.Maskf(invalidFlagError, "--etcd-endpoint must be an etcd endpoint: %s", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var reloader *certs.Reloader
+	{
+		config := certs.Config{
+			Logger: c.logger,
+			Source: certs.FileSource{
+				CA:   c.etcdCACert,
+				Cert: c.etcdCert,
+				Key:  c.etcdKey,
+			},
+			Name:       "agent",
+			MaxAge:     agentCertsMaxAge,
+			ServerName: certs.Fixed(host),
+		}
+
+		reloader, err = certs.New(ctx, config)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	etcdClient, err := etcd.NewV3Client(c.etcdEndpoint, reloader.TLSConfig(), nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer etcdClient.Close() //nolint:errcheck
+
+	var server *agent.Server
+	{
+		config := agent.ServerConfig{
+			Logger:     c.logger,
+			EtcdClient: etcdClient,
+
+			Address:      c.address,
+			CertFile:     c.cert,
+			KeyFile:      c.key,
+			ClientCAFile: c.clientCACert,
+		}
+
+		if signingKey := os.Getenv(key.EnvAgentSigningKey); signingKey != "" {
+			config.SigningKey = []byte(signingKey)
+			config.NewBackupper = c.newBackupper(etcdClient)
+		}
+
+		server, err = agent.NewServer(config)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = server.Run(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// newBackupper returns the backups of the jobs, taken from the local etcd
+// member like the operator takes them and encrypted with the passphrase of
+// the job.
+func (c *agentCommand) newBackupper(etcdClient *clientv3.Client) func(job agent.Job) (agent.Backupper, error) {
+	return func(job agent.Job) (agent.Backupper, error) {
+		b, err := etcd.NewLocalV3Backup(etcdClient, job.Passphrase, c.logger, job.Prefix)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return b, nil
+	}
+}
@@ -0,0 +1,215 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+type backupCommand struct {
+	logger micrologger.Logger
+	s3     s3Flags
+
+	cluster      string
+	installation string
+	endpoint     string
+	kubeconfig   string
+	caCert       string
+	cert         string
+	key          string
+	serverName   string
+	insecure     bool
+	datastore    string
+	output       string
+	timeout      time.Duration
+}
+
+func newBackupCommand(logger micrologger.Logger) *cobra.Command {
+	c := &backupCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Take a backup of an etcd cluster.",
+		Long: `Take a backup of an etcd cluster without the controller.
+
+The etcd cluster is reached either directly with --endpoint, or through a
+port-forward to the etcd pod of the cluster --kubeconfig points to. The backup
+is encrypted when ENCRYPTION_PASSWORD is set, and uploaded when --bucket is
+set. Otherwise it is written to --output. --datastore selects how the
+datastore is backed up, e.g. kine for the kine datastore of a k3s server.`,
+		Example: "  etcd-backup-operator backup --installation gauss --cluster ManagementCluster --endpoint https://127.0.0.1:2379 --cacert ca.crt --cert client.crt --key client.key --bucket backups --region eu-central-1",
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	cmd.Flags().StringVar(&c.cluster, "cluster", key.ManagementCluster, "Name of the cluster, used in the name of the backup.")
+	cmd.Flags().StringVar(&c.installation, "installation", "", "Name of the installation, used in the name of the backup.")
+	cmd.Flags().StringVar(&c.endpoint, "endpoint", "", "Endpoint of etcd.")
+	cmd.Flags().StringVar(&c.kubeconfig, "kubeconfig", "", "Path to the kubeconfig of the cluster whose etcd pods are port-forwarded to, instead of --endpoint.")
+	cmd.Flags().StringVar(&c.caCert, "cacert", "", "Client CA certificate for the etcd connection.")
+	cmd.Flags().StringVar(&c.cert, "cert", "", "Client certificate for the etcd connection.")
+	cmd.Flags().StringVar(&c.key, "key", "", "Client private key for the etcd connection.")
+	cmd.Flags().StringVar(&c.serverName, "server-name", "", "Name expected in the etcd server certificate. Defaults to the host of --endpoint or the node of the etcd pod.")
+	cmd.Flags().BoolVar(&c.insecure, "insecure-skip-tls-verify", false, "Do not verify the etcd server certificate.")
+	cmd.Flags().StringVar(&c.datastore, "datastore", etcd.DatastoreEtcd, "Kind of the datastore: etcd, embedded for the etcd of k3s and RKE2 servers, or kine.")
+	cmd.Flags().StringVar(&c.output, "output", ".", "Directory the backup is written to when --bucket is not set.")
+	cmd.Flags().DurationVar(&c.timeout, "timeout", time.Hour, "Timeout of the whole backup.")
+
+	return cmd
+}
+
+func (c *backupCommand) execute(cmd *cobra.Command, args []string) error {
+	if c.installation == "" {
+		return microerror.Maskf(invalidFlagError, "--installation must not be empty")
+	}
+	if (c.endpoint == "") == (c.kubeconfig == "") {
+		return microerror.Maskf(invalidFlagError, "exactly one of --endpoint and --kubeconfig must be set")
+	}
+	if c.caCert == "" || c.cert == "" || c.key == "" {
+		return microerror.Maskf(invalidFlagError, "--cacert, --cert and --key must not be empty")
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	tlsConfig, err := key.TLSConfigFromCertFiles(c.caCert, c.cert, c.key)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	tlsConfig.ServerName = c.serverName
+	tlsConfig.InsecureSkipVerify = c.insecure //nolint:gosec
+
+	endpoint := c.endpoint
+	var p *proxy.Proxy
+	if c.kubeconfig != "" {
+		endpoint, p, err = c.etcdPodProxy(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = giantnetes.EtcdPodServerName(endpoint)
+		}
+		p.TLSConfig = tlsConfig
+	}
+
+	backupper, err := etcd.NewBackupper(c.datastore, tlsConfig, p, os.Getenv(key.EncryptionPassword), endpoint, c.logger, key.FilenamePrefix(c.installation, c.cluster))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer backupper.Cleanup()
+
+	_, err = backupper.Create(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	fpath, err := backupper.Encrypt(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if c.s3.bucket != "" {
+		s, err := c.s3.storage("")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, err = s.Upload(ctx, fpath)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s%s/%s\n", s3Scheme, c.s3.bucket, filepath.Base(fpath))
+		return nil
+	}
+
+	dst := filepath.Join(c.output, filepath.Base(fpath))
+	err = copyFile(fpath, dst)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), dst)
+	return nil
+}
+
+// etcdPodProxy returns the name of the first etcd pod of the cluster and a
+// proxy port-forwarding to it.
+func (c *backupCommand) etcdPodProxy(ctx context.Context) (string, *proxy.Proxy, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", c.kubeconfig)
+	if err != nil {
+		return "", nil, microerror.Mask(err)
+	}
+
+	ctrlClient, err := key.GetCtrlClient(restConfig)
+	if err != nil {
+		return "", nil, microerror.Mask(err)
+	}
+
+	podList := corev1.PodList{}
+	err = ctrlClient.List(ctx, &podList, client.InNamespace(metav1.NamespaceSystem), client.MatchingLabels{
+		giantnetes.EtcdLabelComponentKey: giantnetes.EtcdLabelComponentValue,
+		giantnetes.EtcdLabelTierKey:      giantnetes.EtcdLabelTierValue,
+	})
+	if err != nil {
+		return "", nil, microerror.Mask(err)
+	}
+	if len(podList.Items) == 0 {
+		return "", nil, microerror.Maskf(invalidFlagError, "no etcd pods found in the cluster of %#q", c.kubeconfig)
+	}
+
+	p := &proxy.Proxy{
+		Kind:       "pods",
+		Namespace:  metav1.NamespaceSystem,
+		KubeConfig: restConfig,
+		Port:       2379,
+	}
+
+	return podList.Items[0].Name, p, nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer in.Close() //nolint:errcheck
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(0600)) // #nosec G304
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer out.Close() //nolint:errcheck
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = out.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
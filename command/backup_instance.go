@@ -0,0 +1,258 @@
+package command
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	backupv1alpha1 "github.com/giantswarm/apiextensions-backup/api/v1alpha1"
+	infrastructurev1alpha3 "github.com/giantswarm/apiextensions/v6/pkg/apis/infrastructure/v1alpha3"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/k8sclient/v8/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/backupjob"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/resource/etcdbackup"
+)
+
+type backupInstanceCommand struct {
+	logger micrologger.Logger
+	s3     s3Flags
+
+	instance     string
+	installation string
+	endpoints    string
+	caCert       string
+	cert         string
+	key          string
+	serverName   string
+	insecure     bool
+	encrypt      bool
+	drBundle     bool
+	inventory    bool
+	timeouts     giantnetes.Timeouts
+	resultPath   string
+}
+
+func newBackupInstanceCommand(logger micrologger.Logger) *cobra.Command {
+	c := &backupInstanceCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   backupjob.Command,
+		Short: "Take the backup of an instance for an ETCDBackup.",
+		Long: `Take the backup of an instance for an ETCDBackup.
+
+This runs in the Jobs the operator creates when its backups are run as Jobs.
+The backup is taken, encrypted and uploaded like the operator does it, with
+the same retries. The management cluster is reached with --endpoints and the
+client certificates, workload clusters and ETCDEndpoints are discovered
+through the Kubernetes API the pod runs in. With --encrypt the backup is
+encrypted with ENCRYPTION_PASSWORD, and with --dr-bundle and --inventory the
+DR bundle and the inventory of the instance are uploaded next to it. The
+result is written to --result-path, which is read by the operator from the
+termination message of the pod.`,
+		Hidden: true,
+		RunE:   c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	cmd.Flags().StringVar(&c.instance, "instance", "", "Name of the instance, the cluster or ETCDEndpoint backed up.")
+	cmd.Flags().StringVar(&c.installation, "installation", "", "Name of the installation, used in the name of the backup.")
+	cmd.Flags().StringVar(&c.endpoints, "endpoints", "", "Comma separated endpoints of the etcd of the management cluster.")
+	cmd.Flags().StringVar(&c.caCert, "cacert", "", "Client CA certificate for the etcd connection of the management cluster.")
+	cmd.Flags().StringVar(&c.cert, "cert", "", "Client certificate for the etcd connection of the management cluster.")
+	cmd.Flags().StringVar(&c.key, "key", "", "Client private key for the etcd connection of the management cluster.")
+	cmd.Flags().StringVar(&c.serverName, "server-name", "", "Name expected in the etcd server certificate of the management cluster.")
+	cmd.Flags().BoolVar(&c.insecure, "insecure-skip-tls-verify", false, "Do not verify the etcd server certificate of the management cluster.")
+	cmd.Flags().BoolVar(&c.encrypt, "encrypt", false, "Encrypt the backup with ENCRYPTION_PASSWORD.")
+	cmd.Flags().BoolVar(&c.drBundle, "dr-bundle", false, "Upload the DR bundle of the workload cluster with its backup. It requires --encrypt.")
+	cmd.Flags().BoolVar(&c.inventory, "inventory", false, "Upload the inventory of the management cluster with its backup. It requires --encrypt.")
+	cmd.Flags().DurationVar(&c.timeouts.Create, "create-timeout", 0, "Timeout of the creation of the backup, zero for none.")
+	cmd.Flags().DurationVar(&c.timeouts.Encrypt, "encrypt-timeout", 0, "Timeout of the encryption of the backup, zero for none.")
+	cmd.Flags().DurationVar(&c.timeouts.Upload, "upload-timeout", 0, "Timeout of the upload of the backup, zero for none.")
+	cmd.Flags().StringVar(&c.resultPath, "result-path", backupjob.ResultPath, "File the result of the backup is written to.")
+
+	return cmd
+}
+
+func (c *backupInstanceCommand) execute(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var result backupjob.Result
+	start := time.Now()
+	err := c.backup(ctx, &result)
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorClass = etcdbackup.ErrorClass(err)
+	}
+
+	// The result is written for failed backups too, so that the operator
+	// can report why they failed.
+	writeErr := backupjob.WriteResult(c.resultPath, result)
+	if writeErr != nil {
+		c.logger.LogCtx(ctx, "level", "error", "message", "Failed to write result", "reason", writeErr)
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	c.logger.LogCtx(ctx, "level", "info", "message", "Backup uploaded", "filename", result.Filename, "duration", time.Since(start).String())
+	return microerror.Mask(writeErr)
+}
+
+func (c *backupInstanceCommand) backup(ctx context.Context, result *backupjob.Result) error {
+	if c.instance == "" {
+		return microerror.Maskf(invalidFlagError, "--instance must not be empty")
+	}
+	if c.encrypt && os.Getenv(key.EncryptionPassword) == "" {
+		return microerror.Maskf(invalidFlagError, "%s must not be empty with --encrypt", key.EncryptionPassword)
+	}
+	if (c.drBundle || c.inventory) && !c.encrypt {
+		return microerror.Maskf(invalidFlagError, "--dr-bundle and --inventory require --encrypt")
+	}
+
+	s, err := c.s3.storage("")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	k8sClient, err := c.k8sClient()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	instance, err := c.etcdInstance(ctx, k8sClient)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var runner *etcdbackup.Runner
+	{
+		config := etcdbackup.RunnerConfig{
+			Logger:          c.logger,
+			EncryptionPwd:   os.Getenv(key.EncryptionPassword),
+			Installation:    c.installation,
+			Uploader:        s,
+			Encrypt:         c.encrypt,
+			K8sClient:       k8sClient,
+			AgentSigningKey: []byte(os.Getenv(key.EnvAgentSigningKey)),
+			AgentStorage:    s,
+		}
+
+		runner, err = etcdbackup.NewRunner(config)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	r, err := runner.Run(ctx, instance.ETCDv3, c.instance, c.timeouts)
+	if r != nil && r.Attempts > 1 {
+		result.Retries = r.Attempts - 1
+	}
+	if err != nil {
+		if r != nil {
+			result.Stage = r.FailedStage
+		}
+		return microerror.Mask(err)
+	}
+
+	result.Filename = r.Filename
+	result.Size = r.BackupSizeMeasurement
+	result.CreationTime = r.CreationTimeMeasurement
+	result.EncryptionTime = r.EncryptionTimeMeasurement
+	result.UploadTime = r.UploadTimeMeasurement
+
+	attachments := etcdbackup.Attachments{
+		DRBundle:  c.drBundle,
+		Inventory: c.inventory,
+	}
+	stage, err := runner.UploadAttachments(ctx, instance, r.Filename, attachments, c.timeouts)
+	if err != nil {
+		// The snapshot is uploaded, the operator reports it with the
+		// failure.
+		result.Stage = stage
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// k8sClient returns the client of the Kubernetes API the pod runs in.
+func (c *backupInstanceCommand) k8sClient() (k8sclient.Interface, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	config := k8sclient.ClientsConfig{
+		Logger: c.logger,
+		SchemeBuilder: k8sclient.SchemeBuilder{
+			backupv1alpha1.AddToScheme,
+			infrastructurev1alpha3.AddToScheme,
+			providerv1alpha1.AddToScheme,
+			capi.AddToScheme,
+			restorev1alpha1.AddToScheme,
+		},
+		RestConfig: restConfig,
+	}
+
+	k8sClient, err := k8sclient.NewClients(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return k8sClient, nil
+}
+
+// etcdInstance returns the instance backed up. The management cluster is
+// reached with the flags, all other instances are discovered like the
+// operator discovers them.
+func (c *backupInstanceCommand) etcdInstance(ctx context.Context, k8sClient k8sclient.Interface) (giantnetes.ETCDInstance, error) {
+	if c.instance == key.ManagementCluster {
+		if c.endpoints == "" || c.caCert == "" || c.cert == "" || c.key == "" {
+			return giantnetes.ETCDInstance{}, microerror.Maskf(invalidFlagError, "--endpoints, --cacert, --cert and --key must not be empty for the management cluster")
+		}
+
+		tlsConfig, err := key.TLSConfigFromCertFiles(c.caCert, c.cert, c.key)
+		if err != nil {
+			return giantnetes.ETCDInstance{}, microerror.Mask(err)
+		}
+		tlsConfig.ServerName = c.serverName
+		tlsConfig.InsecureSkipVerify = c.insecure //nolint:gosec
+
+		instance := giantnetes.ETCDInstance{
+			Name:   key.ManagementCluster,
+			ETCDv3: giantnetes.ETCDv3Settings{Endpoints: c.endpoints, TLSConfig: tlsConfig},
+		}
+
+		return instance, nil
+	}
+
+	utils, err := giantnetes.NewUtils(c.logger, k8sClient)
+	if err != nil {
+		return giantnetes.ETCDInstance{}, microerror.Mask(err)
+	}
+
+	instance, err := utils.GetTenantCluster(ctx, c.instance)
+	if err != nil {
+		return giantnetes.ETCDInstance{}, microerror.Mask(err)
+	}
+	if instance.Failure != nil {
+		return giantnetes.ETCDInstance{}, microerror.Maskf(executionFailedError, "%s", instance.Failure)
+	}
+	if !instance.ETCDv3.AreComplete() {
+		return giantnetes.ETCDInstance{}, microerror.Maskf(executionFailedError, "etcd v3 settings of %#q are not set", c.instance)
+	}
+
+	return instance, nil
+}
@@ -0,0 +1,54 @@
+// Package command implements the standalone subcommands of the operator.
+// They run the backup pipeline directly against an etcd endpoint and the
+// storage, without the controller and without the Kubernetes API of the
+// management cluster, so they can be used during a disaster recovery.
+package command
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+)
+
+type Config struct {
+	Logger micrologger.Logger
+}
+
+// New returns the backup, dr-bundle, inspect, inventory, list, objects,
+// restore and verify subcommands, and the hidden subcommands run on control
+// plane nodes: restore-member during an ETCDRestore and agent serving etcd
+// snapshots, and backup-instance run by the backup Jobs.
+func New(config Config) ([]*cobra.Command, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	commands := []*cobra.Command{
+		newAgentCommand(config.Logger),
+		newBackupCommand(config.Logger),
+		newBackupInstanceCommand(config.Logger),
+		newDRBundleCommand(config.Logger),
+		newInspectCommand(config.Logger),
+		newInventoryCommand(config.Logger),
+		newListCommand(config.Logger),
+		newObjectsCommand(config.Logger),
+		newRestoreCommand(config.Logger),
+		newRestoreMemberCommand(config.Logger),
+		newVerifyCommand(config.Logger),
+	}
+
+	for _, c := range commands {
+		silenceUsage(c)
+	}
+
+	return commands, nil
+}
+
+// silenceUsage keeps the command and its subcommands from printing their
+// usage on errors which are not caused by wrong flags.
+func silenceUsage(c *cobra.Command) {
+	c.SilenceUsage = true
+	for _, sub := range c.Commands() {
+		silenceUsage(sub)
+	}
+}
@@ -0,0 +1,108 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/drbundle"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/objects"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+type drBundleCommand struct {
+	logger micrologger.Logger
+	s3     s3Flags
+
+	outputDir string
+}
+
+func newDRBundleCommand(logger micrologger.Logger) *cobra.Command {
+	c := &drBundleCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "dr-bundle <bundle>",
+		Short: "Extract the management cluster objects from a DR bundle.",
+		Long: `Extract the management cluster objects from a DR bundle.
+
+A DR bundle holds the certificates, the kubeconfig and the CAPI manifests of a
+workload cluster and is uploaded next to its backup. The bundle is a local
+file, an s3://bucket/key URL, an https:// URL or the key of an object in
+--bucket. The name of a backup selects the bundle belonging to it. The
+bundle is decrypted with ENCRYPTION_PASSWORD.
+
+The objects are written to stdout as a multi-document YAML stream, or with
+--output-dir to one file per object laid out as
+<group>/<kind>/<namespace>/<name>.yaml.`,
+		Example: "  etcd-backup-operator dr-bundle gauss-foo-v3-2024-05-01T12-00-00.db.tar.gz.enc --bucket backups --region eu-central-1 | kubectl apply -f -",
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	cmd.Flags().StringVar(&c.outputDir, "output-dir", "", "Directory to write one YAML file per object to.")
+
+	return cmd
+}
+
+func (c *drBundleCommand) execute(cmd *cobra.Command, args []string) error {
+	dir, err := os.MkdirTemp("", "etcd-backup-dr-bundle")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	fpath, err := fetch(cmd.Context(), &c.s3, bundleLocation(args[0]), dir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	f, err := os.Open(fpath) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	objs, err := drbundle.Read(f, os.Getenv(key.EncryptionPassword))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if c.outputDir != "" {
+		err = objects.WriteFiles(c.outputDir, objs)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "extracted %d objects to %s\n", len(objs), c.outputDir)
+		return nil
+	}
+
+	err = objects.WriteYAML(cmd.OutOrStdout(), objs)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// bundleLocation returns the location of the bundle belonging to the backup
+// at location, or location itself if it does not name a backup.
+func bundleLocation(location string) string {
+	if strings.HasPrefix(location, httpsScheme) {
+		// The path of presigned URLs can not be changed.
+		return location
+	}
+
+	name, ok := drbundle.Filename(path.Base(location))
+	if !ok {
+		return location
+	}
+
+	return strings.TrimSuffix(location, path.Base(location)) + name
+}
@@ -0,0 +1,132 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/objects"
+)
+
+type objectsDriftCommand struct {
+	logger micrologger.Logger
+	s3     s3Flags
+
+	all     bool
+	details bool
+	json    bool
+}
+
+type driftResult struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+	objects.Report
+}
+
+func newObjectsDriftCommand(logger micrologger.Logger) *cobra.Command {
+	c := &objectsDriftCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "drift <old-backup> <new-backup>",
+		Short: "Report the objects added, removed and modified between two backups.",
+		Long: `Report the objects added, removed and modified between two backups.
+
+The keys and values below /registry/ of both snapshots are compared and the
+changes are grouped by resource, with the difference of the bytes all objects
+of the resource take. Objects are not decoded, so the report also covers
+resources the operator does not know. Resources without changes are only
+printed with --all, the changed objects only with --details. --json prints
+the full report, including every changed object.`,
+		Example: "  etcd-backup-operator objects drift gauss-foo-v3-2024-05-01T02-00-00.db.tar.gz.enc gauss-foo-v3-2024-05-01T08-00-00.db.tar.gz.enc --details",
+		Args:    cobra.ExactArgs(2),
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	cmd.Flags().BoolVar(&c.all, "all", false, "Also print resources without changes.")
+	cmd.Flags().BoolVar(&c.details, "details", false, "Print every changed object.")
+	cmd.Flags().BoolVar(&c.json, "json", false, "Print the report as JSON.")
+
+	return cmd
+}
+
+func (c *objectsDriftCommand) execute(cmd *cobra.Command, args []string) error {
+	dir, err := os.MkdirTemp("", "etcd-backup-objects")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	var snapshots [2][]objects.Object
+	for i, location := range args {
+		db := filepath.Join(dir, fmt.Sprintf("snapshot-%d.db", i))
+		_, err = fetchSnapshot(cmd.Context(), &c.s3, location, dir, db)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		snapshots[i], err = objects.Read(db)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	result := driftResult{
+		Old:    args[0],
+		New:    args[1],
+		Report: objects.NewReport(snapshots[0], snapshots[1]),
+	}
+
+	if c.json {
+		e := json.NewEncoder(cmd.OutOrStdout())
+		e.SetIndent("", "  ")
+		return microerror.Mask(e.Encode(result))
+	}
+
+	c.printReport(cmd.OutOrStdout(), result)
+	return nil
+}
+
+func (c *objectsDriftCommand) printReport(out io.Writer, r driftResult) {
+	_, _ = fmt.Fprintf(out, "%s -> %s: %d added, %d removed, %d modified, %s bytes\n\n", r.Old, r.New, r.Added, r.Removed, r.Modified, signed(r.SizeDelta))
+
+	resources := r.Changed()
+	if c.all {
+		resources = r.Resources
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "RESOURCE\tADDED\tREMOVED\tMODIFIED\tBYTES\tDELTA")
+	for _, rr := range resources {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", rr.Resource, rr.Added, rr.Removed, rr.Modified, rr.NewBytes, signed(rr.SizeDelta))
+	}
+	_ = w.Flush()
+
+	if !c.details {
+		return
+	}
+
+	for _, rr := range resources {
+		if len(rr.Changes) == 0 {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(out, "\n%s:\n", rr.Resource)
+		w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		for _, ch := range rr.Changes {
+			_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\n", ch.Type, ch.Name, signed(ch.SizeDelta))
+		}
+		_ = w.Flush()
+	}
+}
+
+func signed(n int64) string {
+	return fmt.Sprintf("%+d", n)
+}
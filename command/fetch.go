@@ -0,0 +1,145 @@
+package command
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/artifact"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+const (
+	s3Scheme    = "s3://"
+	httpsScheme = "https://"
+)
+
+// fetch makes the backup at location available as a local file in dir and
+// returns its path. The location is either a local file, an s3://bucket/key
+// URL, an https:// URL, e.g. a presigned S3 URL, or the key of an object in
+// the bucket of the flags.
+func fetch(ctx context.Context, s3 *s3Flags, location string, dir string) (string, error) {
+	if _, err := os.Stat(location); err == nil {
+		return location, nil
+	}
+
+	if strings.HasPrefix(location, httpsScheme) {
+		fpath, err := download(ctx, location, dir)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		return fpath, nil
+	}
+
+	bucket := ""
+	objectKey := location
+	if strings.HasPrefix(location, s3Scheme) {
+		parts := strings.SplitN(strings.TrimPrefix(location, s3Scheme), "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return "", microerror.Maskf(invalidFlagError, "%#q is not a valid s3://bucket/key URL", location)
+		}
+		bucket = parts[0]
+		objectKey = parts[1]
+	}
+
+	s, err := s3.storage(bucket)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	fpath := filepath.Join(dir, filepath.Base(objectKey))
+	_, err = s.Download(ctx, objectKey, fpath)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return fpath, nil
+}
+
+// download fetches the backup at the https:// URL into dir. The file is named
+// after the last path segment of the URL, so presigned URLs keep the name of
+// the backup.
+func download(ctx context.Context, location string, dir string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", microerror.Maskf(invalidFlagError, "%#q is not a valid URL: %s", location, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	if res.StatusCode != http.StatusOK {
+		// The query of presigned URLs holds the signature, so it is not
+		// printed.
+		return "", microerror.Maskf(executionFailedError, "downloading %s://%s%s returned %s", u.Scheme, u.Host, u.Path, res.Status)
+	}
+
+	fpath := filepath.Join(dir, path.Base(u.Path))
+	f, err := os.OpenFile(fpath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) //nolint:gosec
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	_, err = io.Copy(f, res.Body)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return fpath, nil
+}
+
+// fetchSnapshot fetches the backup at location into dir and extracts its
+// etcd snapshot to db. Encrypted backups are decrypted with
+// ENCRYPTION_PASSWORD. It returns the layers of the backup, outermost first.
+func fetchSnapshot(ctx context.Context, s3 *s3Flags, location string, dir string, db string) ([]string, error) {
+	fpath, err := fetch(ctx, s3, location, dir)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	in, err := os.Open(fpath) //nolint:gosec
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer in.Close() //nolint:errcheck
+
+	out, err := os.OpenFile(db, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) //nolint:gosec
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer out.Close() //nolint:errcheck
+
+	layers, _, err := artifact.Extract(ctx, in, out, artifact.Options{Passphrase: os.Getenv(key.EncryptionPassword)})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = out.Close()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return layers, nil
+}
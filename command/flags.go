@@ -0,0 +1,61 @@
+package command
+
+import (
+	"os"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/pflag"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/storage"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+// s3Flags configure the bucket backups are stored in. Credentials are read
+// from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or from the default AWS
+// credential chain, e.g. IRSA, when they are not set.
+type s3Flags struct {
+	bucket         string
+	region         string
+	endpoint       string
+	forcePathStyle bool
+}
+
+func (f *s3Flags) register(fs *pflag.FlagSet) {
+	fs.StringVar(&f.bucket, "bucket", "", "AWS S3 Bucket name.")
+	fs.StringVar(&f.region, "region", "", "AWS S3 Region name.")
+	fs.StringVar(&f.endpoint, "s3-endpoint", "", "Custom AWS S3 Endpoint.")
+	fs.BoolVar(&f.forcePathStyle, "s3-force-path-style", false, "Enable path-style S3 URLs.")
+}
+
+// storage returns the S3 storage for the given bucket, or for the bucket of
+// the flags when bucket is empty.
+func (f *s3Flags) storage(bucket string) (*storage.S3Upload, error) {
+	if bucket == "" {
+		bucket = f.bucket
+	}
+	if bucket == "" {
+		return nil, microerror.Maskf(invalidFlagError, "--bucket must not be empty")
+	}
+	if f.region == "" {
+		return nil, microerror.Maskf(invalidFlagError, "--region must not be empty")
+	}
+
+	c := storage.S3Config{
+		Bucket:          bucket,
+		Region:          f.region,
+		Endpoint:        f.endpoint,
+		ForcePathStyle:  f.forcePathStyle,
+		AccessKeyID:     os.Getenv(key.EnvAWSAccessKeyID),
+		SecretAccessKey: os.Getenv(key.EnvAWSSecretAccessKey),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		c.EnableIRSA = true
+	}
+
+	s, err := storage.NewS3Upload(c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,162 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/artifact"
+)
+
+const stdout = "-"
+
+type inspectCommand struct {
+	logger micrologger.Logger
+	s3     s3Flags
+
+	depth  int
+	json   bool
+	output string
+}
+
+type inspectResult struct {
+	Artifact string   `json:"artifact"`
+	Layers   []string `json:"layers"`
+	artifact.Status
+}
+
+func newInspectCommand(logger micrologger.Logger) *cobra.Command {
+	c := &inspectCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "inspect <backup>",
+		Short: "Extract a backup and print the status of its etcd snapshot.",
+		Long: `Extract a backup and print the status of its etcd snapshot.
+
+The backup is a local file, an s3://bucket/key URL or the key of an object in
+--bucket. Every format the operator ever produced is detected from the content:
+plain snapshots, tar.gz archives and OpenPGP encrypted archives, which are
+decrypted with ENCRYPTION_PASSWORD.
+
+The hash, revision, total keys and size of the snapshot are printed like
+etcdutl snapshot status does, followed by the number of keys and bytes below
+every prefix of --depth path segments, largest first. With --output the
+snapshot is written to a file, or to stdout when it is "-", in which case the
+status is printed to stderr.`,
+		Example: "  etcd-backup-operator inspect gauss-foo-v3-2024-05-01T12-00-00.db.tar.gz.enc --depth 3 --output foo.db",
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	cmd.Flags().IntVar(&c.depth, "depth", 2, "Number of path segments keys are grouped by, e.g. 2 for /registry/pods. 0 disables the histogram.")
+	cmd.Flags().BoolVar(&c.json, "json", false, "Print the status as JSON.")
+	cmd.Flags().StringVar(&c.output, "output", "", `Write the etcd snapshot to this file, or to stdout when it is "-".`)
+
+	return cmd
+}
+
+func (c *inspectCommand) execute(cmd *cobra.Command, args []string) error {
+	if c.depth < 0 {
+		return microerror.Maskf(invalidFlagError, "--depth must not be negative")
+	}
+
+	dir, err := os.MkdirTemp("", "etcd-backup-inspect")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	// bbolt needs a file to open the snapshot, so it is always extracted
+	// to disk first.
+	db := c.output
+	if db == "" || db == stdout {
+		db = filepath.Join(dir, "snapshot.db")
+	}
+
+	layers, err := fetchSnapshot(cmd.Context(), &c.s3, args[0], dir, db)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	status, err := artifact.Inspect(db, c.depth)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	out := cmd.OutOrStdout()
+	if c.output == stdout {
+		out = cmd.ErrOrStderr()
+
+		err = copyTo(cmd.OutOrStdout(), db)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	result := inspectResult{
+		Artifact: args[0],
+		Layers:   layers,
+		Status:   status,
+	}
+
+	if c.json {
+		e := json.NewEncoder(out)
+		e.SetIndent("", "  ")
+		return microerror.Mask(e.Encode(result))
+	}
+
+	printStatus(out, result)
+	return nil
+}
+
+func copyTo(w io.Writer, fpath string) error {
+	f, err := os.Open(fpath) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	_, err = io.Copy(w, f)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func printStatus(out io.Writer, r inspectResult) {
+	layers := "none"
+	if len(r.Layers) > 0 {
+		layers = strings.Join(r.Layers, ", ")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "ARTIFACT\t%s\n", r.Artifact)
+	_, _ = fmt.Fprintf(w, "LAYERS\t%s\n", layers)
+	_, _ = fmt.Fprintf(w, "HASH\t%x\n", r.Hash)
+	_, _ = fmt.Fprintf(w, "REVISION\t%d\n", r.Revision)
+	_, _ = fmt.Fprintf(w, "TOTAL KEYS\t%d\n", r.TotalKeys)
+	_, _ = fmt.Fprintf(w, "TOTAL SIZE\t%d\n", r.TotalSize)
+	_ = w.Flush()
+
+	if len(r.Prefixes) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PREFIX\tKEYS\tBYTES")
+	for _, p := range r.Prefixes {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\n", p.Prefix, p.Keys, p.Bytes)
+	}
+	_ = w.Flush()
+}
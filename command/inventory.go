@@ -0,0 +1,94 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/inventory"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/objects"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+type inventoryCommand struct {
+	logger micrologger.Logger
+	s3     s3Flags
+
+	outputDir string
+}
+
+func newInventoryCommand(logger micrologger.Logger) *cobra.Command {
+	c := &inventoryCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "inventory <archive>",
+		Short: "Extract the CAPI inventory of a management cluster.",
+		Long: `Extract the CAPI inventory of a management cluster.
+
+The inventory holds the Cluster, MachineDeployment, KubeadmControlPlane and
+provider cluster objects of the management cluster together with the secrets
+of the workload clusters. It is uploaded next to the backup of the
+management cluster. The archive is a local file, an s3://bucket/key URL, an
+https:// URL or the key of an object in --bucket. Encrypted archives are
+decrypted with ENCRYPTION_PASSWORD.
+
+The manifest of the inventory is printed to stderr. The objects are written
+to stdout as a multi-document YAML stream, or with --output-dir to one file
+per object laid out as <group>/<kind>/<namespace>/<name>.yaml.`,
+		Example: "  etcd-backup-operator inventory gauss-ManagementCluster-inventory-2024-05-01T12-00-00.tar.gz.enc --bucket backups --region eu-central-1 --output-dir inventory",
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	cmd.Flags().StringVar(&c.outputDir, "output-dir", "", "Directory to write one YAML file per object to.")
+
+	return cmd
+}
+
+func (c *inventoryCommand) execute(cmd *cobra.Command, args []string) error {
+	dir, err := os.MkdirTemp("", "etcd-backup-inventory")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	fpath, err := fetch(cmd.Context(), &c.s3, args[0], dir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	f, err := os.Open(fpath) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	m, objs, err := inventory.Read(f, os.Getenv(key.EncryptionPassword))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "inventory of %s taken %s with backup %s\n", m.Installation, m.CreationTimestamp.Format(time.RFC3339), m.Backup)
+
+	if c.outputDir != "" {
+		err = objects.WriteFiles(c.outputDir, objs)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "extracted %d objects to %s\n", len(objs), c.outputDir)
+		return nil
+	}
+
+	err = objects.WriteYAML(cmd.OutOrStdout(), objs)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
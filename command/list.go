@@ -0,0 +1,97 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	etcdkey "github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/key"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+type listCommand struct {
+	logger micrologger.Logger
+	s3     s3Flags
+
+	cluster      string
+	installation string
+}
+
+func newListCommand(logger micrologger.Logger) *cobra.Command {
+	c := &listCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the backups in the bucket.",
+		Long: `List the backups in the bucket.
+
+Artifacts uploaded along with the etcd snapshots are listed too, with their
+kind in the VERSION column: dr for DR bundles of workload clusters and
+inventory for the inventories of the management cluster.`,
+		Example: "  etcd-backup-operator list --installation gauss --cluster foo --bucket backups --region eu-central-1",
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	cmd.Flags().StringVar(&c.cluster, "cluster", "", "Only list the backups of this cluster. Requires --installation.")
+	cmd.Flags().StringVar(&c.installation, "installation", "", "Only list the backups of this installation.")
+
+	return cmd
+}
+
+func (c *listCommand) execute(cmd *cobra.Command, args []string) error {
+	if c.cluster != "" && c.installation == "" {
+		return microerror.Maskf(invalidFlagError, "--installation must be set together with --cluster")
+	}
+
+	var prefix string
+	if c.cluster != "" {
+		prefix = key.FilenamePrefix(c.installation, c.cluster) + "-"
+	} else if c.installation != "" {
+		prefix = c.installation + "-"
+	}
+
+	s, err := c.s3.storage("")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	objects, err := s.List(cmd.Context(), prefix)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tCLUSTER\tVERSION\tCREATED\tSIZE\tENCRYPTED")
+	for _, o := range objects {
+		a, ok := etcdkey.ParseFilename(o.Key)
+		if !ok {
+			continue
+		}
+
+		// A backup of a cluster whose name is the prefix of another cluster
+		// name matches the prefix too, e.g. foo and foo-v3.
+		if c.cluster != "" && a.Prefix != key.FilenamePrefix(c.installation, c.cluster) {
+			continue
+		}
+
+		cluster := a.Prefix
+		if c.installation != "" {
+			cluster = strings.TrimPrefix(a.Prefix, c.installation+"-")
+		}
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%t\n", o.Key, cluster, a.Version, a.Timestamp.Format(time.RFC3339), o.Size, a.Encrypted)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/objects"
+)
+
+// selectorFlags select the Kubernetes objects of a snapshot.
+type selectorFlags struct {
+	objects.Selector
+}
+
+func (f *selectorFlags) register(fs *pflag.FlagSet) {
+	fs.StringVar(&f.Group, "group", "", `API group of the objects, "core" for the core group.`)
+	fs.StringVar(&f.Kind, "kind", "", "Kind of the objects, e.g. ConfigMap.")
+	fs.StringVarP(&f.Namespace, "namespace", "n", "", "Namespace of the objects.")
+	fs.StringVar(&f.Name, "name", "", "Name of the objects.")
+}
+
+func newObjectsCommand(logger micrologger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "objects",
+		Short: "Extract and compare Kubernetes objects stored in backups.",
+		Long: `Extract and compare Kubernetes objects stored in backups.
+
+Backups are local files, s3://bucket/key URLs or keys of objects in --bucket,
+and are decrypted with ENCRYPTION_PASSWORD. export and diff select objects
+with --group, --kind, --namespace and --name.`,
+	}
+
+	cmd.AddCommand(
+		newObjectsExportCommand(logger),
+		newObjectsDiffCommand(logger),
+		newObjectsDriftCommand(logger),
+	)
+
+	return cmd
+}
+
+type objectsExportCommand struct {
+	logger   micrologger.Logger
+	s3       s3Flags
+	selector selectorFlags
+
+	outputDir string
+}
+
+func newObjectsExportCommand(logger micrologger.Logger) *cobra.Command {
+	c := &objectsExportCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "export <backup>",
+		Short: "Export Kubernetes objects from a backup as YAML for kubectl apply.",
+		Long: `Export Kubernetes objects from a backup as YAML for kubectl apply.
+
+The selected objects are written to stdout as a multi-document YAML stream,
+or with --output-dir to one file per object laid out as
+<group>/<kind>/<namespace>/<name>.yaml. Fields set by the API server, like
+the UID, the resource version and the status, are removed.`,
+		Example: "  etcd-backup-operator objects export gauss-foo-v3-2024-05-01T12-00-00.db.tar.gz.enc --kind ConfigMap --namespace kube-system | kubectl apply -f -",
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	c.selector.register(cmd.Flags())
+	cmd.Flags().StringVar(&c.outputDir, "output-dir", "", "Directory to write one YAML file per object to.")
+
+	return cmd
+}
+
+func (c *objectsExportCommand) execute(cmd *cobra.Command, args []string) error {
+	dir, err := os.MkdirTemp("", "etcd-backup-objects")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	db := filepath.Join(dir, "snapshot.db")
+	_, err = fetchSnapshot(cmd.Context(), &c.s3, args[0], dir, db)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	all, err := objects.Read(db)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var selected []*unstructured.Unstructured
+	for _, o := range all {
+		u, err := o.Decode()
+		if objects.IsUndecodableObject(err) {
+			c.logger.Debugf(cmd.Context(), "skipping object: %s", err)
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if c.selector.Matches(u) {
+			selected = append(selected, objects.Applicable(u))
+		}
+	}
+
+	if c.outputDir != "" {
+		err = objects.WriteFiles(c.outputDir, selected)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "exported %d objects to %s\n", len(selected), c.outputDir)
+		return nil
+	}
+
+	err = objects.WriteYAML(cmd.OutOrStdout(), selected)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+type objectsDiffCommand struct {
+	logger   micrologger.Logger
+	s3       s3Flags
+	selector selectorFlags
+}
+
+func newObjectsDiffCommand(logger micrologger.Logger) *cobra.Command {
+	c := &objectsDiffCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "diff <old-backup> <new-backup>",
+		Short: "Show how Kubernetes objects changed between two backups.",
+		Long: `Show how Kubernetes objects changed between two backups.
+
+Every selected object which was added, removed or modified is printed with
+the difference of its content, ignoring managed fields.`,
+		Example: "  etcd-backup-operator objects diff gauss-foo-v3-2024-05-01T02-00-00.db.tar.gz.enc gauss-foo-v3-2024-05-01T08-00-00.db.tar.gz.enc --namespace kube-system",
+		Args:    cobra.ExactArgs(2),
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	c.selector.register(cmd.Flags())
+
+	return cmd
+}
+
+func (c *objectsDiffCommand) execute(cmd *cobra.Command, args []string) error {
+	dir, err := os.MkdirTemp("", "etcd-backup-objects")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	var snapshots [2][]objects.Object
+	for i, location := range args {
+		db := filepath.Join(dir, fmt.Sprintf("snapshot-%d.db", i))
+		_, err = fetchSnapshot(cmd.Context(), &c.s3, location, dir, db)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		snapshots[i], err = objects.Read(db)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	for _, change := range objects.Diff(snapshots[0], snapshots[1]) {
+		old, new, err := change.Decode()
+		if objects.IsUndecodableObject(err) {
+			c.logger.Debugf(cmd.Context(), "skipping object: %s", err)
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		u := new
+		if u == nil {
+			u = old
+		}
+		if !c.selector.Matches(u) {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(out, "%s %s %s\n", change.Type, u.GetKind(), namespacedName(u))
+		_, _ = fmt.Fprintln(out, objects.ObjectDiff(old, new))
+	}
+
+	return nil
+}
+
+func namespacedName(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return u.GetName()
+	}
+
+	return u.GetNamespace() + "/" + u.GetName()
+}
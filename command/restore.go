@@ -0,0 +1,94 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+)
+
+type restoreCommand struct {
+	logger  micrologger.Logger
+	s3      s3Flags
+	options etcd.RestoreOptions
+
+	from string
+}
+
+func newRestoreCommand(logger micrologger.Logger) *cobra.Command {
+	c := &restoreCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a backup into a new etcd data directory.",
+		Long: `Restore a backup into a new etcd data directory.
+
+The backup given by --from is a local file, an s3://bucket/key URL or the key
+of an object in --bucket. It is decrypted with ENCRYPTION_PASSWORD, verified
+and restored with etcdutl, which must be in the PATH. Restoring a
+multi-member cluster requires running the command on every member with its
+own --name and --initial-advertise-peer-urls.`,
+		Example: "  etcd-backup-operator restore --from s3://backups/gauss-foo-v3-2024-05-01T12-00-00.db.tar.gz.enc --region eu-central-1 --data-dir /var/lib/etcd-restored",
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+	cmd.Flags().StringVar(&c.from, "from", "", "Backup to restore.")
+	cmd.Flags().StringVar(&c.options.DataDir, "data-dir", "", "Data directory to restore the snapshot into. It must not exist.")
+	cmd.Flags().StringVar(&c.options.Name, "name", "", "Human-readable name of the etcd member.")
+	cmd.Flags().StringVar(&c.options.InitialCluster, "initial-cluster", "", "Initial cluster configuration of the restored cluster.")
+	cmd.Flags().StringVar(&c.options.InitialClusterToken, "initial-cluster-token", "", "Initial cluster token of the restored cluster.")
+	cmd.Flags().StringVar(&c.options.InitialAdvertisePeerURLs, "initial-advertise-peer-urls", "", "Peer URLs of the etcd member.")
+	cmd.Flags().BoolVar(&c.options.SkipHashCheck, "skip-hash-check", false, "Ignore the snapshot hash, e.g. for snapshots copied from a data directory.")
+
+	return cmd
+}
+
+func (c *restoreCommand) execute(cmd *cobra.Command, args []string) error {
+	if c.from == "" {
+		return microerror.Maskf(invalidFlagError, "--from must not be empty")
+	}
+	if c.options.DataDir == "" {
+		return microerror.Maskf(invalidFlagError, "--data-dir must not be empty")
+	}
+	if _, err := os.Stat(c.options.DataDir); err == nil {
+		return microerror.Maskf(invalidFlagError, "--data-dir %#q already exists", c.options.DataDir)
+	}
+	err := etcd.CheckRestorable(c.from)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	dir, err := os.MkdirTemp("", "etcd-backup-restore")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	db := filepath.Join(dir, "snapshot.db")
+	_, err = fetchSnapshot(cmd.Context(), &c.s3, c.from, dir, db)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !c.options.SkipHashCheck {
+		_, err = etcd.VerifySnapshot(db)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	out, err := etcd.RestoreSnapshot(cmd.Context(), db, c.options)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s", out)
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "restored %s into %s\n", c.from, c.options.DataDir)
+	return nil
+}
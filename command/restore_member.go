@@ -0,0 +1,65 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/restore"
+)
+
+type restoreMemberCommand struct {
+	logger  micrologger.Logger
+	options restore.SwapOptions
+
+	notBefore string
+}
+
+func newRestoreMemberCommand(logger micrologger.Logger) *cobra.Command {
+	c := &restoreMemberCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "restore-member",
+		Short: "Swap the data directory of an etcd static pod with a restored one.",
+		Long: `Swap the data directory of an etcd static pod with a restored one.
+
+This runs on a control plane node, in the pods the operator creates for an
+ETCDRestore. At --not-before the etcd static pod manifest is moved out of the
+manifests directory, and once etcd stopped listening on --client-address the
+data directory is replaced with --restored-data-dir and the manifest is moved
+back. The previous data directory is kept with --suffix appended.`,
+		Hidden: true,
+		RunE:   c.execute,
+	}
+
+	cmd.Flags().StringVar(&c.options.Manifest, "manifest", restore.ManifestPath, "etcd static pod manifest.")
+	cmd.Flags().StringVar(&c.options.DataDir, "data-dir", "", "Data directory of the member.")
+	cmd.Flags().StringVar(&c.options.RestoredDataDir, "restored-data-dir", "", "Directory the backup was restored into.")
+	cmd.Flags().StringVar(&c.options.Suffix, "suffix", "", "Suffix of the previous data directory and of the parked manifest.")
+	cmd.Flags().StringVar(&c.options.ClientAddress, "client-address", "127.0.0.1:2379", "Address etcd serves clients on, probed until etcd stopped.")
+	cmd.Flags().DurationVar(&c.options.StopTimeout, "stop-timeout", 5*time.Minute, "Time etcd may take to stop.")
+	cmd.Flags().StringVar(&c.notBefore, "not-before", "", "RFC 3339 time before which etcd is not stopped.")
+
+	return cmd
+}
+
+func (c *restoreMemberCommand) execute(cmd *cobra.Command, args []string) error {
+	if c.notBefore != "" {
+		t, err := time.Parse(time.RFC3339, c.notBefore)
+		if err != nil {
+			return microerror.Maskf(invalidFlagError, "--not-before must be an RFC 3339 time: %s", err)
+		}
+		c.options.NotBefore = t
+	}
+
+	err := restore.Swap(cmd.Context(), c.options)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s restored from %s\n", c.options.DataDir, c.options.RestoredDataDir)
+	return nil
+}
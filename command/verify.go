@@ -0,0 +1,66 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+)
+
+type verifyCommand struct {
+	logger micrologger.Logger
+	s3     s3Flags
+}
+
+func newVerifyCommand(logger micrologger.Logger) *cobra.Command {
+	c := &verifyCommand{logger: logger}
+
+	cmd := &cobra.Command{
+		Use:   "verify <backup>",
+		Short: "Verify the integrity of a backup.",
+		Long: `Verify the integrity of a backup.
+
+The backup is a local file, an s3://bucket/key URL or the key of an object in
+--bucket. It is decrypted with ENCRYPTION_PASSWORD, extracted, and the hash
+etcd appended to the snapshot is checked.`,
+		Example: "  etcd-backup-operator verify s3://backups/gauss-foo-v3-2024-05-01T12-00-00.db.tar.gz.enc --region eu-central-1",
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.execute,
+	}
+
+	c.s3.register(cmd.Flags())
+
+	return cmd
+}
+
+func (c *verifyCommand) execute(cmd *cobra.Command, args []string) error {
+	err := etcd.CheckRestorable(args[0])
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	dir, err := os.MkdirTemp("", "etcd-backup-verify")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	db := filepath.Join(dir, "snapshot.db")
+	_, err = fetchSnapshot(cmd.Context(), &c.s3, args[0], dir, db)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	size, err := etcd.VerifySnapshot(db)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s: OK, snapshot size %d bytes\n", args[0], size)
+	return nil
+}
@@ -0,0 +1,5 @@
+package service
+
+type DRBundle struct {
+	Enabled string
+}
@@ -0,0 +1,5 @@
+package service
+
+type Encryption struct {
+	Enabled string
+}
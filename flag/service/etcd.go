@@ -5,4 +5,11 @@ type ETCDv3Settings struct {
 	CaCert    string
 	Key       string
 	Cert      string
+	// ReloadInterval is how often the certificates are read again to pick
+	// up rotated ones.
+	ReloadInterval string
+	// ServerName is the name expected in the server certificate. Defaults
+	// to the host of the endpoint.
+	ServerName         string
+	InsecureSkipVerify string
 }
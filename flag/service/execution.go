@@ -0,0 +1,11 @@
+package service
+
+type Execution struct {
+	Mode string
+	Job  ExecutionJob
+}
+
+type ExecutionJob struct {
+	Template  string
+	Namespace string
+}
@@ -0,0 +1,6 @@
+package service
+
+type History struct {
+	Name      string
+	Namespace string
+}
@@ -0,0 +1,5 @@
+package service
+
+type Inventory struct {
+	Enabled string
+}
@@ -0,0 +1,6 @@
+package service
+
+type Notifications struct {
+	DedupWindow string
+	States      string
+}
@@ -0,0 +1,7 @@
+package service
+
+type Restore struct {
+	Image     string
+	SwapDelay string
+	Timeout   string
+}
@@ -0,0 +1,7 @@
+package service
+
+type RPO struct {
+	Default  string
+	Interval string
+	Rules    string
+}
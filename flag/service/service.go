@@ -14,4 +14,13 @@ type Service struct {
 	Sentry                      Sentry
 	BackupDestination           string
 	EnableIRSA                  string
+	Timeouts                    Timeouts
+	History                     History
+	RPO                         RPO
+	Notifications               Notifications
+	Restore                     Restore
+	Encryption                  Encryption
+	DRBundle                    DRBundle
+	Inventory                   Inventory
+	Execution                   Execution
 }
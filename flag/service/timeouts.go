@@ -0,0 +1,7 @@
+package service
+
+type Timeouts struct {
+	Create  string
+	Encrypt string
+	Upload  string
+}
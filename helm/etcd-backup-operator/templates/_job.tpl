@@ -0,0 +1,118 @@
+{{/* vim: set filetype=mustache: */}}
+{{/*
+Pod template of the backup Jobs, read by the operator when
+execution.mode is "job". The Jobs run on the control plane nodes like the
+operator, so that they reach the etcd of the management cluster with the
+same endpoints and certificates. They are not labelled with the selector
+labels of the operator, which would add them to its Service.
+*/}}
+{{- define "job.template" -}}
+metadata:
+  generateName: {{ include "resource.default.name" . }}-job-
+  labels:
+    app.kubernetes.io/name: {{ printf "%s-job" (include "name" .) | quote }}
+    app.kubernetes.io/instance: {{ .Release.Name | quote }}
+    application.giantswarm.io/team: {{ index .Chart.Annotations "application.giantswarm.io/team" | quote }}
+    app.kubernetes.io/managed-by: {{ .Release.Service | quote }}
+spec:
+{{- if .Values.priorityClassName }}
+  priorityClassName: {{ .Values.priorityClassName }}
+{{- end }}
+  serviceAccountName: {{ include "resource.default.name" . }}
+  securityContext:
+    fsGroup: {{ .Values.pod.group.id }}
+    {{- with .Values.podSecurityContext }}
+      {{- . | toYaml | nindent 4 }}
+    {{- end }}
+  hostNetwork: true
+  dnsPolicy: ClusterFirstWithHostNet
+  tolerations:
+    - key: node-role.kubernetes.io/master
+      operator: Exists
+      effect: NoSchedule
+{{- if semverCompare ">=1.24.0" .Capabilities.KubeVersion.Version }}
+    - key: node-role.kubernetes.io/control-plane
+      operator: Exists
+      effect: NoSchedule
+{{- end }}
+    {{- with .Values.execution.job.tolerations }}
+      {{- . | toYaml | nindent 4 }}
+    {{- end }}
+  nodeSelector:
+{{- if semverCompare ">=1.24.0" .Capabilities.KubeVersion.Version }}
+    node-role.kubernetes.io/control-plane: ""
+{{- else }}
+    node-role.kubernetes.io/master: ""
+{{- end }}
+    {{- with .Values.execution.job.nodeSelector }}
+      {{- . | toYaml | nindent 4 }}
+    {{- end }}
+  volumes:
+  - name: etcd-certs
+    hostPath:
+      path: {{ .Values.clientCertsDir }}
+  - name: scratch
+{{- if .Values.execution.job.scratch.size }}
+    ephemeral:
+      volumeClaimTemplate:
+        spec:
+          accessModes:
+          - ReadWriteOnce
+{{- if .Values.execution.job.scratch.storageClassName }}
+          storageClassName: {{ .Values.execution.job.scratch.storageClassName | quote }}
+{{- end }}
+          resources:
+            requests:
+              storage: {{ .Values.execution.job.scratch.size | quote }}
+{{- else }}
+    emptyDir: {}
+{{- end }}
+  containers:
+  - name: backup
+    image: "{{ .Values.registry.domain }}/{{ .Values.image.name }}:{{ include "image.tag" . }}"
+    args:
+    - {{ printf "--bucket=%v" .Values.aws.s3bucket | quote }}
+    - {{ printf "--region=%v" .Values.aws.s3region | quote }}
+    - {{ printf "--endpoints=%v" .Values.etcdEndpoints | quote }}
+    - {{ printf "--cacert=/certs/%s" .Values.clientCaCertFileName | quote }}
+    - {{ printf "--cert=/certs/%s" .Values.clientCertFileName | quote }}
+    - {{ printf "--key=/certs/%s" .Values.clientKeyFileName | quote }}
+    - {{ printf "--server-name=%v" .Values.etcdServerName | quote }}
+    - {{ printf "--insecure-skip-tls-verify=%v" .Values.etcdInsecureSkipVerify | quote }}
+    volumeMounts:
+    - mountPath: /certs
+      name: etcd-certs
+    - mountPath: /scratch
+      name: scratch
+    env:
+      # The snapshot and its encrypted copy are written to the scratch
+      # volume.
+      - name: TMPDIR
+        value: /scratch
+      - name: AWS_ACCESS_KEY_ID
+        valueFrom:
+          secretKeyRef:
+            name: {{ include "resource.default.name" . }}
+            key: ETCDBACKUP_AWS_ACCESS_KEY
+      - name: AWS_SECRET_ACCESS_KEY
+        valueFrom:
+          secretKeyRef:
+            name: {{ include "resource.default.name" . }}
+            key: ETCDBACKUP_AWS_SECRET_KEY
+      - name: ENCRYPTION_PASSWORD
+        valueFrom:
+          secretKeyRef:
+            name: {{ include "resource.default.name" . }}
+            key: ETCDBACKUP_ENCRYPTION_PASSWORD
+      - name: AGENT_SIGNING_KEY
+        valueFrom:
+          secretKeyRef:
+            name: {{ include "resource.default.name" . }}
+            key: ETCDBACKUP_AGENT_SIGNING_KEY
+    securityContext:
+      {{- with .Values.securityContext }}
+        {{- . | toYaml | nindent 6 }}
+      {{- end }}
+    resources:
+      {{- .Values.execution.job.resources | toYaml | nindent 6 }}
+{{- end -}}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/microkit/command"
@@ -11,10 +12,12 @@ import (
 	"github.com/spf13/viper"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	cli "github.com/giantswarm/etcd-backup-operator/v5/command"
 	"github.com/giantswarm/etcd-backup-operator/v5/flag"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/project"
 	"github.com/giantswarm/etcd-backup-operator/v5/server"
 	"github.com/giantswarm/etcd-backup-operator/v5/service"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
 )
 
 var (
@@ -123,10 +126,46 @@ func mainE(ctx context.Context) error {
 	daemonCommand.PersistentFlags().String(f.Service.ETCDv3.Cert, "", "Client certificate for ETCD v3 connection")
 	daemonCommand.PersistentFlags().String(f.Service.ETCDv3.CaCert, "", "Client CA certificate for ETCD v3 connection")
 	daemonCommand.PersistentFlags().String(f.Service.ETCDv3.Key, "", "Client private key for ETCD v3 connection")
-	daemonCommand.PersistentFlags().String(f.Service.ETCDv3.Endpoints, "", "Endpoints for ETCD v3 connection")
+	daemonCommand.PersistentFlags().String(f.Service.ETCDv3.Endpoints, "", "Comma separated endpoints of the members of the ETCD v3 cluster")
+	daemonCommand.PersistentFlags().Duration(f.Service.ETCDv3.ReloadInterval, time.Minute, "How often the ETCD v3 client certificates are read again to pick up rotated ones.")
+	daemonCommand.PersistentFlags().String(f.Service.ETCDv3.ServerName, "", "Name expected in the ETCD v3 server certificate. Defaults to the host of the endpoint.")
+	daemonCommand.PersistentFlags().Bool(f.Service.ETCDv3.InsecureSkipVerify, false, "Do not verify the ETCD v3 server certificate.")
 	daemonCommand.PersistentFlags().String(f.Service.Installation, "", "Name of the installation")
 	daemonCommand.PersistentFlags().String(f.Service.Sentry.DSN, "", "DSN of the Sentry instance to forward errors to.")
 	daemonCommand.PersistentFlags().Bool(f.Service.EnableIRSA, false, "Enable IAM Roles for Service Accounts (IRSA) for S3 access.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Timeouts.Create, 30*time.Minute, "Timeout of the backup creation stage (snapshot, compaction and defragmentation). Zero disables the timeout.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Timeouts.Encrypt, 10*time.Minute, "Timeout of the backup encryption stage. Zero disables the timeout.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Timeouts.Upload, 30*time.Minute, "Timeout of the backup upload stage. Zero disables the timeout.")
+	daemonCommand.PersistentFlags().String(f.Service.History.Name, "etcd-backup-operator-history", "Name of the ConfigMap the backup history is persisted in.")
+	daemonCommand.PersistentFlags().String(f.Service.History.Namespace, "giantswarm", "Namespace of the ConfigMap the backup history is persisted in.")
+	daemonCommand.PersistentFlags().Duration(f.Service.RPO.Default, 0, "Default recovery point objective, i.e. maximum age of the latest successful backup of a cluster. Zero disables the evaluation.")
+	daemonCommand.PersistentFlags().Duration(f.Service.RPO.Interval, time.Minute, "Interval in which the recovery point objective of all clusters is evaluated.")
+	daemonCommand.PersistentFlags().String(f.Service.RPO.Rules, "", "JSON list of recovery point objectives per cluster regex, e.g. [{\"clusters\":\"^prod-\",\"clustersToExclude\":\"^$\",\"rpo\":\"6h\"}].")
+	daemonCommand.PersistentFlags().Duration(f.Service.Notifications.DedupWindow, time.Hour, "Period in which identical notifications are only sent once.")
+	daemonCommand.PersistentFlags().String(f.Service.Notifications.States, "Completed,Failed", "Comma separated global ETCDBackup states whose transitions are notified. Empty notifies all transitions.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Encryption.Enabled, false, "Encrypt the backups with the encryption password. Inline backups require it in the environment of the operator, backups run in Jobs in the one of the Jobs.")
+	daemonCommand.PersistentFlags().Bool(f.Service.DRBundle.Enabled, false, "Upload an encrypted DR bundle with the certificates, kubeconfig and CAPI manifests of workload clusters next to their backups. Requires encryption.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Inventory.Enabled, false, "Upload the CAPI inventory of the management cluster as an encrypted YAML archive next to its backups. Requires encryption.")
+	daemonCommand.PersistentFlags().String(f.Service.Restore.Image, "", "Image of the operator, run on the control plane nodes of workload clusters to restore etcd. Empty only allows dry runs.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Restore.SwapDelay, 2*time.Minute, "Time between scheduling the restore pods which stop etcd and stopping etcd on all members at once.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Restore.Timeout, time.Hour, "Time after which an ETCDRestore which did not finish is failed.")
+	daemonCommand.PersistentFlags().String(f.Service.Execution.Mode, key.ExecutionModeInline, "How the backups of the instances are run: inline in the operator, or job to run each of them in a Kubernetes Job.")
+	daemonCommand.PersistentFlags().String(f.Service.Execution.Job.Template, "", "Path to the YAML pod template of the backup Jobs.")
+	daemonCommand.PersistentFlags().String(f.Service.Execution.Job.Namespace, "", "Namespace the backup Jobs are created in.")
+
+	// Standalone subcommands, usable without the controller.
+	{
+		c := cli.Config{
+			Logger: logger,
+		}
+
+		cliCommands, err := cli.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		newCommand.CobraCommand().AddCommand(cliCommands...)
+	}
 
 	err = newCommand.CobraCommand().Execute()
 	if err != nil {
@@ -0,0 +1,98 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ETCDEndpoint declares an etcd cluster which is not part of a management or
+// workload cluster, e.g. the kvstore of Cilium or the storage of Vault. It is
+// backed up like the etcd of a workload cluster named like the ETCDEndpoint.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Endpoints",type=string,JSONPath=`.spec.endpoints`
+// +kubebuilder:printcolumn:name="Suspended",type=boolean,JSONPath=`.spec.backup.suspend`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ETCDEndpoint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ETCDEndpointSpec `json:"spec"`
+}
+
+type ETCDEndpointSpec struct {
+	// Endpoints are the client URLs of the etcd members, e.g.
+	// https://etcd-0.etcd:2379. With a proxy they are the names of the
+	// pods to port-forward to instead. The first healthy one is backed
+	// up.
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []string `json:"endpoints"`
+	// Datastore is the kind of the datastore behind the endpoints: etcd,
+	// embedded for the etcd embedded in k3s and RKE2 servers, which is not
+	// defragmented, or kine for kine datastores, which are exported key by
+	// key. Defaults to etcd.
+	// +kubebuilder:validation:Enum=etcd;embedded;kine
+	Datastore string `json:"datastore,omitempty"`
+	// TLS references the client certificate used to connect to etcd.
+	TLS ETCDEndpointTLS `json:"tls"`
+	// Proxy connects to etcd through a port-forward of the Kubernetes API
+	// instead of dialing the endpoints directly.
+	Proxy *ETCDEndpointProxy `json:"proxy,omitempty"`
+	// Backup is the backup policy of the etcd cluster.
+	Backup ETCDEndpointBackupPolicy `json:"backup,omitempty"`
+}
+
+type ETCDEndpointTLS struct {
+	// SecretName is the name of a secret in the namespace of the
+	// ETCDEndpoint holding the CA in ca.crt and the client certificate and
+	// key in tls.crt and tls.key. For embedded datastores it holds the files
+	// of the etcd TLS directory of the server instead, i.e. server-ca.crt,
+	// client.crt and client.key.
+	SecretName string `json:"secretName"`
+	// ServerName is the name expected in the server certificates of the
+	// members. Defaults to the host of the endpoint, which must then be a
+	// DNS name.
+	ServerName string `json:"serverName,omitempty"`
+	// InsecureSkipVerify disables the verification of the server
+	// certificates of the members.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+type ETCDEndpointProxy struct {
+	// KubeconfigSecretRef references the kubeconfig of the cluster running
+	// etcd, in the namespace of the ETCDEndpoint. The management cluster is
+	// used when it is not set.
+	KubeconfigSecretRef *SecretKeyReference `json:"kubeconfigSecretRef,omitempty"`
+	// Namespace is the namespace of the pods named by the endpoints. It
+	// must be the namespace of the ETCDEndpoint when KubeconfigSecretRef is
+	// not set.
+	Namespace string `json:"namespace"`
+	// Port is the etcd client port of the pods. Defaults to 2379.
+	Port int `json:"port,omitempty"`
+}
+
+type SecretKeyReference struct {
+	// Name is the name of the secret.
+	Name string `json:"name"`
+	// Key is the key of the value in the secret. Defaults to value.
+	Key string `json:"key,omitempty"`
+}
+
+type ETCDEndpointBackupPolicy struct {
+	// Suspend stops the backups of the etcd cluster.
+	Suspend bool `json:"suspend,omitempty"`
+	// Timeouts bound the stages of a backup attempt like the timeout
+	// annotations of workload clusters.
+	Timeouts *ETCDEndpointTimeouts `json:"timeouts,omitempty"`
+}
+
+type ETCDEndpointTimeouts struct {
+	Create  *metav1.Duration `json:"create,omitempty"`
+	Encrypt *metav1.Duration `json:"encrypt,omitempty"`
+	Upload  *metav1.Duration `json:"upload,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ETCDEndpointList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ETCDEndpoint `json:"items"`
+}
@@ -0,0 +1,80 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ETCDRestore restores a backup into the etcd cluster of a CAPI workload
+// cluster with a kubeadm control plane. It lives in the namespace of the
+// Cluster.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.cluster`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Started",type=date,JSONPath=`.status.startedTimestamp`
+// +kubebuilder:printcolumn:name="Finished",type=date,JSONPath=`.status.finishedTimestamp`
+type ETCDRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ETCDRestoreSpec   `json:"spec"`
+	Status            ETCDRestoreStatus `json:"status,omitempty"`
+}
+
+type ETCDRestoreSpec struct {
+	// Cluster is the name of the CAPI Cluster to restore.
+	Cluster string `json:"cluster"`
+	// Backup is the key of the backup in the bucket of the operator, as
+	// printed by the list command.
+	Backup string `json:"backup"`
+	// DryRun only plans the restore and records the plan in the status,
+	// without touching the cluster.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+type ETCDRestoreStatus struct {
+	// Phase is the progress of the restore. Planned, Completed and Failed
+	// are final.
+	Phase string `json:"phase,omitempty"`
+	// Message explains the phase, e.g. why the restore failed.
+	Message string `json:"message,omitempty"`
+	// Plan lists the steps of the restore.
+	Plan []string `json:"plan,omitempty"`
+	// ClusterToken is the new initial cluster token of the restored members.
+	ClusterToken string `json:"clusterToken,omitempty"`
+	// PreviousClusterID is the ID of the etcd cluster before the restore.
+	// Restored members form a new cluster with a different ID.
+	PreviousClusterID string `json:"previousClusterID,omitempty"`
+	// Members are the etcd members being restored.
+	Members []ETCDRestoreMemberStatus `json:"members,omitempty"`
+	// SwapNotBefore is the time the members stop etcd to swap their data
+	// directory, once all of them are ready.
+	SwapNotBefore *metav1.Time `json:"swapNotBefore,omitempty"`
+
+	StartedTimestamp  *metav1.Time `json:"startedTimestamp,omitempty"`
+	FinishedTimestamp *metav1.Time `json:"finishedTimestamp,omitempty"`
+}
+
+type ETCDRestoreMemberStatus struct {
+	// Name is the etcd member name.
+	Name string `json:"name"`
+	// Node is the control plane node running the member.
+	Node string `json:"node"`
+	// Pod is the etcd static pod of the member.
+	Pod string `json:"pod"`
+	// PeerURL is the URL the member advertises to its peers.
+	PeerURL string `json:"peerURL"`
+	// DataDir is the data directory of the member on the node.
+	DataDir string `json:"dataDir"`
+	// Phase is the progress of the restore of this member.
+	Phase string `json:"phase,omitempty"`
+	// Message explains the phase.
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+type ETCDRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ETCDRestore `json:"items"`
+}
@@ -0,0 +1,33 @@
+// Package v1alpha1 contains the custom resources of the operator which are
+// not part of apiextensions-backup. They share its backup.giantswarm.io API
+// group.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// GroupVersion is the group and version of the custom resources.
+	GroupVersion = schema.GroupVersion{Group: "backup.giantswarm.io", Version: "v1alpha1"}
+
+	// SchemeBuilder registers the custom resources with a scheme.
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+	// AddToScheme adds the custom resources to a scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(s *runtime.Scheme) error {
+	s.AddKnownTypes(GroupVersion,
+		&ETCDEndpoint{},
+		&ETCDEndpointList{},
+		&ETCDRestore{},
+		&ETCDRestoreList{},
+	)
+	metav1.AddToGroupVersion(s, GroupVersion)
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package backupjob
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var missingResultError = &microerror.Error{
+	Kind: "missingResultError",
+}
+
+// IsMissingResult asserts missingResultError.
+func IsMissingResult(err error) bool {
+	return microerror.Cause(err) == missingResultError
+}
@@ -0,0 +1,222 @@
+// Package backupjob builds the Kubernetes Jobs backing up single instances
+// when the operator runs its backups as Jobs, and carries their results back
+// to the operator.
+package backupjob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Command is the subcommand of the operator run by the backup Jobs.
+	Command = "backup-instance"
+
+	// LabelBackup is set to the name of the ETCDBackup on its Jobs and their
+	// pods, LabelInstance to the name of the instance they back up.
+	LabelBackup   = "backup.giantswarm.io/backup"
+	LabelInstance = "backup.giantswarm.io/instance"
+
+	// ResultPath is where the backup Jobs write their Result. It is the
+	// termination message path of their container.
+	ResultPath = corev1.TerminationMessagePathDefault
+
+	// defaultNamePrefix prefixes the names of the Jobs whose template has no
+	// GenerateName.
+	defaultNamePrefix = "etcd-backup-"
+	// maxNamePrefixLength leaves room for the hash in the 63 characters Job
+	// names may have.
+	maxNamePrefixLength = 47
+)
+
+// Config configures the Jobs.
+type Config struct {
+	// Template is the pod template of the Jobs. Its first container runs the
+	// image of the operator, the arguments of the backup are appended to its
+	// args. It carries the resources, node selectors, volumes and the
+	// environment the backup needs, e.g. the encryption password and the
+	// S3 credentials. Its
+	// GenerateName prefixes the names of the Jobs.
+	Template corev1.PodTemplateSpec
+	// Namespace is the namespace the Jobs are created in.
+	Namespace string
+}
+
+// Spec is the backup of an instance run by a Job.
+type Spec struct {
+	// Backup is the name of the ETCDBackup the backup is taken for, Owner
+	// references it. The Job is deleted with the ETCDBackup.
+	Backup string
+	Owner  metav1.OwnerReference
+
+	Instance     string
+	Installation string
+	// Encrypt encrypts the backup with the encryption password of the
+	// template. DRBundle and Inventory upload the DR bundle and the
+	// inventory of the instance with its backup, they require Encrypt.
+	Encrypt   bool
+	DRBundle  bool
+	Inventory bool
+
+	CreateTimeout  time.Duration
+	EncryptTimeout time.Duration
+	UploadTimeout  time.Duration
+	// Deadline bounds the whole Job, zero leaves it unbounded.
+	Deadline time.Duration
+}
+
+// ReadTemplate reads the pod template of the Jobs from the YAML file at
+// path. Unknown fields are rejected, so that misspelled settings are not
+// silently dropped.
+func ReadTemplate(path string) (corev1.PodTemplateSpec, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return corev1.PodTemplateSpec{}, microerror.Mask(err)
+	}
+
+	var template corev1.PodTemplateSpec
+	err = yaml.UnmarshalStrict(data, &template)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, microerror.Maskf(invalidConfigError, "invalid pod template %#q: %s", path, err)
+	}
+
+	return template, nil
+}
+
+// Validate checks that the template can run backups.
+func (c Config) Validate() error {
+	if c.Namespace == "" {
+		return microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", c)
+	}
+	if len(c.Template.Spec.Containers) == 0 {
+		return microerror.Maskf(invalidConfigError, "%T.Template must have a container", c)
+	}
+	if c.Template.Spec.Containers[0].Image == "" {
+		return microerror.Maskf(invalidConfigError, "%T.Template must set the image of its first container", c)
+	}
+
+	return nil
+}
+
+// Name returns the name of the Job backing up instance for the ETCDBackup
+// backup. Names are hashed, so that they fit into labels whatever the length
+// of the names of the backup and the instance.
+func (c Config) Name(backup string, instance string) string {
+	prefix := c.Template.GenerateName
+	if prefix == "" {
+		prefix = defaultNamePrefix
+	}
+	if len(prefix) > maxNamePrefixLength {
+		prefix = prefix[:maxNamePrefixLength]
+	}
+
+	sum := sha256.Sum256([]byte(backup + "/" + instance))
+	return prefix + hex.EncodeToString(sum[:])[:16]
+}
+
+// Job returns the Job running the backup of s.
+func (c Config) Job(s Spec) *batchv1.Job {
+	labels := map[string]string{
+		LabelBackup:   s.Backup,
+		LabelInstance: s.Instance,
+	}
+
+	template := *c.Template.DeepCopy()
+	template.GenerateName = ""
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		template.Labels[k] = v
+	}
+	// The Job is not retried, the backup command retries failed attempts
+	// itself.
+	template.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	container := &template.Spec.Containers[0]
+	container.Args = append([]string{Command}, container.Args...)
+	container.Args = append(container.Args,
+		"--instance", s.Instance,
+		"--installation", s.Installation,
+		"--create-timeout", s.CreateTimeout.String(),
+		"--encrypt-timeout", s.EncryptTimeout.String(),
+		"--upload-timeout", s.UploadTimeout.String(),
+		fmt.Sprintf("--encrypt=%t", s.Encrypt),
+		fmt.Sprintf("--dr-bundle=%t", s.DRBundle),
+		fmt.Sprintf("--inventory=%t", s.Inventory),
+	)
+	// The Result is read from the termination message, which must not be
+	// replaced with the log of the container.
+	container.TerminationMessagePath = ResultPath
+	container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+
+	backoffLimit := int32(0)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            c.Name(s.Backup, s.Instance),
+			Namespace:       c.Namespace,
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{s.Owner},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     template,
+		},
+	}
+	if s.Deadline > 0 {
+		deadline := int64(s.Deadline.Seconds())
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+
+	return job
+}
+
+// Finished returns whether job finished, and whether it succeeded.
+func Finished(job *batchv1.Job) (bool, bool) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, true
+		case batchv1.JobFailed:
+			return true, false
+		}
+	}
+
+	return false, false
+}
+
+// FailureReason returns the reason of the failure condition of job, e.g.
+// batchv1.JobReasonDeadlineExceeded, or an empty string when it did not fail.
+func FailureReason(job *batchv1.Job) string {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return c.Reason
+		}
+	}
+
+	return ""
+}
+
+// FailureMessage returns why job failed as reported by Kubernetes, e.g. when
+// it exceeded its deadline.
+func FailureMessage(job *batchv1.Job) string {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return fmt.Sprintf("%s: %s", c.Reason, c.Message)
+		}
+	}
+
+	return fmt.Sprintf("job %s failed", job.Name)
+}
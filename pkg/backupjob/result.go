@@ -0,0 +1,74 @@
+package backupjob
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxErrorLength keeps the Result within the 4096 bytes Kubernetes keeps of
+// termination messages.
+const maxErrorLength = 3072
+
+// Result is the outcome of the backup run by a Job. Times are in
+// milliseconds, like the ones of the instance status.
+type Result struct {
+	Filename       string `json:"filename,omitempty"`
+	Size           int64  `json:"size,omitempty"`
+	CreationTime   int64  `json:"creationTime,omitempty"`
+	EncryptionTime int64  `json:"encryptionTime,omitempty"`
+	UploadTime     int64  `json:"uploadTime,omitempty"`
+	Retries        int    `json:"retries,omitempty"`
+	// Error is the latest error of a failed backup.
+	Error string `json:"error,omitempty"`
+	// ErrorClass is the class of Error as exposed in metrics, e.g. "timeout".
+	ErrorClass string `json:"errorClass,omitempty"`
+	// Stage is the stage the latest attempt of a failed backup failed in.
+	Stage string `json:"stage,omitempty"`
+}
+
+// WriteResult writes r to path, see ResultPath.
+func WriteResult(path string, r Result) error {
+	if len(r.Error) > maxErrorLength {
+		r.Error = r.Error[:maxErrorLength]
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = os.WriteFile(path, data, 0600) // #nosec G306
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// ResultFromPod reads the Result from the termination message of the
+// terminated container of pod. Containers which were killed, e.g. when they
+// ran out of memory, do not write one.
+func ResultFromPod(pod *corev1.Pod) (Result, error) {
+	for _, s := range pod.Status.ContainerStatuses {
+		t := s.State.Terminated
+		if t == nil {
+			continue
+		}
+		if t.Message == "" {
+			return Result{}, microerror.Maskf(missingResultError, "container %s of pod %s terminated with reason %#q and exit code %d without result", s.Name, pod.Name, t.Reason, t.ExitCode)
+		}
+
+		var r Result
+		err := json.Unmarshal([]byte(t.Message), &r)
+		if err != nil {
+			return Result{}, microerror.Maskf(missingResultError, "container %s of pod %s terminated with invalid result: %s", s.Name, pod.Name, err)
+		}
+
+		return r, nil
+	}
+
+	return Result{}, microerror.Maskf(missingResultError, "pod %s has no terminated container", pod.Name)
+}
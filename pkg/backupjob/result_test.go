@@ -0,0 +1,137 @@
+package backupjob
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ResultFromPod(t *testing.T) {
+	testCases := []struct {
+		name         string
+		state        corev1.ContainerState
+		expected     Result
+		errorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: succeeded",
+			state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				Message: `{"filename":"gauss-abc12-backup-2026-10-19T12-00-00.db.tar.gz.enc","size":1024,"creationTime":3000,"encryptionTime":200,"uploadTime":1000,"retries":1}`,
+			}},
+			expected: Result{
+				Filename:       "gauss-abc12-backup-2026-10-19T12-00-00.db.tar.gz.enc",
+				Size:           1024,
+				CreationTime:   3000,
+				EncryptionTime: 200,
+				UploadTime:     1000,
+				Retries:        1,
+			},
+			errorMatcher: nil,
+		},
+		{
+			name: "case 1: failed",
+			state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 1,
+				Message:  `{"retries":2,"error":"timeout error: etcd v3 creation exceeded timeout of 30m0s","errorClass":"timeout","stage":"creation"}`,
+			}},
+			expected: Result{
+				Retries:    2,
+				Error:      "timeout error: etcd v3 creation exceeded timeout of 30m0s",
+				ErrorClass: "timeout",
+				Stage:      "creation",
+			},
+			errorMatcher: nil,
+		},
+		{
+			name: "case 2: killed without result",
+			state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 137,
+				Reason:   "OOMKilled",
+			}},
+			expected:     Result{},
+			errorMatcher: IsMissingResult,
+		},
+		{
+			name: "case 3: invalid result",
+			state: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 1,
+				Message:  "panic: runtime error",
+			}},
+			expected:     Result{},
+			errorMatcher: IsMissingResult,
+		},
+		{
+			name:         "case 4: still running",
+			state:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			expected:     Result{},
+			errorMatcher: IsMissingResult,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "etcd-backup-0123456789abcdef-x7k2p"},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "backup", State: tc.state}},
+				},
+			}
+
+			r, err := ResultFromPod(pod)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !cmp.Equal(r, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, r))
+			}
+		})
+	}
+}
+
+func Test_WriteResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termination-log")
+
+	err := WriteResult(path, Result{Error: strings.Repeat("x", 2*maxErrorLength)})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kubernetes keeps 4096 bytes of termination messages.
+	if len(data) > 4096 {
+		t.Fatalf("result has %d bytes, want at most 4096", len(data))
+	}
+
+	pod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: string(data)}}}},
+		},
+	}
+	r, err := ResultFromPod(pod)
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	if len(r.Error) != maxErrorLength {
+		t.Fatalf("error has %d bytes, want %d", len(r.Error), maxErrorLength)
+	}
+}
@@ -0,0 +1,90 @@
+package certs
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "etcd_backup"
+
+	labelName        = "name"
+	labelCertificate = "certificate"
+
+	certificateClient = "client"
+	certificateCA     = "ca"
+)
+
+var (
+	expiryTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "certificate_expiry_timestamp_seconds",
+			Help:      "Time the etcd client certificate or the earliest certificate of the CA bundle used to back up the cluster expires, as a Unix timestamp.",
+		},
+		[]string{labelName, labelCertificate},
+	)
+
+	reloadFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "certificate_reload_failures_total",
+			Help:      "Number of failed attempts to reload the etcd client certificates of the cluster.",
+		},
+		[]string{labelName},
+	)
+)
+
+// names holds the names the metrics were reported for, so that they can be
+// pruned.
+var (
+	namesMutex sync.Mutex
+	names      = map[string]bool{}
+)
+
+func init() {
+	prometheus.MustRegister(expiryTimestamp)
+	prometheus.MustRegister(reloadFailuresTotal)
+}
+
+func observe(name string, c certificates) {
+	namesMutex.Lock()
+	defer namesMutex.Unlock()
+
+	names[name] = true
+	expiryTimestamp.WithLabelValues(name, certificateClient).Set(float64(c.clientExpiry.Unix()))
+	if !c.caExpiry.IsZero() {
+		expiryTimestamp.WithLabelValues(name, certificateCA).Set(float64(c.caExpiry.Unix()))
+	}
+}
+
+func observeFailure(name string) {
+	namesMutex.Lock()
+	defer namesMutex.Unlock()
+
+	names[name] = true
+	reloadFailuresTotal.WithLabelValues(name).Inc()
+}
+
+// Prune removes the metrics of all certificates whose name is not in keep,
+// e.g. the ones of deleted clusters.
+func Prune(keep ...string) {
+	namesMutex.Lock()
+	defer namesMutex.Unlock()
+
+	kept := map[string]bool{}
+	for _, name := range keep {
+		kept[name] = true
+	}
+
+	for name := range names {
+		if kept[name] {
+			continue
+		}
+
+		expiryTimestamp.DeletePartialMatch(prometheus.Labels{labelName: name})
+		reloadFailuresTotal.DeleteLabelValues(name)
+		delete(names, name)
+	}
+}
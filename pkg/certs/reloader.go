@@ -0,0 +1,264 @@
+// Package certs keeps the etcd client certificates used for backups up to
+// date when they are rotated, and reports when they expire.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+type Config struct {
+	Logger micrologger.Logger
+	Source Source
+
+	// Name identifies the certificates in logs and metrics, e.g. the name
+	// of the cluster.
+	Name string
+	// MaxAge is how long loaded certificates are used before they are loaded
+	// again on the next handshake. Zero leaves reloading to Boot.
+	MaxAge time.Duration
+	// ServerName maps the name dialed to the one expected in the server
+	// certificate. The name dialed is expected when it is nil.
+	ServerName ServerName
+	// InsecureSkipVerify disables the verification of the server
+	// certificate, for etcd clusters whose certificates can not be verified.
+	InsecureSkipVerify bool
+}
+
+// ServerName returns the name expected in the server certificate when
+// dialing dialed, which is empty when an IP address is dialed.
+type ServerName func(dialed string) string
+
+// Fixed expects name in the server certificate whatever is dialed.
+func Fixed(name string) ServerName {
+	return func(string) string {
+		return name
+	}
+}
+
+// Reloader serves the client certificate of a Source to TLS handshakes,
+// loading it again when it may have been rotated.
+type Reloader struct {
+	logger micrologger.Logger
+	source Source
+
+	name               string
+	maxAge             time.Duration
+	serverName         ServerName
+	insecureSkipVerify bool
+
+	mutex sync.Mutex
+	certs certificates
+}
+
+type certificates struct {
+	client       *tls.Certificate
+	ca           *x509.CertPool
+	clientExpiry time.Time
+	caExpiry     time.Time
+	loaded       time.Time
+}
+
+// New creates a Reloader and loads the certificates once, so that invalid
+// certificates are reported right away.
+func New(ctx context.Context, config Config) (*Reloader, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Source == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Source must not be empty", config)
+	}
+	if config.Name == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Name must not be empty", config)
+	}
+
+	r := &Reloader{
+		logger: config.Logger,
+		source: config.Source,
+
+		name:               config.Name,
+		maxAge:             config.MaxAge,
+		serverName:         config.ServerName,
+		insecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	err := r.Reload(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return r, nil
+}
+
+// Boot reloads the certificates every interval until ctx is done. Failed
+// reloads keep the previous certificates.
+func (r *Reloader) Boot(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := r.Reload(ctx)
+			if err != nil {
+				r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed to reload etcd client certificates of %s", r.name), "stack", microerror.JSON(err))
+			}
+		}
+	}
+}
+
+// Reload loads the certificates from the source and serves them to the
+// following handshakes.
+func (r *Reloader) Reload(ctx context.Context) error {
+	p, err := r.source.Load(ctx)
+	if err != nil {
+		observeFailure(r.name)
+		return microerror.Mask(err)
+	}
+
+	c, err := parse(p)
+	if err != nil {
+		observeFailure(r.name)
+		return microerror.Mask(err)
+	}
+
+	r.mutex.Lock()
+	changed := r.certs.client != nil && !r.certs.clientExpiry.Equal(c.clientExpiry)
+	r.certs = c
+	r.mutex.Unlock()
+
+	if changed {
+		r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("reloaded rotated etcd client certificate of %s", r.name), "expiry", c.clientExpiry)
+	}
+	observe(r.name, c)
+
+	return nil
+}
+
+// TLSConfig returns a client TLS configuration presenting the current client
+// certificate of the Reloader and verifying the server certificate against
+// its current CA bundle.
+func (r *Reloader) TLSConfig() *tls.Config {
+	c := &tls.Config{
+		GetClientCertificate: r.getClientCertificate,
+		MinVersion:           tls.VersionTLS12,
+		// The built-in verification is replaced by verifyConnection, which
+		// uses the CA bundle loaded last and the expected server name.
+		InsecureSkipVerify: true, //nolint:gosec
+	}
+	if !r.insecureSkipVerify {
+		c.VerifyConnection = r.verifyConnection
+	}
+
+	return c
+}
+
+func (r *Reloader) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return microerror.Maskf(invalidCertificateError, "etcd of %s presented no certificate", r.name)
+	}
+
+	name := cs.ServerName
+	if r.serverName != nil {
+		name = r.serverName(name)
+	}
+	if name == "" {
+		return microerror.Maskf(invalidCertificateError, "no server name to verify the certificate of etcd of %s against", r.name)
+	}
+
+	r.mutex.Lock()
+	roots := r.certs.ca
+	r.mutex.Unlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       name,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	if err != nil {
+		return microerror.Maskf(invalidCertificateError, "certificate of etcd of %s: %s", r.name, err)
+	}
+
+	return nil
+}
+
+func (r *Reloader) getClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mutex.Lock()
+	expired := r.maxAge != 0 && time.Since(r.certs.loaded) > r.maxAge
+	r.mutex.Unlock()
+
+	if expired {
+		ctx := info.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		err := r.Reload(ctx)
+		if err != nil {
+			// The cached certificate may still be valid.
+			r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to reload etcd client certificates of %s, using cached ones", r.name), "reason", err)
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.certs.client, nil
+}
+
+func parse(p PEM) (certificates, error) {
+	client, err := tls.X509KeyPair(p.Cert, p.Key)
+	if err != nil {
+		return certificates{}, microerror.Maskf(invalidCertificateError, "%s", err)
+	}
+
+	leaf, err := x509.ParseCertificate(client.Certificate[0])
+	if err != nil {
+		return certificates{}, microerror.Maskf(invalidCertificateError, "%s", err)
+	}
+
+	c := certificates{
+		client:       &client,
+		ca:           x509.NewCertPool(),
+		clientExpiry: leaf.NotAfter,
+		loaded:       time.Now(),
+	}
+
+	rest := p.CA
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return certificates{}, microerror.Maskf(invalidCertificateError, "invalid CA certificate: %s", err)
+		}
+
+		c.ca.AddCert(ca)
+		if c.caExpiry.IsZero() || ca.NotAfter.Before(c.caExpiry) {
+			c.caExpiry = ca.NotAfter
+		}
+	}
+
+	return c, nil
+}
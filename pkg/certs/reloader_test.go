@@ -0,0 +1,325 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+func Test_Reloader(t *testing.T) {
+	dir := t.TempDir()
+	source := FileSource{
+		CA:   filepath.Join(dir, "ca.crt"),
+		Cert: filepath.Join(dir, "client.crt"),
+		Key:  filepath.Join(dir, "client.key"),
+	}
+
+	first := time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+	writeCerts(t, source, newPEM(t, first), newPEM(t, first))
+
+	r, err := New(context.Background(), Config{Logger: microloggertest.New(), Source: source, Name: "test"})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	tlsConfig := r.TLSConfig()
+	assertExpiry(t, tlsConfig, first)
+
+	// Rotated certificates are served after a reload.
+	second := first.Add(24 * time.Hour)
+	writeCerts(t, source, newPEM(t, second), newPEM(t, second))
+	err = r.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	assertExpiry(t, tlsConfig, second)
+
+	// Invalid certificates keep the previous ones.
+	err = os.WriteFile(source.Key, []byte("garbage"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Reload(context.Background())
+	if !IsInvalidCertificate(err) {
+		t.Fatalf("error == %#v, want matching", err)
+	}
+	assertExpiry(t, tlsConfig, second)
+}
+
+func Test_Reloader_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	source := FileSource{
+		CA:   filepath.Join(dir, "ca.crt"),
+		Cert: filepath.Join(dir, "client.crt"),
+		Key:  filepath.Join(dir, "client.key"),
+	}
+
+	first := time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+	writeCerts(t, source, newPEM(t, first), newPEM(t, first))
+
+	r, err := New(context.Background(), Config{Logger: microloggertest.New(), Source: source, Name: "test", MaxAge: time.Millisecond})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	second := first.Add(24 * time.Hour)
+	writeCerts(t, source, newPEM(t, second), newPEM(t, second))
+	time.Sleep(10 * time.Millisecond)
+
+	// The handshake loads the rotated certificates.
+	assertExpiry(t, r.TLSConfig(), second)
+}
+
+func Test_parse(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	valid := newPEM(t, expiry)
+	other := newPEM(t, expiry)
+
+	testCases := []struct {
+		name         string
+		pem          PEM
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: valid certificates",
+			pem:          PEM{CA: valid.Cert, Cert: valid.Cert, Key: valid.Key},
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: key of another certificate",
+			pem:          PEM{CA: valid.Cert, Cert: valid.Cert, Key: other.Key},
+			errorMatcher: IsInvalidCertificate,
+		},
+		{
+			name:         "case 2: invalid CA",
+			pem:          PEM{CA: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}), Cert: valid.Cert, Key: valid.Key},
+			errorMatcher: IsInvalidCertificate,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			_, err := parse(tc.pem)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
+
+func Test_Reloader_verifyConnection(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	ca := newPEM(t, expiry)
+	otherCA := newPEM(t, expiry)
+	server := newServerCert(t, ca, "node-1")
+	otherServer := newServerCert(t, otherCA, "node-1")
+
+	dir := t.TempDir()
+	source := FileSource{
+		CA:   filepath.Join(dir, "ca.crt"),
+		Cert: filepath.Join(dir, "client.crt"),
+		Key:  filepath.Join(dir, "client.key"),
+	}
+	writeCerts(t, source, ca, ca)
+
+	testCases := []struct {
+		name         string
+		serverName   ServerName
+		dialed       string
+		peer         *x509.Certificate
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: dialed name in certificate",
+			serverName:   nil,
+			dialed:       "node-1",
+			peer:         server,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: dialed name not in certificate",
+			serverName:   nil,
+			dialed:       "node-2",
+			peer:         server,
+			errorMatcher: IsInvalidCertificate,
+		},
+		{
+			name:         "case 2: mapped name in certificate",
+			serverName:   func(dialed string) string { return dialed[len("etcd-"):] },
+			dialed:       "etcd-node-1",
+			peer:         server,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 3: fixed name when dialing an IP address",
+			serverName:   Fixed("node-1"),
+			dialed:       "",
+			peer:         server,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 4: no name when dialing an IP address",
+			serverName:   nil,
+			dialed:       "",
+			peer:         server,
+			errorMatcher: IsInvalidCertificate,
+		},
+		{
+			name:         "case 5: certificate signed by another CA",
+			serverName:   nil,
+			dialed:       "node-1",
+			peer:         otherServer,
+			errorMatcher: IsInvalidCertificate,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			r, err := New(context.Background(), Config{Logger: microloggertest.New(), Source: source, Name: "test", ServerName: tc.serverName})
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			err = r.TLSConfig().VerifyConnection(tls.ConnectionState{ServerName: tc.dialed, PeerCertificates: []*x509.Certificate{tc.peer}})
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
+
+func assertExpiry(t *testing.T, tlsConfig *tls.Config, expected time.Time) {
+	t.Helper()
+
+	c, err := tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	leaf, err := x509.ParseCertificate(c.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !leaf.NotAfter.Equal(expected) {
+		t.Fatalf("expiry == %s, want %s", leaf.NotAfter, expected)
+	}
+}
+
+func writeCerts(t *testing.T, source FileSource, ca PEM, client PEM) {
+	t.Helper()
+
+	for path, data := range map[string][]byte{source.CA: ca.Cert, source.Cert: client.Cert, source.Key: client.Key} {
+		err := os.WriteFile(path, data, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newServerCert returns a server certificate for name signed by ca.
+func newServerCert(t *testing.T, ca PEM, name string) *x509.Certificate {
+	t.Helper()
+
+	caPair, err := tls.X509KeyPair(ca.Cert, ca.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caPair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     caCert.NotAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caPair.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// newPEM returns a self-signed certificate expiring at notAfter and its key.
+func newPEM(t *testing.T, notAfter time.Time) PEM {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "etcd-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return PEM{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
@@ -0,0 +1,115 @@
+package certs
+
+import (
+	"context"
+	"os"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PEM holds a PEM encoded CA bundle, client certificate and private key.
+type PEM struct {
+	CA   []byte
+	Cert []byte
+	Key  []byte
+}
+
+// Source loads the certificates of a Reloader.
+type Source interface {
+	Load(ctx context.Context) (PEM, error)
+}
+
+// FileSource loads the certificates from files, e.g. the ones mounted from
+// the host of the management cluster.
+type FileSource struct {
+	CA   string
+	Cert string
+	Key  string
+}
+
+func (s FileSource) Load(ctx context.Context) (PEM, error) {
+	var p PEM
+	for _, f := range []struct {
+		path string
+		data *[]byte
+	}{{s.CA, &p.CA}, {s.Cert, &p.Cert}, {s.Key, &p.Key}} {
+		b, err := os.ReadFile(f.path) //nolint:gosec
+		if err != nil {
+			return PEM{}, microerror.Mask(err)
+		}
+		*f.data = b
+	}
+
+	return p, nil
+}
+
+// SecretSource loads the certificates from the keys of a secret, e.g. the
+// etcd client certificates of a workload cluster.
+type SecretSource struct {
+	Client client.Reader
+	Secret client.ObjectKey
+
+	CAKey   string
+	CertKey string
+	KeyKey  string
+}
+
+func (s SecretSource) Load(ctx context.Context) (PEM, error) {
+	p, err := SecretKeysSource{
+		Client: s.Client,
+		CA:     SecretKey{Secret: s.Secret, Key: s.CAKey},
+		Cert:   SecretKey{Secret: s.Secret, Key: s.CertKey},
+		Key:    SecretKey{Secret: s.Secret, Key: s.KeyKey},
+	}.Load(ctx)
+	if err != nil {
+		return PEM{}, microerror.Mask(err)
+	}
+
+	return p, nil
+}
+
+// SecretKey selects a key of a secret.
+type SecretKey struct {
+	Secret client.ObjectKey
+	Key    string
+}
+
+// SecretKeysSource loads the certificates from keys of possibly different
+// secrets, e.g. the ones referenced by a Kamaji DataStore.
+type SecretKeysSource struct {
+	Client client.Reader
+
+	CA   SecretKey
+	Cert SecretKey
+	Key  SecretKey
+}
+
+func (s SecretKeysSource) Load(ctx context.Context) (PEM, error) {
+	secrets := map[client.ObjectKey]*v1.Secret{}
+
+	var p PEM
+	for _, k := range []struct {
+		ref  SecretKey
+		data *[]byte
+	}{{s.CA, &p.CA}, {s.Cert, &p.Cert}, {s.Key, &p.Key}} {
+		secret, ok := secrets[k.ref.Secret]
+		if !ok {
+			secret = &v1.Secret{}
+			err := s.Client.Get(ctx, k.ref.Secret, secret)
+			if err != nil {
+				return PEM{}, microerror.Mask(err)
+			}
+			secrets[k.ref.Secret] = secret
+		}
+
+		b, ok := secret.Data[k.ref.Key]
+		if !ok {
+			return PEM{}, microerror.Maskf(invalidCertificateError, "secret %s/%s has no %#q", k.ref.Secret.Namespace, k.ref.Secret.Name, k.ref.Key)
+		}
+		*k.data = b
+	}
+
+	return p, nil
+}
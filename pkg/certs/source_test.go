@@ -0,0 +1,40 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_SecretSource_SharedKey(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "org-foo", Name: "foo-etcd"},
+		Data: map[string][]byte{
+			"tls.crt": []byte("ca"),
+			"tls.key": []byte("key"),
+		},
+	}
+
+	s := SecretSource{
+		Client:  fake.NewClientBuilder().WithObjects(secret).Build(),
+		Secret:  client.ObjectKeyFromObject(secret),
+		CAKey:   "tls.crt",
+		CertKey: "tls.crt",
+		KeyKey:  "tls.key",
+	}
+
+	// The CA is loaded even when it is the client certificate, like in the
+	// etcd secrets of CAPI clusters.
+	p, err := s.Load(context.Background())
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	if !bytes.Equal(p.CA, []byte("ca")) || !bytes.Equal(p.Cert, []byte("ca")) || !bytes.Equal(p.Key, []byte("key")) {
+		t.Fatalf("PEM == %#v, want CA and certificate from tls.crt", p)
+	}
+}
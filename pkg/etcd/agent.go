@@ -0,0 +1,116 @@
+package etcd
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/agent"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// AgentBackup backs up etcd through the node agents running next to its
+// members, which stream the snapshots over mTLS. etcd itself is not reached,
+// so it is neither compacted nor defragmented, which is left to the API
+// server compacting it anyway.
+type AgentBackup struct {
+	V3Backup
+
+	agent *agent.Client
+}
+
+// NewAgentBackup returns the AgentBackup of the members named by endpoints,
+// whose agents are reached at the nodes of p.
+func NewAgentBackup(tlsConfig *tls.Config, p *proxy.Proxy, encPass string, endpoints string, logger micrologger.Logger, prefix string) (AgentBackup, error) {
+	if p == nil || !p.Agent {
+		return AgentBackup{}, microerror.Maskf(invalidConfigError, "agent backups need the nodes of the agents")
+	}
+
+	dial, err := proxy.ContextDialer(*p)
+	if err != nil {
+		return AgentBackup{}, microerror.Mask(err)
+	}
+
+	var c *agent.Client
+	{
+		config := agent.ClientConfig{
+			TLSConfig: tlsConfig,
+			Dial:      dial,
+			Port:      p.Port,
+		}
+
+		c, err = agent.NewClient(config)
+		if err != nil {
+			return AgentBackup{}, microerror.Mask(err)
+		}
+	}
+
+	filename := ""
+	tmpDir := ""
+
+	b := AgentBackup{
+		V3Backup: V3Backup{
+			EncPass:   encPass,
+			Endpoints: endpoints,
+			Logger:    logger,
+			Prefix:    prefix,
+
+			tlsConfig: tlsConfig,
+			proxy:     p,
+			filename:  &filename,
+			info:      &SnapshotInfo{},
+			tmpDir:    &tmpDir,
+		},
+
+		agent: c,
+	}
+
+	return b, nil
+}
+
+// Create etcd in temporary directory.
+func (b AgentBackup) Create(ctx context.Context) (string, error) {
+	members, err := b.probeMembers(ctx, b.probeAgent)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	source := snapshotMember(members)
+	b.Logger.Debugf(ctx, "Streaming snapshot from the agent of etcd member %s", source.Endpoint)
+
+	snapshot, revision, err := b.agent.Snapshot(ctx, source.Endpoint)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	defer func() { _ = snapshot.Close() }()
+
+	fpath, err := b.save(snapshot, b.Version(), revision)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return fpath, nil
+}
+
+// probeAgent returns the status of the member of the agent of endpoint.
+func (b AgentBackup) probeAgent(ctx context.Context, endpoint string) (member, error) {
+	ctx, cancel := context.WithTimeout(ctx, memberProbeTimeout)
+	defer cancel()
+
+	s, err := b.agent.Status(ctx, endpoint)
+	if err != nil {
+		return member{}, microerror.Mask(err)
+	}
+
+	m := member{
+		Endpoint:  endpoint,
+		ID:        s.ID,
+		Leader:    s.Leader,
+		Revision:  s.Revision,
+		RaftIndex: s.RaftIndex,
+	}
+
+	return m, nil
+}
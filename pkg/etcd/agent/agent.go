@@ -0,0 +1,29 @@
+// Package agent implements the node agent, which runs on the control plane
+// nodes of workload clusters and serves the status of the local etcd member
+// and snapshots of it over mTLS, and the client of the operator talking to
+// it. Agents configured with a signing key and an object storage also run
+// signed backup jobs, uploading the backups themselves.
+package agent
+
+const (
+	// DefaultPort is the port the node agent listens on, next to the ports
+	// of etcd.
+	DefaultPort = 2382
+
+	// StatusPath serves the MemberStatus of the local etcd member as JSON.
+	StatusPath = "/status"
+	// SnapshotPath streams a snapshot of the local etcd member.
+	SnapshotPath = "/snapshot"
+
+	// RevisionHeader is the etcd revision the snapshot streamed from
+	// SnapshotPath was taken at, or after.
+	RevisionHeader = "Etcd-Revision"
+)
+
+// MemberStatus is the status of the etcd member of the node of an agent.
+type MemberStatus struct {
+	ID        uint64 `json:"id"`
+	Leader    bool   `json:"leader"`
+	Revision  int64  `json:"revision"`
+	RaftIndex uint64 `json:"raftIndex"`
+}
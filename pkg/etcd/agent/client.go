@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+type ClientConfig struct {
+	// TLSConfig presents the client certificate to the agents and verifies
+	// their server certificates.
+	TLSConfig *tls.Config
+	// Dial reaches the agents by the names of their endpoints.
+	Dial func(ctx context.Context, addr string) (net.Conn, error)
+	// Port is the port the agents listen on.
+	Port int
+}
+
+// Client talks to the node agents of a cluster, which are addressed by the
+// names of the etcd endpoints of their nodes.
+type Client struct {
+	httpClient *http.Client
+	port       int
+}
+
+func NewClient(config ClientConfig) (*Client, error) {
+	if config.TLSConfig == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TLSConfig must not be empty", config)
+	}
+	if config.Dial == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Dial must not be empty", config)
+	}
+	if config.Port == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Port must not be empty", config)
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _ string, addr string) (net.Conn, error) {
+			return config.Dial(ctx, addr)
+		},
+		TLSClientConfig:   config.TLSConfig.Clone(),
+		ForceAttemptHTTP2: true,
+	}
+
+	c := &Client{
+		httpClient: &http.Client{Transport: transport},
+		port:       config.Port,
+	}
+
+	return c, nil
+}
+
+// Status returns the status of the etcd member of the agent of endpoint.
+func (c *Client) Status(ctx context.Context, endpoint string) (MemberStatus, error) {
+	res, err := c.do(ctx, http.MethodGet, endpoint, StatusPath, nil, http.StatusOK)
+	if err != nil {
+		return MemberStatus{}, microerror.Mask(err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	var status MemberStatus
+	err = json.NewDecoder(res.Body).Decode(&status)
+	if err != nil {
+		return MemberStatus{}, microerror.Maskf(requestFailedError, "invalid status of agent %s: %s", endpoint, err)
+	}
+
+	return status, nil
+}
+
+// Snapshot streams a snapshot of the etcd member of the agent of endpoint
+// and returns the revision it was taken at. The caller closes the snapshot.
+func (c *Client) Snapshot(ctx context.Context, endpoint string) (io.ReadCloser, int64, error) {
+	res, err := c.do(ctx, http.MethodGet, endpoint, SnapshotPath, nil, http.StatusOK)
+	if err != nil {
+		return nil, 0, microerror.Mask(err)
+	}
+
+	revision, err := strconv.ParseInt(res.Header.Get(RevisionHeader), 10, 64)
+	if err != nil {
+		_ = res.Body.Close()
+		return nil, 0, microerror.Maskf(requestFailedError, "invalid %s header of agent %s: %s", RevisionHeader, endpoint, err)
+	}
+
+	return res.Body, revision, nil
+}
+
+// Dispatch has the agent of endpoint run job.
+func (c *Client) Dispatch(ctx context.Context, endpoint string, job SignedJob) (JobStatus, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return JobStatus{}, microerror.Mask(err)
+	}
+
+	res, err := c.do(ctx, http.MethodPost, endpoint, JobsPath, bytes.NewReader(body), http.StatusAccepted)
+	if err != nil {
+		return JobStatus{}, microerror.Mask(err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	return decodeJobStatus(res.Body, endpoint)
+}
+
+// Job returns the status of the job with the given ID run by the agent of
+// endpoint. Jobs the agent does not know, e.g. because it was restarted,
+// are unknown job errors.
+func (c *Client) Job(ctx context.Context, endpoint string, id string) (JobStatus, error) {
+	res, err := c.do(ctx, http.MethodGet, endpoint, JobsPath+"/"+url.PathEscape(id), nil, http.StatusOK)
+	if err != nil {
+		return JobStatus{}, microerror.Mask(err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	return decodeJobStatus(res.Body, endpoint)
+}
+
+func decodeJobStatus(r io.Reader, endpoint string) (JobStatus, error) {
+	var status JobStatus
+	err := json.NewDecoder(r).Decode(&status)
+	if err != nil {
+		return JobStatus{}, microerror.Maskf(requestFailedError, "invalid job status of agent %s: %s", endpoint, err)
+	}
+
+	return status, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, endpoint string, path string, body io.Reader, expected int) (*http.Response, error) {
+	u := fmt.Sprintf("https://%s%s", net.JoinHostPort(endpoint, strconv.Itoa(c.port)), path)
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, microerror.Maskf(requestFailedError, "error requesting %s: %s", u, err)
+	}
+
+	if res.StatusCode != expected {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		_ = res.Body.Close()
+		if res.StatusCode == http.StatusNotFound && strings.HasPrefix(path, JobsPath+"/") {
+			return nil, microerror.Maskf(unknownJobError, "agent %s returned %s: %s", endpoint, res.Status, message)
+		}
+		return nil, microerror.Maskf(requestFailedError, "agent %s returned %s: %s", endpoint, res.Status, message)
+	}
+
+	return res, nil
+}
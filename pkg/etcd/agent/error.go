@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var requestFailedError = &microerror.Error{
+	Kind: "requestFailedError",
+}
+
+// IsRequestFailed asserts requestFailedError.
+func IsRequestFailed(err error) bool {
+	return microerror.Cause(err) == requestFailedError
+}
+
+// executionFailedError should never be matched against and therefore there is
+// no matcher implement. For further information see:
+//
+//	https://github.com/giantswarm/fmt/blob/master/go/errors.md#matching-errors
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+var invalidJobError = &microerror.Error{
+	Kind: "invalidJobError",
+}
+
+// IsInvalidJob asserts invalidJobError.
+func IsInvalidJob(err error) bool {
+	return microerror.Cause(err) == invalidJobError
+}
+
+var unknownJobError = &microerror.Error{
+	Kind: "unknownJobError",
+}
+
+// IsUnknownJob asserts unknownJobError.
+func IsUnknownJob(err error) bool {
+	return microerror.Cause(err) == unknownJobError
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// JobsPath accepts SignedJobs, which the agent runs in the background.
+	// The status of a job is served at JobsPath/<id>.
+	JobsPath = "/jobs"
+
+	// MaxJobTTL is how far in the future jobs may expire. Agents remember
+	// the jobs they accepted for longer, so that a job can not be replayed.
+	MaxJobTTL = 10 * time.Minute
+
+	// States of a job.
+	JobRunning   = "Running"
+	JobSucceeded = "Succeeded"
+	JobFailed    = "Failed"
+
+	// Stages of a job, named like the stages of the backups taken by the
+	// operator.
+	StageCreation   = "creation"
+	StageEncryption = "encryption"
+	StageUpload     = "upload"
+)
+
+// Job has an agent take a backup of its etcd member, encrypt it and upload
+// it with a presigned URL, so that agents hold neither credentials of the
+// object storage nor the encryption password of the operator.
+type Job struct {
+	// ID identifies the job. It is unique, the agents reject jobs whose ID
+	// they have seen.
+	ID string `json:"id"`
+	// Prefix is the prefix of the name of the backup, see
+	// key.FilenamePrefix.
+	Prefix string `json:"prefix"`
+	// Key is the key of the object the backup is uploaded to, UploadURL the
+	// URL presigned by the operator to upload it.
+	Key       string `json:"key"`
+	UploadURL string `json:"uploadURL"`
+	// Passphrase is generated for the job, the backup is encrypted with it.
+	// An empty passphrase leaves the backup unencrypted.
+	Passphrase string `json:"passphrase,omitempty"`
+	// Timeouts bounds the stages of the job. A zero timeout leaves a stage
+	// unbounded.
+	Timeouts JobTimeouts `json:"timeouts"`
+	// Expires is when the agent stops accepting the job.
+	Expires time.Time `json:"expires"`
+}
+
+type JobTimeouts struct {
+	Create  time.Duration `json:"create"`
+	Encrypt time.Duration `json:"encrypt"`
+	Upload  time.Duration `json:"upload"`
+}
+
+// SignedJob is a Job with the HMAC-SHA256 of its JSON encoding, keyed with
+// the signing key shared by the operator and the agents. The signature is
+// checked against Payload as sent, so that the JSON encoding does not need
+// to be canonical.
+type SignedJob struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// JobStatus is the status of a job served by the agent running it.
+type JobStatus struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+
+	// Stage is the stage a failed job failed in, TimedOut is set when it
+	// ran into its timeout.
+	Stage    string `json:"stage,omitempty"`
+	TimedOut bool   `json:"timedOut,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	// Filename is the key of the uploaded backup, see Job.Key, and Size its
+	// size in bytes.
+	Filename       string        `json:"filename,omitempty"`
+	Size           int64         `json:"size,omitempty"`
+	CreationTime   time.Duration `json:"creationTime,omitempty"`
+	EncryptionTime time.Duration `json:"encryptionTime,omitempty"`
+	UploadTime     time.Duration `json:"uploadTime,omitempty"`
+}
+
+// Backupper takes the backup of a job, see etcd.Backupper.
+type Backupper interface {
+	Create(ctx context.Context) (string, error)
+	Encrypt(ctx context.Context) (string, error)
+	Cleanup()
+}
+
+// Sign returns job signed with key.
+func Sign(job Job, key []byte) (SignedJob, error) {
+	if len(key) == 0 {
+		return SignedJob{}, microerror.Maskf(invalidConfigError, "signing key must not be empty")
+	}
+
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return SignedJob{}, microerror.Mask(err)
+	}
+
+	s := SignedJob{
+		Payload:   payload,
+		Signature: signature(payload, key),
+	}
+
+	return s, nil
+}
+
+// Verify returns the job of s when it is signed with key and has not expired
+// at now.
+func Verify(s SignedJob, key []byte, now time.Time) (Job, error) {
+	if len(key) == 0 {
+		return Job{}, microerror.Maskf(invalidConfigError, "signing key must not be empty")
+	}
+	if !hmac.Equal(s.Signature, signature(s.Payload, key)) {
+		return Job{}, microerror.Maskf(invalidJobError, "invalid signature")
+	}
+
+	var job Job
+	err := json.Unmarshal(s.Payload, &job)
+	if err != nil {
+		return Job{}, microerror.Maskf(invalidJobError, "invalid payload: %s", err)
+	}
+
+	if job.ID == "" {
+		return Job{}, microerror.Maskf(invalidJobError, "job has no ID")
+	}
+	if job.Prefix == "" {
+		return Job{}, microerror.Maskf(invalidJobError, "job %s has no prefix", job.ID)
+	}
+	if job.Key == "" || job.UploadURL == "" {
+		return Job{}, microerror.Maskf(invalidJobError, "job %s has no upload URL", job.ID)
+	}
+	if !now.Before(job.Expires) {
+		return Job{}, microerror.Maskf(invalidJobError, "job %s expired at %s", job.ID, job.Expires.Format(time.RFC3339))
+	}
+	if job.Expires.After(now.Add(MaxJobTTL)) {
+		return Job{}, microerror.Maskf(invalidJobError, "job %s expires later than %s from now", job.ID, MaxJobTTL)
+	}
+
+	return job, nil
+}
+
+func signature(payload []byte, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Verify(t *testing.T) {
+	key := []byte("signing-key")
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	job := Job{
+		ID:         "abc",
+		Prefix:     "gauss-abc12",
+		Key:        "gauss-abc12-v3-2026-10-19T12-00-00.db.tar.gz.enc.agent",
+		UploadURL:  "https://etcd-backups.s3.amazonaws.com/gauss-abc12-v3-2026-10-19T12-00-00.db.tar.gz.enc.agent?X-Amz-Signature=abc",
+		Passphrase: "passphrase",
+		Expires:    now.Add(time.Minute),
+	}
+
+	testCases := []struct {
+		name         string
+		signed       func() SignedJob
+		expected     Job
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: valid job",
+			signed:       func() SignedJob { return mustSign(t, job, key) },
+			expected:     job,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: signed with another key",
+			signed:       func() SignedJob { return mustSign(t, job, []byte("other-key")) },
+			expected:     Job{},
+			errorMatcher: IsInvalidJob,
+		},
+		{
+			name: "case 2: tampered payload",
+			signed: func() SignedJob {
+				s := mustSign(t, job, key)
+				other := mustSign(t, Job{ID: "abc", Prefix: "gauss-def34", Expires: job.Expires}, key)
+				s.Payload = other.Payload
+				return s
+			},
+			expected:     Job{},
+			errorMatcher: IsInvalidJob,
+		},
+		{
+			name: "case 3: expired",
+			signed: func() SignedJob {
+				expired := job
+				expired.Expires = now.Add(-time.Second)
+				return mustSign(t, expired, key)
+			},
+			expected:     Job{},
+			errorMatcher: IsInvalidJob,
+		},
+		{
+			name: "case 4: expires too late",
+			signed: func() SignedJob {
+				late := job
+				late.Expires = now.Add(MaxJobTTL + time.Minute)
+				return mustSign(t, late, key)
+			},
+			expected:     Job{},
+			errorMatcher: IsInvalidJob,
+		},
+		{
+			name: "case 5: no ID",
+			signed: func() SignedJob {
+				noID := job
+				noID.ID = ""
+				return mustSign(t, noID, key)
+			},
+			expected:     Job{},
+			errorMatcher: IsInvalidJob,
+		},
+		{
+			name: "case 6: no upload URL",
+			signed: func() SignedJob {
+				noURL := job
+				noURL.UploadURL = ""
+				return mustSign(t, noURL, key)
+			},
+			expected:     Job{},
+			errorMatcher: IsInvalidJob,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			j, err := Verify(tc.signed(), key, now)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !cmp.Equal(j, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, j))
+			}
+		})
+	}
+}
+
+func mustSign(t *testing.T, job Job, key []byte) SignedJob {
+	t.Helper()
+
+	s, err := Sign(job, key)
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	return s
+}
@@ -0,0 +1,440 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/storage"
+)
+
+const (
+	// statusTimeout bounds the status requests to the local etcd member.
+	statusTimeout = 10 * time.Second
+	// shutdownTimeout is how long running snapshots may take to finish when
+	// the agent is stopped.
+	shutdownTimeout = time.Minute
+	// jobRetention is how long the status of finished jobs is served. It
+	// is longer than MaxJobTTL, so that the IDs of all jobs which could
+	// still be accepted are known.
+	jobRetention = time.Hour
+	// maxSignedJobSize bounds the size of the requests dispatching jobs.
+	maxSignedJobSize = 64 * 1024
+)
+
+type ServerConfig struct {
+	Logger micrologger.Logger
+	// EtcdClient is connected to the local etcd member only.
+	EtcdClient *clientv3.Client
+
+	// Address is the address the agent listens on.
+	Address string
+	// CertFile and KeyFile are the server certificate of the agent, read on
+	// every handshake so that rotated certificates are picked up.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is the CA the client certificates are verified against.
+	ClientCAFile string
+
+	// SigningKey verifies the signatures of the backup jobs. Jobs are only
+	// run when SigningKey and NewBackupper are set. Their backups are
+	// uploaded to the URLs presigned in the jobs.
+	SigningKey []byte
+	// NewBackupper returns the Backupper taking the backup of job from the
+	// local etcd member.
+	NewBackupper func(job Job) (Backupper, error)
+}
+
+// Server serves the status and snapshots of the local etcd member to clients
+// presenting a certificate signed by the client CA, and runs the backup jobs
+// signed with the signing key. Only one snapshot is streamed, or job run, at
+// a time.
+type Server struct {
+	logger     micrologger.Logger
+	etcdClient *clientv3.Client
+
+	address      string
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	signingKey   []byte
+	newBackupper func(job Job) (Backupper, error)
+
+	snapshotting sync.Mutex
+
+	jobsMutex sync.Mutex
+	jobs      map[string]*jobEntry
+}
+
+// jobEntry is a job the agent accepted.
+type jobEntry struct {
+	status   JobStatus
+	finished time.Time
+}
+
+func NewServer(config ServerConfig) (*Server, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.EtcdClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EtcdClient must not be empty", config)
+	}
+	if config.Address == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Address must not be empty", config)
+	}
+	if config.CertFile == "" || config.KeyFile == "" || config.ClientCAFile == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CertFile, %T.KeyFile and %T.ClientCAFile must not be empty", config, config, config)
+	}
+	jobs := len(config.SigningKey) > 0
+	if (config.NewBackupper != nil) != jobs {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SigningKey and %T.NewBackupper must be set together", config, config)
+	}
+
+	s := &Server{
+		logger:     config.Logger,
+		etcdClient: config.EtcdClient,
+
+		address:      config.Address,
+		certFile:     config.CertFile,
+		keyFile:      config.KeyFile,
+		clientCAFile: config.ClientCAFile,
+
+		signingKey:   config.SigningKey,
+		newBackupper: config.NewBackupper,
+
+		jobs: map[string]*jobEntry{},
+	}
+
+	return s, nil
+}
+
+// Run serves until ctx is done.
+func (s *Server) Run(ctx context.Context) error {
+	// The files are loaded once, so that the agent does not start with
+	// invalid certificates.
+	_, err := s.tlsConfig(nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+StatusPath, s.serveStatus)
+	mux.HandleFunc("GET "+SnapshotPath, s.serveSnapshot)
+	mux.HandleFunc("POST "+JobsPath, func(w http.ResponseWriter, r *http.Request) {
+		// Jobs outlive the requests dispatching them, until the agent is
+		// stopped.
+		s.serveDispatch(ctx, w, r)
+	})
+	mux.HandleFunc("GET "+JobsPath+"/{id}", s.serveJob)
+
+	server := &http.Server{
+		Addr:              s.address,
+		Handler:           mux,
+		ReadHeaderTimeout: statusTimeout,
+		TLSConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			GetConfigForClient: s.tlsConfig,
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("serving etcd snapshots on %s", s.address))
+
+	err = server.ListenAndServeTLS("", "")
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// tlsConfig reads the certificates of the agent again for every handshake.
+func (s *Server) tlsConfig(*tls.ClientHelloInfo) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	ca, err := os.ReadFile(s.clientCAFile)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(ca) {
+		return nil, microerror.Maskf(invalidConfigError, "no certificates found in %s", s.clientCAFile)
+	}
+
+	c := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+
+	return c, nil
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.status(r.Context())
+	if err != nil {
+		s.fail(w, r, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !s.snapshotting.TryLock() {
+		s.fail(w, r, http.StatusConflict, microerror.Maskf(executionFailedError, "a snapshot is already being streamed"))
+		return
+	}
+	defer s.snapshotting.Unlock()
+
+	// The snapshot contains at least the revision of the member before it
+	// is taken.
+	status, err := s.status(r.Context())
+	if err != nil {
+		s.fail(w, r, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	snapshot, err := s.etcdClient.Snapshot(r.Context())
+	if err != nil {
+		s.fail(w, r, http.StatusServiceUnavailable, err)
+		return
+	}
+	defer snapshot.Close() //nolint:errcheck
+
+	s.logger.LogCtx(r.Context(), "level", "info", "message", fmt.Sprintf("streaming snapshot at revision %d to %s", status.Revision, r.RemoteAddr))
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set(RevisionHeader, strconv.FormatInt(status.Revision, 10))
+	w.WriteHeader(http.StatusOK)
+
+	// Once the header is written, failures can only be reported by
+	// cutting the stream short, which the client detects.
+	_, err = io.Copy(w, snapshot)
+	if err != nil {
+		s.logger.LogCtx(r.Context(), "level", "error", "message", "streaming snapshot failed", "stack", microerror.JSON(err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func (s *Server) serveDispatch(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if len(s.signingKey) == 0 {
+		s.fail(w, r, http.StatusNotImplemented, microerror.Maskf(invalidConfigError, "backup jobs are not enabled"))
+		return
+	}
+
+	var signed SignedJob
+	err := json.NewDecoder(io.LimitReader(r.Body, maxSignedJobSize)).Decode(&signed)
+	if err != nil {
+		s.fail(w, r, http.StatusBadRequest, microerror.Maskf(invalidJobError, "invalid job: %s", err))
+		return
+	}
+
+	now := time.Now()
+	job, err := Verify(signed, s.signingKey, now)
+	if err != nil {
+		s.fail(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	s.jobsMutex.Lock()
+	s.pruneJobs(now)
+	if _, ok := s.jobs[job.ID]; ok {
+		s.jobsMutex.Unlock()
+		s.fail(w, r, http.StatusConflict, microerror.Maskf(invalidJobError, "job %s was already dispatched", job.ID))
+		return
+	}
+	if !s.snapshotting.TryLock() {
+		s.jobsMutex.Unlock()
+		s.fail(w, r, http.StatusConflict, microerror.Maskf(executionFailedError, "a snapshot is already being taken"))
+		return
+	}
+	status := JobStatus{ID: job.ID, State: JobRunning}
+	s.jobs[job.ID] = &jobEntry{status: status}
+	s.jobsMutex.Unlock()
+
+	go func() {
+		defer s.snapshotting.Unlock()
+		s.runJob(ctx, job)
+	}()
+
+	s.logger.LogCtx(r.Context(), "level", "info", "message", fmt.Sprintf("accepted backup job %s from %s", job.ID, r.RemoteAddr))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+func (s *Server) serveJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.jobsMutex.Lock()
+	e, ok := s.jobs[id]
+	var status JobStatus
+	if ok {
+		status = e.status
+	}
+	s.jobsMutex.Unlock()
+
+	if !ok {
+		s.fail(w, r, http.StatusNotFound, microerror.Maskf(unknownJobError, "job %s is not known", id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+// runJob takes, encrypts and uploads the backup of job and records its
+// status.
+func (s *Server) runJob(ctx context.Context, job Job) {
+	s.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("running backup job %s", job.ID))
+
+	status := s.backup(ctx, job)
+
+	s.jobsMutex.Lock()
+	s.jobs[job.ID] = &jobEntry{status: status, finished: time.Now()}
+	s.jobsMutex.Unlock()
+
+	if status.State == JobSucceeded {
+		s.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("backup job %s uploaded %s", job.ID, status.Filename))
+	} else {
+		s.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("backup job %s failed in stage %s", job.ID, status.Stage), "reason", status.Error)
+	}
+}
+
+func (s *Server) backup(ctx context.Context, job Job) JobStatus {
+	status := JobStatus{ID: job.ID, State: JobFailed}
+
+	b, err := s.newBackupper(job)
+	if err != nil {
+		return jobFailed(status, StageCreation, err)
+	}
+	defer b.Cleanup()
+
+	start := time.Now()
+	err = runStage(ctx, job.Timeouts.Create, func(ctx context.Context) error {
+		_, err := b.Create(ctx)
+		return err
+	})
+	if err != nil {
+		return jobFailed(status, StageCreation, err)
+	}
+	status.CreationTime = time.Since(start)
+
+	start = time.Now()
+	var path string
+	err = runStage(ctx, job.Timeouts.Encrypt, func(ctx context.Context) error {
+		var err error
+		path, err = b.Encrypt(ctx)
+		return err
+	})
+	if err != nil {
+		return jobFailed(status, StageEncryption, err)
+	}
+	status.EncryptionTime = time.Since(start)
+
+	start = time.Now()
+	err = runStage(ctx, job.Timeouts.Upload, func(ctx context.Context) error {
+		var err error
+		status.Size, err = storage.PutURL(ctx, job.UploadURL, path)
+		return err
+	})
+	if err != nil {
+		return jobFailed(status, StageUpload, err)
+	}
+	status.UploadTime = time.Since(start)
+
+	status.State = JobSucceeded
+	status.Filename = job.Key
+
+	return status
+}
+
+// pruneJobs forgets the jobs which finished longer than jobRetention ago.
+func (s *Server) pruneJobs(now time.Time) {
+	for id, e := range s.jobs {
+		if !e.finished.IsZero() && now.Sub(e.finished) > jobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// runStage executes a stage of a job bounded by timeout, unless it is zero.
+func runStage(ctx context.Context, timeout time.Duration, stage func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := stage(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return microerror.Mask(context.DeadlineExceeded)
+	}
+
+	return err
+}
+
+func jobFailed(status JobStatus, stage string, err error) JobStatus {
+	status.Stage = stage
+	status.TimedOut = errors.Is(err, context.DeadlineExceeded)
+	status.Error = err.Error()
+
+	return status
+}
+
+func (s *Server) status(ctx context.Context) (MemberStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+
+	endpoints := s.etcdClient.Endpoints()
+	if len(endpoints) == 0 {
+		return MemberStatus{}, microerror.Maskf(invalidConfigError, "etcd client has no endpoints")
+	}
+
+	res, err := s.etcdClient.Status(ctx, endpoints[0])
+	if err != nil {
+		return MemberStatus{}, microerror.Mask(err)
+	}
+
+	status := MemberStatus{
+		ID:        res.Header.MemberId,
+		Leader:    res.Leader == res.Header.MemberId,
+		Revision:  res.Header.Revision,
+		RaftIndex: res.RaftIndex,
+	}
+
+	return status, nil
+}
+
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, code int, err error) {
+	s.logger.LogCtx(r.Context(), "level", "error", "message", fmt.Sprintf("request %s failed", r.URL.Path), "stack", microerror.JSON(err))
+	http.Error(w, err.Error(), code)
+}
@@ -0,0 +1,152 @@
+package etcd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/agent"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/encrypt"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/key"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+const (
+	// jobTTL is how long a dispatched job is valid. Jobs are dispatched
+	// right after they are signed.
+	jobTTL = time.Minute
+	// jobPollInterval is how often the status of a running job is polled.
+	jobPollInterval = 5 * time.Second
+	// maxJobPollFailures is how many times in a row polling the status of a
+	// job may fail before it is given up.
+	maxJobPollFailures = 3
+)
+
+// AgentUpload has the node agent next to an etcd member take a backup,
+// encrypt it and upload it to the object storage itself, instead of
+// streaming the snapshot to the operator. The jobs are signed with the key
+// shared with the agents.
+type AgentUpload struct {
+	AgentBackup
+
+	signingKey []byte
+}
+
+// NewAgentUpload returns the AgentUpload of the members named by endpoints,
+// whose agents are reached at the nodes of p.
+func NewAgentUpload(tlsConfig *tls.Config, p *proxy.Proxy, endpoints string, logger micrologger.Logger, prefix string, signingKey []byte) (AgentUpload, error) {
+	if len(signingKey) == 0 {
+		return AgentUpload{}, microerror.Maskf(invalidConfigError, "agent uploads need a signing key")
+	}
+
+	b, err := NewAgentBackup(tlsConfig, p, "", endpoints, logger, prefix)
+	if err != nil {
+		return AgentUpload{}, microerror.Mask(err)
+	}
+
+	u := AgentUpload{
+		AgentBackup: b,
+		signingKey:  signingKey,
+	}
+
+	return u, nil
+}
+
+// Run dispatches job to the agent of the member the snapshot is taken from,
+// picked like Create does, and waits until the job is done. The ID, prefix
+// and expiry of job are set by Run. Jobs which fail on the agent are
+// returned with their status, errors are only returned when the job could
+// not be dispatched or followed.
+func (b AgentUpload) Run(ctx context.Context, job agent.Job) (agent.JobStatus, error) {
+	members, err := b.probeMembers(ctx, b.probeAgent)
+	if err != nil {
+		return agent.JobStatus{}, microerror.Mask(err)
+	}
+
+	source := snapshotMember(members)
+
+	job.ID, err = jobID()
+	if err != nil {
+		return agent.JobStatus{}, microerror.Mask(err)
+	}
+	job.Prefix = b.Prefix
+	job.Expires = time.Now().Add(jobTTL)
+
+	signed, err := agent.Sign(job, b.signingKey)
+	if err != nil {
+		return agent.JobStatus{}, microerror.Mask(err)
+	}
+
+	b.Logger.Debugf(ctx, "Dispatching backup job %s to the agent of etcd member %s", job.ID, source.Endpoint)
+
+	status, err := b.agent.Dispatch(ctx, source.Endpoint, signed)
+	if err != nil {
+		return agent.JobStatus{}, microerror.Mask(err)
+	}
+
+	failures := 0
+	for status.State == agent.JobRunning {
+		select {
+		case <-ctx.Done():
+			return agent.JobStatus{}, microerror.Mask(ctx.Err())
+		case <-time.After(jobPollInterval):
+		}
+
+		s, err := b.agent.Job(ctx, source.Endpoint, job.ID)
+		if agent.IsUnknownJob(err) {
+			return agent.JobStatus{}, microerror.Maskf(executionFailedError, "the agent of etcd member %s lost backup job %s", source.Endpoint, job.ID)
+		} else if err != nil {
+			failures++
+			if failures >= maxJobPollFailures {
+				return agent.JobStatus{}, microerror.Mask(err)
+			}
+			b.Logger.LogCtx(ctx, "level", "warning", "message", "polling backup job failed", "job", job.ID, "reason", err)
+			continue
+		}
+
+		failures = 0
+		status = s
+	}
+
+	return status, nil
+}
+
+// Filename returns the name of a backup taken now, named like the backups the
+// operator takes itself, so that the key of the backup is known before the
+// job is dispatched.
+func (b AgentUpload) Filename(encrypted bool) string {
+	filename := b.Prefix + "-" + b.Version() + "-" + time.Now().Format(key.TsFormat) + key.DbExt + key.TgzExt
+	if encrypted {
+		filename += key.EncExt
+	}
+
+	return filename
+}
+
+// ReencryptAgentUpload writes the backup at srcPath, encrypted by a node agent
+// with the passphrase of its job, to dstPath encrypted with encPass. The
+// archive of the snapshot is kept as it is.
+func ReencryptAgentUpload(ctx context.Context, srcPath string, dstPath string, passphrase string, encPass string) error {
+	err := encrypt.Reencrypt(ctx, srcPath, dstPath, passphrase, encPass)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// jobID returns a random ID of a job.
+func jobID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return hex.EncodeToString(id), nil
+}
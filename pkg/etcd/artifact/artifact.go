@@ -0,0 +1,165 @@
+// Package artifact opens the backup artifacts produced by the operator. An
+// artifact is the etcd snapshot wrapped in any number of layers, e.g. a tar
+// archive, gzip compression and OpenPGP symmetric encryption for
+// .db.tar.gz.enc files. Layers are detected by their content rather than by
+// the file extension, so every artifact the operator ever produced can be
+// opened, and new formats only need a new Decoder.
+package artifact
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/ctxio"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/encrypt"
+)
+
+const (
+	// peekSize is the number of bytes decoders can look at to detect their
+	// format. The tar header magic is at offset 257.
+	peekSize = 512
+	// maxLayers guards against artifacts nesting layers endlessly.
+	maxLayers = 8
+)
+
+// Options configure how artifacts are opened.
+type Options struct {
+	// Passphrase decrypts encrypted artifacts.
+	Passphrase string
+}
+
+// Decoder peels one layer off an artifact.
+type Decoder interface {
+	// Name identifies the layer, e.g. "gzip".
+	Name() string
+	// Detect tells whether the data starting with header is in the format
+	// of the decoder. header is shorter than peekSize for small data.
+	Detect(header []byte) bool
+	// Open returns a reader streaming the content of the layer.
+	Open(r io.Reader, options Options) (io.Reader, error)
+}
+
+var (
+	decodersMutex sync.RWMutex
+	decoders      = []Decoder{
+		openPGPDecoder{},
+		gzipDecoder{},
+		tarDecoder{},
+	}
+)
+
+// Register adds a decoder for a new artifact format.
+func Register(d Decoder) {
+	decodersMutex.Lock()
+	defer decodersMutex.Unlock()
+
+	decoders = append(decoders, d)
+}
+
+// Open returns a reader streaming the etcd snapshot contained in the
+// artifact read from r, and the names of the layers which were peeled off,
+// outermost first. Reading is aborted as soon as ctx is done.
+func Open(ctx context.Context, r io.Reader, options Options) (io.Reader, []string, error) {
+	var layers []string
+
+	br := bufio.NewReaderSize(ctxio.NewReader(ctx, r), peekSize)
+	for {
+		header, err := br.Peek(peekSize)
+		if err != nil && err != io.EOF {
+			return nil, nil, microerror.Mask(err)
+		}
+
+		if isSnapshot(header) {
+			return br, layers, nil
+		}
+
+		if len(layers) == maxLayers {
+			return nil, nil, microerror.Maskf(unknownFormatError, "artifact has more than %d layers", maxLayers)
+		}
+
+		d := detect(header)
+		if d == nil {
+			return nil, nil, microerror.Maskf(unknownFormatError, "artifact content after layers %v is neither an etcd snapshot nor a known format", layers)
+		}
+
+		next, err := d.Open(br, options)
+		if err != nil {
+			return nil, nil, microerror.Mask(err)
+		}
+
+		layers = append(layers, d.Name())
+		br = bufio.NewReaderSize(next, peekSize)
+	}
+}
+
+// Extract writes the etcd snapshot contained in the artifact read from r to
+// w. It returns the layers which were peeled off, outermost first, and the
+// size of the snapshot.
+func Extract(ctx context.Context, r io.Reader, w io.Writer, options Options) ([]string, int64, error) {
+	snapshot, layers, err := Open(ctx, r, options)
+	if err != nil {
+		return nil, 0, microerror.Mask(err)
+	}
+
+	n, err := io.Copy(w, snapshot)
+	if err != nil {
+		return nil, 0, microerror.Mask(err)
+	}
+
+	return layers, n, nil
+}
+
+// Reencrypt writes the etcd snapshot contained in the artifact read from r
+// to w, gzip compressed and encrypted with passphrase, e.g. to hand a backup
+// out without the passphrase it was encrypted with. It returns the size of
+// the snapshot.
+func Reencrypt(ctx context.Context, r io.Reader, w io.Writer, options Options, passphrase string) (int64, error) {
+	if passphrase == "" {
+		return 0, microerror.Maskf(missingPassphraseError, "passphrase must not be empty")
+	}
+
+	snapshot, _, err := Open(ctx, r, options)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	encrypted, err := encrypt.NewWriter(w, passphrase)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+	z := gzip.NewWriter(encrypted)
+
+	n, err := io.Copy(z, snapshot)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	err = z.Close()
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+	err = encrypted.Close()
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return n, nil
+}
+
+func detect(header []byte) Decoder {
+	decodersMutex.RLock()
+	defer decodersMutex.RUnlock()
+
+	for _, d := range decoders {
+		if d.Detect(header) {
+			return d
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package artifact
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"golang.org/x/crypto/openpgp" //nolint
+	"google.golang.org/protobuf/proto"
+)
+
+// writeSnapshot writes a bbolt database laid out like an etcd snapshot and
+// returns its content.
+func writeSnapshot(t *testing.T, kvs map[string]string) []byte {
+	fpath := filepath.Join(t.TempDir(), "snapshot.db")
+	db, err := bolt.Open(fpath, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte(keyBucket))
+		if err != nil {
+			return err
+		}
+
+		revision := int64(1)
+		for _, k := range sortedKeys(kvs) {
+			revision++
+			v, err := proto.Marshal(&mvccpb.KeyValue{Key: []byte(k), Value: []byte(kvs[k]), ModRevision: revision})
+			if err != nil {
+				return err
+			}
+
+			rev := make([]byte, revisionLength)
+			binary.BigEndian.PutUint64(rev, uint64(revision)) // nolint:gosec
+			rev[8] = '_'
+			err = b.Put(rev, v)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.CreateBucket([]byte("meta"))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(fpath) //nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return content
+}
+
+func sortedKeys(kvs map[string]string) []string {
+	var keys []string
+	for k := range kvs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// wrap packs the snapshot the way the Backupper does.
+func wrap(t *testing.T, snapshot []byte, passphrase string) []byte {
+	var tgz bytes.Buffer
+	z := gzip.NewWriter(&tgz)
+	tw := tar.NewWriter(z)
+	err := tw.WriteHeader(&tar.Header{Name: "gauss-abc12-v3-2024-05-01T12-00-00.db", Mode: 0600, Size: int64(len(snapshot)), Typeflag: tar.TypeReg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tw.Write(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if passphrase == "" {
+		return tgz.Bytes()
+	}
+
+	var enc bytes.Buffer
+	w, err := openpgp.SymmetricallyEncrypt(&enc, []byte(passphrase), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Write(tgz.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return enc.Bytes()
+}
+
+func Test_Extract(t *testing.T) {
+	snapshot := writeSnapshot(t, map[string]string{"/registry/pods/default/a": "a"})
+
+	testCases := []struct {
+		name           string
+		artifact       []byte
+		passphrase     string
+		expectedLayers []string
+		errorMatcher   func(error) bool
+	}{
+		{
+			name:           "case 0: plain snapshot",
+			artifact:       snapshot,
+			expectedLayers: nil,
+		},
+		{
+			name:           "case 1: tar.gz archive",
+			artifact:       wrap(t, snapshot, ""),
+			expectedLayers: []string{"gzip", "tar"},
+		},
+		{
+			name:           "case 2: encrypted tar.gz archive",
+			artifact:       wrap(t, snapshot, "secret"),
+			passphrase:     "secret",
+			expectedLayers: []string{"openpgp", "gzip", "tar"},
+		},
+		{
+			name:         "case 3: missing passphrase",
+			artifact:     wrap(t, snapshot, "secret"),
+			errorMatcher: IsMissingPassphrase,
+		},
+		{
+			name:         "case 4: unknown format",
+			artifact:     []byte("etcd snapshot content"),
+			errorMatcher: IsUnknownFormat,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var out bytes.Buffer
+			layers, n, err := Extract(context.Background(), bytes.NewReader(tc.artifact), &out, Options{Passphrase: tc.passphrase})
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher != nil {
+				return
+			}
+
+			if !cmp.Equal(layers, tc.expectedLayers) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedLayers, layers))
+			}
+			if n != int64(len(snapshot)) || !bytes.Equal(out.Bytes(), snapshot) {
+				t.Fatalf("extracted %d bytes which differ from the snapshot", n)
+			}
+		})
+	}
+}
+
+func Test_Reencrypt(t *testing.T) {
+	snapshot := writeSnapshot(t, map[string]string{"/registry/pods/default/a": "a"})
+
+	var reencrypted bytes.Buffer
+	n, err := Reencrypt(context.Background(), bytes.NewReader(wrap(t, snapshot, "secret")), &reencrypted, Options{Passphrase: "secret"}, "one-off")
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	if n != int64(len(snapshot)) {
+		t.Fatalf("reencrypted %d bytes, want %d", n, len(snapshot))
+	}
+
+	// The original passphrase does not decrypt the artifact.
+	_, _, err = Extract(context.Background(), bytes.NewReader(reencrypted.Bytes()), io.Discard, Options{Passphrase: "secret"})
+	if err == nil {
+		t.Fatalf("error == nil, want non-nil")
+	}
+
+	var out bytes.Buffer
+	layers, _, err := Extract(context.Background(), bytes.NewReader(reencrypted.Bytes()), &out, Options{Passphrase: "one-off"})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	if !cmp.Equal(layers, []string{"openpgp", "gzip"}) {
+		t.Fatalf("\n\n%s\n", cmp.Diff([]string{"openpgp", "gzip"}, layers))
+	}
+	if !bytes.Equal(out.Bytes(), snapshot) {
+		t.Fatalf("extracted snapshot differs from the snapshot")
+	}
+}
+
+func Test_Inspect(t *testing.T) {
+	snapshot := writeSnapshot(t, map[string]string{
+		"/registry/pods/default/a":        "aaaa",
+		"/registry/pods/default/b":        "bbbb",
+		"/registry/secrets/default/token": "s",
+		"compact_rev_key":                 "",
+	})
+
+	fpath := filepath.Join(t.TempDir(), "snapshot.db")
+	err := os.WriteFile(fpath, snapshot, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Inspect(fpath, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Revision != 5 {
+		t.Fatalf("revision == %d, want 5", s.Revision)
+	}
+	if s.TotalKeys != 4 {
+		t.Fatalf("total keys == %d, want 4", s.TotalKeys)
+	}
+	if s.TotalSize <= 0 || s.TotalSize > int64(len(snapshot)) {
+		t.Fatalf("total size == %d, want at most %d", s.TotalSize, len(snapshot))
+	}
+
+	expected := []PrefixStat{
+		{Prefix: "/registry/pods", Keys: 2, Bytes: 56},
+		{Prefix: "/registry/secrets", Keys: 1, Bytes: 32},
+		{Prefix: "compact_rev_key", Keys: 1, Bytes: 15},
+	}
+	if !cmp.Equal(s.Prefixes, expected) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, s.Prefixes))
+	}
+
+	again, err := Inspect(fpath, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Hash != s.Hash || again.Prefixes != nil {
+		t.Fatalf("hash == %d, want %d without prefixes", again.Hash, s.Hash)
+	}
+}
@@ -0,0 +1,112 @@
+package artifact
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/encrypt"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/key"
+)
+
+const (
+	// boltMagic is stored in the meta pages of bbolt databases, right after
+	// the 16 bytes long page header.
+	boltMagic       = 0xED0CDAED
+	boltMagicOffset = 16
+
+	tarMagicOffset = 257
+)
+
+// isSnapshot tells whether the data starting with header is a bbolt
+// database, i.e. an etcd snapshot.
+func isSnapshot(header []byte) bool {
+	if len(header) < boltMagicOffset+4 {
+		return false
+	}
+
+	return binary.LittleEndian.Uint32(header[boltMagicOffset:]) == boltMagic
+}
+
+// openPGPDecoder decrypts OpenPGP messages symmetrically encrypted with a
+// passphrase, as written by the Backupper.
+type openPGPDecoder struct{}
+
+func (openPGPDecoder) Name() string {
+	return "openpgp"
+}
+
+func (openPGPDecoder) Detect(header []byte) bool {
+	if len(header) == 0 {
+		return false
+	}
+
+	// Symmetric-Key Encrypted Session Key packet (tag 3) in the new or the
+	// old packet format.
+	return header[0] == 0xC3 || header[0]&0xFC == 0x8C
+}
+
+func (openPGPDecoder) Open(r io.Reader, options Options) (io.Reader, error) {
+	if options.Passphrase == "" {
+		return nil, microerror.Maskf(missingPassphraseError, "artifact is encrypted but no passphrase was given")
+	}
+
+	decrypted, err := encrypt.NewReader(r, options.Passphrase)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return decrypted, nil
+}
+
+type gzipDecoder struct{}
+
+func (gzipDecoder) Name() string {
+	return "gzip"
+}
+
+func (gzipDecoder) Detect(header []byte) bool {
+	return bytes.HasPrefix(header, []byte{0x1f, 0x8b})
+}
+
+func (gzipDecoder) Open(r io.Reader, options Options) (io.Reader, error) {
+	z, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return z, nil
+}
+
+// tarDecoder streams the first .db file out of a tar archive.
+type tarDecoder struct{}
+
+func (tarDecoder) Name() string {
+	return "tar"
+}
+
+func (tarDecoder) Detect(header []byte) bool {
+	return len(header) >= tarMagicOffset+5 && string(header[tarMagicOffset:tarMagicOffset+5]) == "ustar"
+}
+
+func (tarDecoder) Open(r io.Reader, options Options) (io.Reader, error) {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, microerror.Maskf(unknownFormatError, "tar archive does not contain a %s file", key.DbExt)
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if h.Typeflag == tar.TypeReg && strings.HasSuffix(path.Base(h.Name), key.DbExt) {
+			return tr, nil
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package artifact
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var missingPassphraseError = &microerror.Error{
+	Kind: "missingPassphraseError",
+}
+
+// IsMissingPassphrase asserts missingPassphraseError.
+func IsMissingPassphrase(err error) bool {
+	return microerror.Cause(err) == missingPassphraseError
+}
+
+var unknownFormatError = &microerror.Error{
+	Kind: "unknownFormatError",
+}
+
+// IsUnknownFormat asserts unknownFormatError.
+func IsUnknownFormat(err error) bool {
+	return microerror.Cause(err) == unknownFormatError
+}
+
+var invalidSnapshotError = &microerror.Error{
+	Kind: "invalidSnapshotError",
+}
+
+// IsInvalidSnapshot asserts invalidSnapshotError.
+func IsInvalidSnapshot(err error) bool {
+	return microerror.Cause(err) == invalidSnapshotError
+}
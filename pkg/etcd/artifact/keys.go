@@ -0,0 +1,78 @@
+package artifact
+
+import (
+	"time"
+
+	"github.com/giantswarm/microerror"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// keyBucket is the bucket etcd stores the revisions of all keys in.
+	keyBucket = "key"
+	// revisionLength is the length of the keys in keyBucket: the main and the
+	// sub revision separated by '_'.
+	revisionLength = 17
+	// tombstoneMarker is appended to the revisions deleting a key.
+	tombstoneMarker = 't'
+)
+
+// openSnapshot opens the etcd snapshot at fpath read-only.
+func openSnapshot(fpath string) (*bolt.DB, error) {
+	db, err := bolt.Open(fpath, 0400, &bolt.Options{ReadOnly: true, Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, microerror.Maskf(invalidSnapshotError, "%#q is not an etcd snapshot: %s", fpath, err)
+	}
+
+	return db, nil
+}
+
+// Revisions calls fn for every revision of every key stored in the etcd
+// snapshot at fpath, oldest first. tombstone is true for the revisions
+// deleting a key. A snapshot taken after compaction only contains the latest
+// revision of every key, and the tombstones of keys deleted since the
+// previous compaction.
+func Revisions(fpath string, fn func(kv *mvccpb.KeyValue, tombstone bool) error) error {
+	db, err := openSnapshot(fpath)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(keyBucket))
+		if b == nil {
+			return microerror.Maskf(invalidSnapshotError, "snapshot has no %#q bucket", keyBucket)
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			kv, err := decodeKeyValue(v)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return fn(kv, isTombstone(k))
+		})
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func decodeKeyValue(v []byte) (*mvccpb.KeyValue, error) {
+	var kv mvccpb.KeyValue
+	err := proto.Unmarshal(v, &kv)
+	if err != nil {
+		return nil, microerror.Maskf(invalidSnapshotError, "cannot decode key value: %s", err)
+	}
+
+	return &kv, nil
+}
+
+func isTombstone(revision []byte) bool {
+	return len(revision) == revisionLength+1 && revision[revisionLength] == tombstoneMarker
+}
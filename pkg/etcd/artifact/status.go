@@ -0,0 +1,137 @@
+package artifact
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Status describes an etcd snapshot the same way `etcdutl snapshot status`
+// does, plus a histogram of the keys by prefix.
+type Status struct {
+	Hash      uint32 `json:"hash"`
+	Revision  int64  `json:"revision"`
+	TotalKeys int    `json:"totalKeys"`
+	TotalSize int64  `json:"totalSize"`
+
+	Prefixes []PrefixStat `json:"prefixes,omitempty"`
+}
+
+// PrefixStat counts the live keys below a prefix and the bytes their latest
+// revision occupies.
+type PrefixStat struct {
+	Prefix string `json:"prefix"`
+	Keys   int    `json:"keys"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// Inspect reads the status of the etcd snapshot at fpath. The histogram
+// groups keys by their first depth path segments, e.g. /registry/pods for a
+// depth of 2. A depth of zero disables the histogram.
+func Inspect(fpath string, depth int) (Status, error) {
+	db, err := openSnapshot(fpath)
+	if err != nil {
+		return Status{}, microerror.Mask(err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	var s Status
+	prefixes := map[string]*PrefixStat{}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		s.TotalSize = tx.Size()
+
+		h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+		c := tx.Cursor()
+		for name, _ := c.First(); name != nil; name, _ = c.Next() {
+			b := tx.Bucket(name)
+			if b == nil {
+				return microerror.Maskf(invalidSnapshotError, "cannot read bucket %#q", name)
+			}
+			_, _ = h.Write(name)
+
+			isKeyBucket := string(name) == keyBucket
+			err := b.ForEach(func(k, v []byte) error {
+				_, _ = h.Write(k)
+				_, _ = h.Write(v)
+				s.TotalKeys++
+
+				if !isKeyBucket || len(k) < revisionLength {
+					return nil
+				}
+				s.Revision = int64(binary.BigEndian.Uint64(k[0:8])) // nolint:gosec
+
+				if depth > 0 && !isTombstone(k) {
+					err := countKey(prefixes, v, depth)
+					if err != nil {
+						return microerror.Mask(err)
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		s.Hash = h.Sum32()
+		return nil
+	})
+	if err != nil {
+		return Status{}, microerror.Mask(err)
+	}
+
+	for _, p := range prefixes {
+		s.Prefixes = append(s.Prefixes, *p)
+	}
+	sort.Slice(s.Prefixes, func(i, j int) bool {
+		if s.Prefixes[i].Bytes != s.Prefixes[j].Bytes {
+			return s.Prefixes[i].Bytes > s.Prefixes[j].Bytes
+		}
+		return s.Prefixes[i].Prefix < s.Prefixes[j].Prefix
+	})
+
+	return s, nil
+}
+
+// countKey adds the key value stored in a revision to the histogram. A
+// snapshot taken after compaction only contains the latest revision of every
+// key.
+func countKey(prefixes map[string]*PrefixStat, v []byte, depth int) error {
+	kv, err := decodeKeyValue(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	p := Prefix(string(kv.Key), depth)
+	stat, ok := prefixes[p]
+	if !ok {
+		stat = &PrefixStat{Prefix: p}
+		prefixes[p] = stat
+	}
+	stat.Keys++
+	stat.Bytes += int64(len(kv.Key) + len(kv.Value))
+
+	return nil
+}
+
+// Prefix returns the first depth path segments of key, e.g.
+// /registry/pods for /registry/pods/kube-system/etcd-0 and a depth of 2.
+func Prefix(key string, depth int) string {
+	segments := strings.SplitN(strings.TrimPrefix(key, "/"), "/", depth+1)
+	if len(segments) > depth {
+		segments = segments[:depth]
+	}
+
+	prefix := strings.Join(segments, "/")
+	if strings.HasPrefix(key, "/") {
+		prefix = "/" + prefix
+	}
+
+	return prefix
+}
@@ -0,0 +1,123 @@
+// Package drbundle writes and reads DR bundles, encrypted archives of the
+// management cluster objects needed to rebuild a workload cluster from its
+// etcd backup, e.g. its certificates, kubeconfig and CAPI manifests. A
+// bundle is uploaded next to the backup it belongs to.
+package drbundle
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/encrypt"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/yamlarchive"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/key"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/objects"
+)
+
+const (
+	// Version takes the place of the etcd version in the name of a bundle,
+	// e.g. gauss-foo-dr-2024-05-01T12-00-00.tar.gz.enc.
+	Version = "dr"
+)
+
+// Filename returns the name of the bundle belonging to the backup with the
+// given name, which has the same prefix and timestamp. It returns false if
+// backup is not named like a backup.
+func Filename(backup string) (string, bool) {
+	a, ok := key.ParseFilename(backup)
+	if !ok || !a.Snapshot {
+		return "", false
+	}
+
+	return a.Prefix + "-" + Version + "-" + a.Timestamp.Format(key.TsFormat) + key.TgzExt + key.EncExt, true
+}
+
+// Write writes the objects to fpath as an encrypted tar.gz archive with one
+// YAML file per object. Bundles contain secrets, so they are never written
+// without passphrase.
+func Write(ctx context.Context, fpath string, objs []*unstructured.Unstructured, passphrase string) error {
+	if passphrase == "" {
+		return microerror.Maskf(missingPassphraseError, "DR bundles contain secrets and must be encrypted")
+	}
+
+	f, err := os.OpenFile(fpath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	encrypted, err := encrypt.NewWriter(f, passphrase)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	w := yamlarchive.NewWriter(encrypted)
+
+	for _, u := range objs {
+		if err := ctx.Err(); err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = w.WriteObject(objects.Portable(u))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, c := range []io.Closer{w, encrypted, f} {
+		err = c.Close()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// Read decrypts the bundle read from r and returns its objects sorted by
+// their path in the bundle.
+func Read(r io.Reader, passphrase string) ([]*unstructured.Unstructured, error) {
+	if passphrase == "" {
+		return nil, microerror.Maskf(missingPassphraseError, "DR bundles are encrypted")
+	}
+
+	decrypted, err := encrypt.NewReader(r, passphrase)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var objs []*unstructured.Unstructured
+	paths := map[*unstructured.Unstructured]string{}
+	err = yamlarchive.Read(decrypted, func(name string, b []byte) error {
+		if !strings.HasSuffix(path.Base(name), ".yaml") {
+			return nil
+		}
+
+		u, err := yamlarchive.DecodeObject(name, b)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		objs = append(objs, u)
+		paths[u] = name
+
+		return nil
+	})
+	if yamlarchive.IsInvalidArchive(err) {
+		return nil, microerror.Maskf(invalidBundleError, "%s", err)
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	sort.SliceStable(objs, func(i, j int) bool {
+		return paths[objs[i]] < paths[objs[j]]
+	})
+
+	return objs, nil
+}
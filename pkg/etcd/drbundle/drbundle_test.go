@@ -0,0 +1,142 @@
+package drbundle
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/encrypt"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/yamlarchive/yamlarchivetest"
+)
+
+func Test_WriteAndRead(t *testing.T) {
+	secret := yamlarchivetest.Object("v1", "Secret", "org-acme", "foo-etcd")
+	secret.SetUID("1234")
+	secret.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "controlplane.cluster.x-k8s.io/v1beta2", Kind: "KubeadmControlPlane", Name: "foo", UID: "5678"}})
+	_ = unstructured.SetNestedField(secret.Object, "Y2E=", "data", "tls.crt")
+
+	cluster := yamlarchivetest.Object("cluster.x-k8s.io/v1beta2", "Cluster", "org-acme", "foo")
+	_ = unstructured.SetNestedField(cluster.Object, "Provisioned", "status", "phase")
+
+	testCases := []struct {
+		name         string
+		passphrase   string
+		readWith     string
+		expected     []*unstructured.Unstructured
+		errorMatcher func(error) bool
+	}{
+		{
+			name:       "case 0: objects are restored without server fields and owner references",
+			passphrase: "secret",
+			readWith:   "secret",
+			expected: []*unstructured.Unstructured{
+				yamlarchivetest.Object("cluster.x-k8s.io/v1beta2", "Cluster", "org-acme", "foo"),
+				func() *unstructured.Unstructured {
+					u := yamlarchivetest.Object("v1", "Secret", "org-acme", "foo-etcd")
+					_ = unstructured.SetNestedField(u.Object, "Y2E=", "data", "tls.crt")
+					return u
+				}(),
+			},
+		},
+		{
+			name:         "case 1: bundles are not written without passphrase",
+			errorMatcher: IsMissingPassphrase,
+		},
+		{
+			name:         "case 2: wrong passphrase",
+			passphrase:   "secret",
+			readWith:     "guess",
+			errorMatcher: encrypt.IsWrongPassphrase,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fpath := filepath.Join(t.TempDir(), "bundle.tar.gz.enc")
+
+			var objs []*unstructured.Unstructured
+			err := Write(context.Background(), fpath, []*unstructured.Unstructured{secret, cluster}, tc.passphrase)
+			if err == nil {
+				var f *os.File
+				f, err = os.Open(fpath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close() //nolint:errcheck
+
+				objs, err = Read(f, tc.readWith)
+			}
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher != nil {
+				return
+			}
+
+			if !cmp.Equal(objs, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, objs))
+			}
+		})
+	}
+}
+
+func Test_Filename(t *testing.T) {
+	testCases := []struct {
+		name     string
+		backup   string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "case 0: encrypted backup",
+			backup:   "gauss-foo-v3-2024-05-01T12-00-00.db.tar.gz.enc",
+			expected: "gauss-foo-dr-2024-05-01T12-00-00.tar.gz.enc",
+			ok:       true,
+		},
+		{
+			name:     "case 1: unencrypted backup",
+			backup:   "gauss-foo-v3-2024-05-01T12-00-00.db.tar.gz",
+			expected: "gauss-foo-dr-2024-05-01T12-00-00.tar.gz.enc",
+			ok:       true,
+		},
+		{
+			name:   "case 2: not a backup",
+			backup: "foo.db",
+		},
+		{
+			name:   "case 3: bundle",
+			backup: "gauss-foo-dr-2024-05-01T12-00-00.tar.gz.enc",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			filename, ok := Filename(tc.backup)
+			if ok != tc.ok {
+				t.Fatalf("ok == %t, want %t", ok, tc.ok)
+			}
+			if filename != tc.expected {
+				t.Fatalf("filename == %q, want %q", filename, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package drbundle
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var missingPassphraseError = &microerror.Error{
+	Kind: "missingPassphraseError",
+}
+
+// IsMissingPassphrase asserts missingPassphraseError.
+func IsMissingPassphrase(err error) bool {
+	return microerror.Cause(err) == missingPassphraseError
+}
+
+var invalidBundleError = &microerror.Error{
+	Kind: "invalidBundleError",
+}
+
+// IsInvalidBundle asserts invalidBundleError.
+func IsInvalidBundle(err error) bool {
+	return microerror.Cause(err) == invalidBundleError
+}
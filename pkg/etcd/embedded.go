@@ -0,0 +1,49 @@
+package etcd
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// EmbeddedBackup backs up the etcd embedded in k3s and RKE2 servers. Unlike
+// V3Backup it neither compacts nor defragments etcd: the servers compact it
+// themselves, and a defragmentation would block the API server running in
+// the same process.
+type EmbeddedBackup struct {
+	V3Backup
+}
+
+func NewEmbeddedBackup(tlsConfig *tls.Config, p *proxy.Proxy, encPass string, endpoints string, logger micrologger.Logger, prefix string) (EmbeddedBackup, error) {
+	b, err := NewV3Backup(tlsConfig, p, encPass, endpoints, logger, prefix)
+	if err != nil {
+		return EmbeddedBackup{}, microerror.Mask(err)
+	}
+
+	return EmbeddedBackup{V3Backup: b}, nil
+}
+
+// Create etcd in temporary directory.
+func (b EmbeddedBackup) Create(ctx context.Context) (string, error) {
+	members, err := b.probeMembers(ctx, b.probeMember)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	source := snapshotMember(members)
+
+	fpath, err := b.snapshot(ctx, source, b.Version(), source.Revision)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return fpath, nil
+}
+
+func (b EmbeddedBackup) Version() string {
+	return DatastoreEmbedded
+}
@@ -0,0 +1,49 @@
+package etcd
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidSnapshotError = &microerror.Error{
+	Kind: "invalidSnapshotError",
+}
+
+// IsInvalidSnapshot asserts invalidSnapshotError.
+func IsInvalidSnapshot(err error) bool {
+	return microerror.Cause(err) == invalidSnapshotError
+}
+
+var notRestorableError = &microerror.Error{
+	Kind: "notRestorableError",
+}
+
+// IsNotRestorable asserts notRestorableError.
+func IsNotRestorable(err error) bool {
+	return microerror.Cause(err) == notRestorableError
+}
+
+var restoreFailedError = &microerror.Error{
+	Kind: "restoreFailedError",
+}
+
+// IsRestoreFailed asserts restoreFailedError.
+func IsRestoreFailed(err error) bool {
+	return microerror.Cause(err) == restoreFailedError
+}
+
+// executionFailedError should never be matched against and therefore there is
+// no matcher implement. For further information see:
+//
+//	https://github.com/giantswarm/fmt/blob/master/go/errors.md#matching-errors
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
@@ -21,13 +21,18 @@ import (
 )
 
 type V3Backup struct {
-	EncPass   string
+	EncPass string
+	// Endpoints are the comma separated endpoints of the members of the
+	// etcd cluster.
 	Endpoints string
 	Logger    micrologger.Logger
 	Prefix    string
 
 	etcdClient *clientv3.Client
+	tlsConfig  *tls.Config
+	proxy      *proxy.Proxy
 	filename   *string
+	info       *SnapshotInfo
 	tmpDir     *string
 }
 
@@ -35,7 +40,7 @@ func NewV3Backup(tlsConfig *tls.Config, p *proxy.Proxy, encPass string, endpoint
 	filename := ""
 	tmpDir := ""
 
-	etcdClient, err := createEtcdV3Client(endpoints, tlsConfig, p)
+	etcdClient, err := NewV3Client(endpoints, tlsConfig, p)
 	if err != nil {
 		return V3Backup{}, microerror.Mask(err)
 	}
@@ -47,26 +52,57 @@ func NewV3Backup(tlsConfig *tls.Config, p *proxy.Proxy, encPass string, endpoint
 		Prefix:    prefix,
 
 		etcdClient: etcdClient,
+		tlsConfig:  tlsConfig,
+		proxy:      p,
 		filename:   &filename,
+		info:       &SnapshotInfo{},
 		tmpDir:     &tmpDir,
 	}, nil
 }
 
-func createEtcdV3Client(endpoint string, tlsConfig *tls.Config, p *proxy.Proxy) (*clientv3.Client, error) {
+// NewLocalV3Backup returns the V3Backup of the single etcd member c is
+// connected to, e.g. the member on the node of an agent. c is shared, the
+// backup does not close it.
+func NewLocalV3Backup(c *clientv3.Client, encPass string, logger micrologger.Logger, prefix string) (V3Backup, error) {
+	endpoints := c.Endpoints()
+	if len(endpoints) != 1 {
+		return V3Backup{}, microerror.Maskf(invalidConfigError, "local backups need a client of exactly one etcd member, got %d endpoints", len(endpoints))
+	}
+
+	filename := ""
+	tmpDir := ""
+
+	return V3Backup{
+		EncPass:   encPass,
+		Endpoints: endpoints[0],
+		Logger:    logger,
+		Prefix:    prefix,
+
+		etcdClient: c,
+		filename:   &filename,
+		info:       &SnapshotInfo{},
+		tmpDir:     &tmpDir,
+	}, nil
+}
+
+// NewV3Client returns an etcd client for the comma separated endpoints. When
+// p is not nil the connection goes through the API server port-forward proxy,
+// or to the nodes of p, and endpoint is the name of the etcd pod.
+func NewV3Client(endpoint string, tlsConfig *tls.Config, p *proxy.Proxy) (*clientv3.Client, error) {
 	dialOpt := []grpc.DialOption{}
 
 	// add proxy dialer if proxy is not nil
 	if p != nil {
-		dialer, err := proxy.NewDialer(*p)
+		dial, err := proxy.ContextDialer(*p)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 
-		dialOpt = append(dialOpt, grpc.WithContextDialer(dialer.DialContextWithAddr))
+		dialOpt = append(dialOpt, grpc.WithContextDialer(dial))
 	}
 
 	c, err := clientv3.New(clientv3.Config{
-		Endpoints:   []string{endpoint},
+		Endpoints:   SplitEndpoints(endpoint),
 		DialTimeout: time.Second * 60,
 		DialOptions: dialOpt,
 		TLS:         tlsConfig,
@@ -79,30 +115,77 @@ func createEtcdV3Client(endpoint string, tlsConfig *tls.Config, p *proxy.Proxy)
 	return c, nil
 }
 
-// Cleanup clears temporary directory
+// Cleanup clears temporary directory. The next backup creates a new one.
 func (b V3Backup) Cleanup() {
-	os.RemoveAll(b.getTmpDir()) //nolint:errcheck,gosec
+	if len(*b.tmpDir) == 0 {
+		return
+	}
+
+	os.RemoveAll(*b.tmpDir) //nolint:errcheck,gosec
+	*b.tmpDir = ""
 }
 
 // Create etcd in temporary directory.
-func (b V3Backup) Create() (string, error) {
-	ctx := context.Background()
-	err := b.compactAndDefrag()
+func (b V3Backup) Create(ctx context.Context) (string, error) {
+	members, err := b.probeMembers(ctx, b.probeMember)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
 
-	// filename
-	*b.filename = b.Prefix + "-v3-" + time.Now().Format(key.TsFormat) + key.DbExt
+	source := snapshotMember(members)
 
-	// Full path to file.
-	fpath := filepath.Join(b.getTmpDir(), *b.filename)
+	err = b.compactAndDefrag(ctx, source.Revision, defragMembers(members))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	fpath, err := b.snapshot(ctx, source, b.Version(), source.Revision)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return fpath, nil
+}
+
+// snapshot streams a snapshot of the etcd member m taken at revision into the
+// temporary directory and archives it, naming it after version.
+func (b V3Backup) snapshot(ctx context.Context, m member, version string, revision int64) (string, error) {
+	// The client of b may be connected to any of the endpoints, so members
+	// of clusters with several endpoints are connected to on their own.
+	c := b.etcdClient
+	if len(SplitEndpoints(b.Endpoints)) > 1 {
+		var err error
+		c, err = NewV3Client(m.Endpoint, b.tlsConfig, b.proxy)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		defer c.Close() //nolint:errcheck
+	}
+	b.Logger.Debugf(ctx, "Taking snapshot from etcd member %s at revision %d", m.Endpoint, revision)
 
 	// Create a etcd.
-	snapshot, err := b.etcdClient.Snapshot(ctx)
+	snapshot, err := c.Snapshot(ctx)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
+	defer func() { _ = snapshot.Close() }()
+
+	fpath, err := b.save(snapshot, version, revision)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return fpath, nil
+}
+
+// save writes the snapshot read from r, taken at revision, into the
+// temporary directory and archives it, naming it after version.
+func (b V3Backup) save(r io.Reader, version string, revision int64) (string, error) {
+	// filename
+	*b.filename = b.Prefix + "-" + version + "-" + time.Now().Format(key.TsFormat) + key.DbExt
+
+	// Full path to file.
+	fpath := filepath.Join(b.getTmpDir(), *b.filename)
 
 	outFile, err := os.Create(fpath) //nolint:gosec
 	if err != nil {
@@ -110,13 +193,24 @@ func (b V3Backup) Create() (string, error) {
 	}
 	// handle err
 	defer func() { _ = outFile.Close() }()
-	_, err = io.Copy(outFile, snapshot)
+	size, err := io.Copy(outFile, r)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	fpath, err = b.archive(fpath, revision, size)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
 
+	return fpath, nil
+}
+
+// archive compresses the snapshot at fpath, taken at revision and of size
+// bytes, and records its info.
+func (b V3Backup) archive(fpath string, revision int64, size int64) (string, error) {
 	// Create tar.gz.
-	err = archiver.Archive([]string{fpath}, fpath+key.TgzExt)
+	err := archiver.Archive([]string{fpath}, fpath+key.TgzExt)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
@@ -125,12 +219,23 @@ func (b V3Backup) Create() (string, error) {
 	*b.filename = *b.filename + key.TgzExt
 	fpath = filepath.Join(b.getTmpDir(), *b.filename)
 
-	b.Logger.Log("level", "info", "msg", "Etcd v3 backup created successfully", "file", b.filename)
+	archive, err := os.Stat(fpath)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	*b.info = SnapshotInfo{
+		Revision:    revision,
+		Size:        size,
+		ArchiveSize: archive.Size(),
+	}
+
+	b.Logger.Log("level", "info", "msg", "Etcd backup created successfully", "file", b.filename)
 	return fpath, nil
 }
 
 // Encrypt backup.
-func (b V3Backup) Encrypt() (string, error) {
+func (b V3Backup) Encrypt(ctx context.Context) (string, error) {
 	// Full path to file.
 	fpath := filepath.Join(b.getTmpDir(), *b.filename)
 
@@ -140,7 +245,7 @@ func (b V3Backup) Encrypt() (string, error) {
 	}
 
 	// Encrypt etcd.
-	err := encrypt.File(fpath, fpath+key.EncExt, b.EncPass)
+	err := encrypt.File(ctx, fpath, fpath+key.EncExt, b.EncPass)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
@@ -153,6 +258,10 @@ func (b V3Backup) Encrypt() (string, error) {
 	return fpath, nil
 }
 
+func (b V3Backup) Info() SnapshotInfo {
+	return *b.info
+}
+
 func (b V3Backup) Version() string {
 	return "v3"
 }
@@ -170,35 +279,28 @@ func (b V3Backup) getTmpDir() string {
 	return *b.tmpDir
 }
 
-func (b V3Backup) compactAndDefrag() error {
-	ctx := context.Background()
-	b.Logger.Debugf(ctx, "Compacting etcd instance")
-	// Get latest revision.
-	s, err := b.etcdClient.Status(ctx, b.Endpoints)
-
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	b.Logger.Debugf(context.Background(), "Revision is %d", s.Header.Revision)
-
-	_, err = b.etcdClient.Compact(ctx, s.Header.Revision)
+// compactAndDefrag compacts etcd at revision and defragments the members
+// one after the other.
+func (b V3Backup) compactAndDefrag(ctx context.Context, revision int64, members []member) error {
+	b.Logger.Debugf(ctx, "Compacting etcd instance at revision %d", revision)
 
+	_, err := b.etcdClient.Compact(ctx, revision)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	b.Logger.Debugf(context.Background(), "Compacted etcd instance")
+	b.Logger.Debugf(ctx, "Compacted etcd instance")
 
-	b.Logger.Debugf(context.Background(), "Defragging etcd instance")
+	for _, m := range members {
+		b.Logger.Debugf(ctx, "Defragging etcd member %s", m.Endpoint)
 
-	_, err = b.etcdClient.Defragment(ctx, b.Endpoints)
+		_, err = b.etcdClient.Defragment(ctx, m.Endpoint)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-	if err != nil {
-		return microerror.Mask(err)
+		b.Logger.Debugf(ctx, "Defragged etcd member %s", m.Endpoint)
 	}
 
-	b.Logger.Debugf(context.Background(), "Defragged etcd instance")
-
 	return nil
 }
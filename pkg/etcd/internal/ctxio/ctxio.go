@@ -0,0 +1,26 @@
+// Package ctxio bounds reads by the lifetime of a context.
+package ctxio
+
+import (
+	"context"
+	"io"
+)
+
+// NewReader returns a reader which stops reading from r as soon as ctx is
+// done.
+func NewReader(ctx context.Context, r io.Reader) io.Reader {
+	return reader{ctx: ctx, r: r}
+}
+
+type reader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c reader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.r.Read(p)
+}
@@ -1,45 +1,176 @@
 package encrypt
 
 import (
-	"bytes"
+	"context"
+	"io"
 	"os"
 
 	"github.com/giantswarm/microerror"
 	"golang.org/x/crypto/openpgp" //nolint
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/ctxio"
 )
 
-// Encrypt data with passphrase.
-func data(value []byte, pass string) (ciphertext []byte, err error) {
-	buf := bytes.NewBuffer(nil)
+// Encrypts file from srcPath and writes encrypted data to dstPart. The
+// encryption is aborted as soon as ctx is done.
+func File(ctx context.Context, srcPath string, dstPart string, passphrase string) error {
+	src, err := os.Open(srcPath) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer src.Close() //nolint:errcheck
 
-	encrypter, err := openpgp.SymmetricallyEncrypt(buf, []byte(pass), nil, nil)
+	dst, err := os.OpenFile(dstPart, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(0600)) // #nosec G304
 	if err != nil {
-		return nil, microerror.Mask(err)
+		return microerror.Mask(err)
+	}
+	defer dst.Close() //nolint:errcheck
+
+	encrypter, err := openpgp.SymmetricallyEncrypt(dst, []byte(passphrase), nil, nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = io.Copy(encrypter, ctxio.NewReader(ctx, src))
+	if err != nil {
+		encrypter.Close() //nolint:errcheck,gosec
+		return microerror.Mask(err)
+	}
+
+	err = encrypter.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Decrypts file from srcPath and writes decrypted data to dstPath. The
+// decryption is aborted as soon as ctx is done.
+func Decrypt(ctx context.Context, srcPath string, dstPath string, passphrase string) error {
+	src, err := os.Open(srcPath) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer src.Close() //nolint:errcheck
+
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(0600)) // #nosec G304
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer dst.Close() //nolint:errcheck
+
+	decrypted, err := NewReader(src, passphrase)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = io.Copy(dst, ctxio.NewReader(ctx, decrypted))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
-	_, err = encrypter.Write(value)
+	return nil
+}
+
+// NewReader returns a reader streaming the data decrypted from src.
+func NewReader(src io.Reader, passphrase string) (io.Reader, error) {
+	prompted := false
+	md, err := openpgp.ReadMessage(src, nil, func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		// The prompt is called again when the passphrase is wrong.
+		if prompted {
+			return nil, microerror.Maskf(wrongPassphraseError, "passphrase does not decrypt the data")
+		}
+		prompted = true
+		return []byte(passphrase), nil
+	}, nil)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	encrypter.Close() //nolint:errcheck,gosec
+	return &bodyReader{r: md.UnverifiedBody}, nil
+}
+
+// bodyReader keeps returning the first error of the body of a message. The
+// integrity of the message is checked again, and fails, every time the body
+// is read after it returned io.EOF, which readers like gzip do.
+type bodyReader struct {
+	r   io.Reader
+	err error
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	n, err := b.r.Read(p)
+	b.err = err
+
+	return n, err
+}
+
+// NewWriter returns a writer encrypting the data written to it into dst. The
+// writer must be closed to complete the encrypted data.
+func NewWriter(dst io.Writer, passphrase string) (io.WriteCloser, error) {
+	w, err := openpgp.SymmetricallyEncrypt(dst, []byte(passphrase), nil, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 
-	return buf.Bytes(), nil
+	return w, nil
 }
 
-// Encrypts file from srcPath and writes encrypted data to dstPart.
-func File(srcPath string, dstPart string, passphrase string) error {
-	contents, err := os.ReadFile(srcPath) //nolint:gosec
+// Reencrypt decrypts the file from srcPath with passphrase and writes it to
+// dstPath encrypted with newPassphrase. The data is only streamed, it is
+// never written to disk decrypted. The reencryption is aborted as soon as
+// ctx is done.
+func Reencrypt(ctx context.Context, srcPath string, dstPath string, passphrase string, newPassphrase string) error {
+	src, err := os.Open(srcPath) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer src.Close() //nolint:errcheck
+
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(0600)) // #nosec G304
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer dst.Close() //nolint:errcheck
+
+	decrypted, err := NewReader(src, passphrase)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	encrypter, err := NewWriter(dst, newPassphrase)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The integrity of the source is checked once it is read to its end.
+	_, err = io.Copy(encrypter, ctxio.NewReader(ctx, decrypted))
 	if err != nil {
+		encrypter.Close() //nolint:errcheck,gosec
 		return microerror.Mask(err)
 	}
 
-	encData, err := data(contents, passphrase)
+	err = encrypter.Close()
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	err = os.WriteFile(dstPart, encData, os.FileMode(0600)) // #nosec G703
+	err = dst.Close()
 	if err != nil {
 		return microerror.Mask(err)
 	}
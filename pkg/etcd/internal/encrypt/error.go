@@ -0,0 +1,14 @@
+package encrypt
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var wrongPassphraseError = &microerror.Error{
+	Kind: "wrongPassphraseError",
+}
+
+// IsWrongPassphrase asserts wrongPassphraseError.
+func IsWrongPassphrase(err error) bool {
+	return microerror.Cause(err) == wrongPassphraseError
+}
@@ -0,0 +1,14 @@
+package yamlarchive
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidArchiveError = &microerror.Error{
+	Kind: "invalidArchiveError",
+}
+
+// IsInvalidArchive asserts invalidArchiveError.
+func IsInvalidArchive(err error) bool {
+	return microerror.Cause(err) == invalidArchiveError
+}
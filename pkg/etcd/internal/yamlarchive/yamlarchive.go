@@ -0,0 +1,128 @@
+// Package yamlarchive writes and reads the tar.gz archives of YAML files
+// which DR bundles and inventories are made of.
+package yamlarchive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/objects"
+)
+
+type Writer struct {
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	modTime time.Time
+}
+
+// NewWriter returns a writer writing a tar.gz archive to w. It must be closed
+// to complete the archive, w is not closed.
+func NewWriter(w io.Writer) *Writer {
+	gz := gzip.NewWriter(w)
+
+	return &Writer{
+		gz:      gz,
+		tw:      tar.NewWriter(gz),
+		modTime: time.Now(),
+	}
+}
+
+// WriteFile adds a file with the given content to the archive.
+func (w *Writer) WriteFile(name string, b []byte) error {
+	err := w.tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(b)),
+		ModTime:  w.modTime,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = w.tw.Write(b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// WriteObject adds the object to the archive as YAML file at the path
+// objects.WriteFiles would write it to.
+func (w *Writer) WriteObject(u *unstructured.Unstructured) error {
+	b, err := yaml.Marshal(u.Object)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = w.WriteFile(objects.Path(u), b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (w *Writer) Close() error {
+	err := w.tw.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = w.gz.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Read calls fn with the name and content of every regular file of the
+// archive read from r, in the order of the archive.
+func Read(r io.Reader, fn func(name string, b []byte) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return microerror.Maskf(invalidArchiveError, "%s", err)
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return microerror.Maskf(invalidArchiveError, "%s", err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = fn(h.Name, b)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+}
+
+// DecodeObject decodes the content of a YAML file written by WriteObject.
+func DecodeObject(name string, b []byte) (*unstructured.Unstructured, error) {
+	u := &unstructured.Unstructured{}
+	err := yaml.Unmarshal(b, &u.Object)
+	if err != nil {
+		return nil, microerror.Maskf(invalidArchiveError, "%#q is not a Kubernetes object: %s", name, err)
+	}
+
+	return u, nil
+}
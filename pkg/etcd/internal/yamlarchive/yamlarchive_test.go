@@ -0,0 +1,107 @@
+package yamlarchive
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/yamlarchive/yamlarchivetest"
+)
+
+func Test_WriteAndRead(t *testing.T) {
+	secret := yamlarchivetest.Object("v1", "Secret", "org-acme", "foo-etcd")
+	_ = unstructured.SetNestedField(secret.Object, "Y2E=", "data", "tls.crt")
+
+	testCases := []struct {
+		name         string
+		archive      func(t *testing.T) []byte
+		expected     []string
+		errorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: files and objects are read in the order of the archive",
+			archive: func(t *testing.T) []byte {
+				var buf bytes.Buffer
+				w := NewWriter(&buf)
+				err := w.WriteFile("manifest.yaml", []byte("formatVersion: 1\n"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = w.WriteObject(secret)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = w.WriteObject(yamlarchivetest.Object("cluster.x-k8s.io/v1beta2", "Cluster", "org-acme", "foo"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = w.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return buf.Bytes()
+			},
+			expected: []string{
+				"manifest.yaml",
+				"core/Secret/org-acme/foo-etcd.yaml",
+				"cluster.x-k8s.io/Cluster/org-acme/foo.yaml",
+			},
+		},
+		{
+			name: "case 1: not an archive",
+			archive: func(t *testing.T) []byte {
+				return []byte("foo")
+			},
+			errorMatcher: IsInvalidArchive,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var names []string
+			objs := map[string]*unstructured.Unstructured{}
+			err := Read(bytes.NewReader(tc.archive(t)), func(name string, b []byte) error {
+				names = append(names, name)
+				if name == "manifest.yaml" {
+					return nil
+				}
+
+				u, err := DecodeObject(name, b)
+				if err != nil {
+					return err
+				}
+				objs[name] = u
+
+				return nil
+			})
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher != nil {
+				return
+			}
+
+			if !cmp.Equal(names, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, names))
+			}
+			if !cmp.Equal(objs["core/Secret/org-acme/foo-etcd.yaml"], secret) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(secret, objs["core/Secret/org-acme/foo-etcd.yaml"]))
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+// Package yamlarchivetest provides the fixtures shared by the tests of the
+// packages writing YAML archives.
+package yamlarchivetest
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Object returns an object with the given type and name and nothing else.
+func Object(apiVersion string, kind string, namespace string, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+
+	return u
+}
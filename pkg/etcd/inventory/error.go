@@ -0,0 +1,32 @@
+package inventory
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var missingPassphraseError = &microerror.Error{
+	Kind: "missingPassphraseError",
+}
+
+// IsMissingPassphrase asserts missingPassphraseError.
+func IsMissingPassphrase(err error) bool {
+	return microerror.Cause(err) == missingPassphraseError
+}
+
+var invalidInventoryError = &microerror.Error{
+	Kind: "invalidInventoryError",
+}
+
+// IsInvalidInventory asserts invalidInventoryError.
+func IsInvalidInventory(err error) bool {
+	return microerror.Cause(err) == invalidInventoryError
+}
+
+var unsupportedFormatError = &microerror.Error{
+	Kind: "unsupportedFormatError",
+}
+
+// IsUnsupportedFormat asserts unsupportedFormatError.
+func IsUnsupportedFormat(err error) bool {
+	return microerror.Cause(err) == unsupportedFormatError
+}
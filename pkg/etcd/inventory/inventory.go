@@ -0,0 +1,173 @@
+// Package inventory writes and reads inventories, logical exports of the
+// CAPI and provider objects of the management cluster and of the secrets
+// they reference. An inventory is taken with every backup of the management
+// cluster and allows to move the workload clusters to a new management
+// cluster without restoring etcd.
+package inventory
+
+import (
+	"context"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/encrypt"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/yamlarchive"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/key"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/objects"
+)
+
+const (
+	// Version takes the place of the etcd version in the name of an
+	// inventory, e.g. gauss-ManagementCluster-inventory-2024-05-01T12-00-00.tar.gz.enc.
+	Version = "inventory"
+
+	// FormatVersion is the version of the layout of inventories written by
+	// this package. Inventories of newer versions are not read.
+	FormatVersion = 1
+
+	// ManifestFile is the file at the root of an inventory describing it.
+	ManifestFile = "inventory.yaml"
+)
+
+// Manifest describes an inventory.
+type Manifest struct {
+	FormatVersion     int       `json:"formatVersion"`
+	Installation      string    `json:"installation"`
+	CreationTimestamp time.Time `json:"creationTimestamp"`
+	// Backup is the name of the etcd backup taken at the same time.
+	Backup string `json:"backup,omitempty"`
+	// Objects are the paths of the objects in the inventory.
+	Objects []string `json:"objects"`
+}
+
+// Filename returns the name of the inventory belonging to the backup with
+// the given name, which has the same prefix and timestamp. It returns false
+// if backup is not named like a backup.
+func Filename(backup string) (string, bool) {
+	a, ok := key.ParseFilename(backup)
+	if !ok || !a.Snapshot {
+		return "", false
+	}
+
+	return a.Prefix + "-" + Version + "-" + a.Timestamp.Format(key.TsFormat) + key.TgzExt + key.EncExt, true
+}
+
+// Write writes the manifest and the objects to fpath as an encrypted tar.gz
+// archive. The objects of the manifest are set from objs. Inventories contain
+// secrets, so they are never written without passphrase.
+func Write(ctx context.Context, fpath string, m Manifest, objs []*unstructured.Unstructured, passphrase string) error {
+	if passphrase == "" {
+		return microerror.Maskf(missingPassphraseError, "inventories contain secrets and must be encrypted")
+	}
+
+	f, err := os.OpenFile(fpath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	encrypted, err := encrypt.NewWriter(f, passphrase)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	w := yamlarchive.NewWriter(encrypted)
+
+	m.FormatVersion = FormatVersion
+	m.Objects = nil
+	for _, u := range objs {
+		m.Objects = append(m.Objects, objects.Path(u))
+	}
+	sort.Strings(m.Objects)
+
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = w.WriteFile(ManifestFile, b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, u := range objs {
+		if err := ctx.Err(); err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = w.WriteObject(objects.Portable(u))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, c := range []io.Closer{w, encrypted, f} {
+		err = c.Close()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// Read reads the inventory from r, decrypting it with passphrase, and returns
+// its manifest and its objects in the order of the manifest.
+func Read(r io.Reader, passphrase string) (Manifest, []*unstructured.Unstructured, error) {
+	if passphrase == "" {
+		return Manifest{}, nil, microerror.Maskf(missingPassphraseError, "inventories are encrypted")
+	}
+
+	decrypted, err := encrypt.NewReader(r, passphrase)
+	if err != nil {
+		return Manifest{}, nil, microerror.Mask(err)
+	}
+
+	var m *Manifest
+	files := map[string][]byte{}
+	err = yamlarchive.Read(decrypted, func(name string, b []byte) error {
+		if name != ManifestFile {
+			files[name] = b
+			return nil
+		}
+
+		m = &Manifest{}
+		err := yaml.Unmarshal(b, m)
+		if err != nil {
+			return microerror.Maskf(invalidInventoryError, "%#q is invalid: %s", ManifestFile, err)
+		}
+		if m.FormatVersion > FormatVersion {
+			return microerror.Maskf(unsupportedFormatError, "inventory has format version %d, this release reads up to %d", m.FormatVersion, FormatVersion)
+		}
+
+		return nil
+	})
+	if yamlarchive.IsInvalidArchive(err) {
+		return Manifest{}, nil, microerror.Maskf(invalidInventoryError, "%s", err)
+	} else if err != nil {
+		return Manifest{}, nil, microerror.Mask(err)
+	}
+	if m == nil {
+		return Manifest{}, nil, microerror.Maskf(invalidInventoryError, "%#q is missing", ManifestFile)
+	}
+
+	var objs []*unstructured.Unstructured
+	for _, p := range m.Objects {
+		b, ok := files[p]
+		if !ok {
+			return Manifest{}, nil, microerror.Maskf(invalidInventoryError, "object %#q is missing", p)
+		}
+
+		u, err := yamlarchive.DecodeObject(p, b)
+		if err != nil {
+			return Manifest{}, nil, microerror.Mask(err)
+		}
+		objs = append(objs, u)
+	}
+
+	return *m, objs, nil
+}
@@ -0,0 +1,164 @@
+package inventory
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/encrypt"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/yamlarchive"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/internal/yamlarchive/yamlarchivetest"
+)
+
+// writeFutureInventory writes an inventory of a format version this release
+// does not know.
+func writeFutureInventory(t *testing.T, fpath string, passphrase string) {
+	f, err := os.Create(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	encrypted, err := encrypt.NewWriter(f, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := yamlarchive.NewWriter(encrypted)
+	err = w.WriteFile(ManifestFile, []byte("formatVersion: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []io.Closer{w, encrypted} {
+		err = c.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_WriteAndRead(t *testing.T) {
+	cluster := yamlarchivetest.Object("cluster.x-k8s.io/v1beta2", "Cluster", "org-acme", "foo")
+	cluster.SetUID("1234")
+	machineDeployment := yamlarchivetest.Object("cluster.x-k8s.io/v1beta2", "MachineDeployment", "org-acme", "foo-md-0")
+	secret := yamlarchivetest.Object("v1", "Secret", "org-acme", "foo-ca")
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		passphrase   string
+		readWith     string
+		future       bool
+		expected     Manifest
+		errorMatcher func(error) bool
+	}{
+		{
+			name:       "case 0: encrypted inventory",
+			passphrase: "secret",
+			readWith:   "secret",
+			expected: Manifest{
+				FormatVersion:     FormatVersion,
+				Installation:      "gauss",
+				CreationTimestamp: created,
+				Backup:            "gauss-ManagementCluster-v3-2024-05-01T12-00-00.db.tar.gz.enc",
+				Objects: []string{
+					"cluster.x-k8s.io/Cluster/org-acme/foo.yaml",
+					"cluster.x-k8s.io/MachineDeployment/org-acme/foo-md-0.yaml",
+					"core/Secret/org-acme/foo-ca.yaml",
+				},
+			},
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: inventories are not written without passphrase",
+			errorMatcher: IsMissingPassphrase,
+		},
+		{
+			name:         "case 2: wrong passphrase",
+			passphrase:   "secret",
+			readWith:     "guess",
+			errorMatcher: encrypt.IsWrongPassphrase,
+		},
+		{
+			name:         "case 3: newer format version",
+			passphrase:   "secret",
+			readWith:     "secret",
+			future:       true,
+			errorMatcher: IsUnsupportedFormat,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			fpath := filepath.Join(t.TempDir(), "inventory.tar.gz.enc")
+
+			var err error
+			if tc.future {
+				writeFutureInventory(t, fpath, tc.passphrase)
+			} else {
+				m := Manifest{
+					Installation:      "gauss",
+					CreationTimestamp: created,
+					Backup:            "gauss-ManagementCluster-v3-2024-05-01T12-00-00.db.tar.gz.enc",
+				}
+				err = Write(context.Background(), fpath, m, []*unstructured.Unstructured{secret, machineDeployment, cluster}, tc.passphrase)
+			}
+
+			var m Manifest
+			var objs []*unstructured.Unstructured
+			if err == nil {
+				var f *os.File
+				f, err = os.Open(fpath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close() //nolint:errcheck
+
+				m, objs, err = Read(f, tc.readWith)
+			}
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher != nil {
+				return
+			}
+
+			if !cmp.Equal(m, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, m))
+			}
+			if len(objs) != 3 || objs[0].GetName() != "foo" || objs[0].GetUID() != "" {
+				t.Fatalf("objects are not read in the order of the manifest without server fields: %v", objs)
+			}
+		})
+	}
+}
+
+func Test_Filename(t *testing.T) {
+	name, ok := Filename("gauss-ManagementCluster-v3-2024-05-01T12-00-00.db.tar.gz")
+	if !ok || name != "gauss-ManagementCluster-inventory-2024-05-01T12-00-00.tar.gz.enc" {
+		t.Fatalf("Filename == %q, %t", name, ok)
+	}
+
+	_, ok = Filename("gauss-ManagementCluster-inventory-2024-05-01T12-00-00.tar.gz.enc")
+	if ok {
+		t.Fatalf("inventories do not have an inventory")
+	}
+}
@@ -1,5 +1,10 @@
 package key
 
+import (
+	"strings"
+	"time"
+)
+
 const (
 	AwsCmd   = "Aws"
 	TgzExt   = ".tar.gz"
@@ -7,3 +12,55 @@ const (
 	DbExt    = ".db"
 	TsFormat = "2006-01-02T15-04-05"
 )
+
+// Artifact describes a file in the storage as named by the operator, i.e.
+// <prefix>-<version>-<timestamp>.db.tar.gz[.enc] for etcd snapshots written
+// by the Backupper, and <prefix>-<kind>-<timestamp>.tar.gz[.enc] for the
+// artifacts uploaded along with them, like DR bundles and inventories.
+type Artifact struct {
+	Prefix string
+	// Version is the etcd version of snapshots, e.g. v3, and the kind of
+	// other artifacts, e.g. dr.
+	Version   string
+	Timestamp time.Time
+	Encrypted bool
+	Snapshot  bool
+}
+
+// ParseFilename parses the name of an artifact. It returns false if the name
+// does not follow the naming of the operator.
+func ParseFilename(name string) (Artifact, bool) {
+	var a Artifact
+
+	if strings.HasSuffix(name, EncExt) {
+		a.Encrypted = true
+		name = strings.TrimSuffix(name, EncExt)
+	}
+	if !strings.HasSuffix(name, TgzExt) {
+		return Artifact{}, false
+	}
+	name = strings.TrimSuffix(name, TgzExt)
+	if strings.HasSuffix(name, DbExt) {
+		a.Snapshot = true
+		name = strings.TrimSuffix(name, DbExt)
+	}
+
+	if len(name) < len(TsFormat)+1 || name[len(name)-len(TsFormat)-1] != '-' {
+		return Artifact{}, false
+	}
+	ts, err := time.Parse(TsFormat, name[len(name)-len(TsFormat):])
+	if err != nil {
+		return Artifact{}, false
+	}
+	a.Timestamp = ts
+	name = name[:len(name)-len(TsFormat)-1]
+
+	i := strings.LastIndex(name, "-")
+	if i <= 0 || i == len(name)-1 {
+		return Artifact{}, false
+	}
+	a.Prefix = name[:i]
+	a.Version = name[i+1:]
+
+	return a, true
+}
@@ -0,0 +1,83 @@
+package key
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_ParseFilename(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filename string
+		expected Artifact
+		ok       bool
+	}{
+		{
+			name:     "case 0: encrypted v3 backup",
+			filename: "gauss-abc12-v3-2024-05-01T12-00-00.db.tar.gz.enc",
+			expected: Artifact{
+				Prefix:    "gauss-abc12",
+				Version:   "v3",
+				Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				Encrypted: true,
+				Snapshot:  true,
+			},
+			ok: true,
+		},
+		{
+			name:     "case 1: unencrypted backup of a cluster with dashes",
+			filename: "gauss-my-cluster-v3-2024-05-01T12-00-00.db.tar.gz",
+			expected: Artifact{
+				Prefix:    "gauss-my-cluster",
+				Version:   "v3",
+				Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				Snapshot:  true,
+			},
+			ok: true,
+		},
+		{
+			name:     "case 2: unknown extension",
+			filename: "gauss-abc12-v3-2024-05-01T12-00-00.db",
+			ok:       false,
+		},
+		{
+			name:     "case 3: invalid timestamp",
+			filename: "gauss-abc12-v3-yesterday.db.tar.gz",
+			ok:       false,
+		},
+		{
+			name:     "case 4: missing version",
+			filename: "2024-05-01T12-00-00.db.tar.gz",
+			ok:       false,
+		},
+		{
+			name:     "case 5: inventory of the management cluster",
+			filename: "gauss-ManagementCluster-inventory-2024-05-01T12-00-00.tar.gz.enc",
+			expected: Artifact{
+				Prefix:    "gauss-ManagementCluster",
+				Version:   "inventory",
+				Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				Encrypted: true,
+			},
+			ok: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			a, ok := ParseFilename(tc.filename)
+			if ok != tc.ok {
+				t.Fatalf("ok == %t, want %t", ok, tc.ok)
+			}
+
+			if !cmp.Equal(a, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, a))
+			}
+		})
+	}
+}
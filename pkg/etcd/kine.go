@@ -0,0 +1,158 @@
+package etcd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/key"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+const (
+	// kinePrefix is the prefix of all keys kine serves.
+	kinePrefix = "/"
+	// kinePageSize is the number of keys read at once.
+	kinePageSize = 500
+
+	// kineKeyBucket is the bucket etcd stores the revisions of all keys
+	// in, see the artifact package.
+	kineKeyBucket = "key"
+)
+
+// KineBackup backs up kine datastores, e.g. the SQLite or PostgreSQL
+// datastore of k3s, through the etcd API kine serves. kine can not take
+// snapshots, so all keys are read at a single revision and written to a
+// database laid out like an etcd snapshot. The backups can be inspected and
+// their objects exported like etcd snapshots, but they only hold the latest
+// revision of every key.
+type KineBackup struct {
+	V3Backup
+}
+
+func NewKineBackup(tlsConfig *tls.Config, p *proxy.Proxy, encPass string, endpoints string, logger micrologger.Logger, prefix string) (KineBackup, error) {
+	b, err := NewV3Backup(tlsConfig, p, encPass, endpoints, logger, prefix)
+	if err != nil {
+		return KineBackup{}, microerror.Mask(err)
+	}
+
+	return KineBackup{V3Backup: b}, nil
+}
+
+// Create etcd in temporary directory.
+func (b KineBackup) Create(ctx context.Context) (string, error) {
+	*b.filename = b.Prefix + "-" + b.Version() + "-" + time.Now().Format(key.TsFormat) + key.DbExt
+	fpath := filepath.Join(b.getTmpDir(), *b.filename)
+
+	revision, err := b.export(ctx, fpath)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	stat, err := os.Stat(fpath)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	fpath, err = b.archive(fpath, revision, stat.Size())
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return fpath, nil
+}
+
+func (b KineBackup) Version() string {
+	return DatastoreKine
+}
+
+// export writes all keys to a database at fpath and returns the revision
+// they were read at.
+func (b KineBackup) export(ctx context.Context, fpath string) (int64, error) {
+	db, err := bolt.Open(fpath, 0600, nil)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+	defer db.Close() //nolint:errcheck
+
+	var revision int64
+	var keys int
+	start := kinePrefix
+	for {
+		opts := []clientv3.OpOption{
+			clientv3.WithRange(clientv3.GetPrefixRangeEnd(kinePrefix)),
+			clientv3.WithLimit(kinePageSize),
+		}
+		if revision != 0 {
+			// All pages are read at the revision of the first one.
+			opts = append(opts, clientv3.WithRev(revision))
+		}
+
+		resp, err := b.etcdClient.Get(ctx, start, opts...)
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
+		if revision == 0 {
+			revision = resp.Header.Revision
+		}
+
+		err = db.Update(func(tx *bolt.Tx) error {
+			return writeKeyValues(tx, resp.Kvs)
+		})
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
+		keys += len(resp.Kvs)
+
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		start = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+
+	b.Logger.Debugf(ctx, "Exported %d keys of kine at revision %d", keys, revision)
+
+	return revision, nil
+}
+
+// writeKeyValues stores kvs in the key bucket by their revision, the way etcd
+// does.
+func writeKeyValues(tx *bolt.Tx, kvs []*mvccpb.KeyValue) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(kineKeyBucket))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, kv := range kvs {
+		v, err := proto.Marshal(kv)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// Keys written in the same transaction share their main revision,
+		// they are told apart by the sub revision.
+		rev := make([]byte, 17)
+		binary.BigEndian.PutUint64(rev, uint64(kv.ModRevision)) //nolint:gosec
+		rev[8] = '_'
+		for sub := uint64(0); bucket.Get(rev) != nil; sub++ {
+			binary.BigEndian.PutUint64(rev[9:], sub+1)
+		}
+
+		err = bucket.Put(rev, v)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
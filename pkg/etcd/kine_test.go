@@ -0,0 +1,77 @@
+package etcd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/artifact"
+)
+
+func Test_writeKeyValues(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "kine.db")
+	db, err := bolt.Open(fpath, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second page shares a revision with the first one, like keys
+	// written in the same transaction.
+	pages := [][]*mvccpb.KeyValue{
+		{
+			{Key: []byte("/registry/configmaps/default/a"), Value: []byte("a"), ModRevision: 7},
+			{Key: []byte("/registry/configmaps/default/b"), Value: []byte("b"), ModRevision: 3},
+		},
+		{
+			{Key: []byte("/registry/configmaps/default/c"), Value: []byte("c"), ModRevision: 7},
+		},
+	}
+	for _, kvs := range pages {
+		err = db.Update(func(tx *bolt.Tx) error {
+			return writeKeyValues(tx, kvs)
+		})
+		if err != nil {
+			t.Fatalf("error == %#v, want nil", err)
+		}
+	}
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	err = artifact.Revisions(fpath, func(kv *mvccpb.KeyValue, tombstone bool) error {
+		keys = append(keys, string(kv.Key))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	expected := []string{
+		"/registry/configmaps/default/b",
+		"/registry/configmaps/default/a",
+		"/registry/configmaps/default/c",
+	}
+	if !cmp.Equal(keys, expected) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, keys))
+	}
+
+	status, err := artifact.Inspect(fpath, 0)
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	if status.Revision != 7 || status.TotalKeys != 3 {
+		t.Fatalf("status == %#v, want revision 7 and 3 keys", status)
+	}
+}
+
+func Test_NewBackupper_UnknownDatastore(t *testing.T) {
+	_, err := NewBackupper("mysql", nil, nil, "", "https://127.0.0.1:2379", nil, "gauss-foo")
+	if !IsInvalidConfig(err) {
+		t.Fatalf("error == %#v, want matching", err)
+	}
+}
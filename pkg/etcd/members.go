@@ -0,0 +1,141 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+// memberProbeTimeout bounds the status request probing a member.
+const memberProbeTimeout = 10 * time.Second
+
+// member is the status of the etcd member reached at an endpoint.
+type member struct {
+	Endpoint  string
+	ID        uint64
+	Leader    bool
+	Revision  int64
+	RaftIndex uint64
+}
+
+// SplitEndpoints returns the comma separated endpoints.
+func SplitEndpoints(endpoints string) []string {
+	var split []string
+	for _, e := range strings.Split(endpoints, ",") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			split = append(split, e)
+		}
+	}
+
+	return split
+}
+
+// EndpointHost returns the host of the etcd endpoint. Endpoints without a
+// scheme, which the etcd client accepts as well, default to https.
+func EndpointHost(endpoint string) (string, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", microerror.Maskf(invalidConfigError, "invalid etcd endpoint %#q: %s", endpoint, err)
+	}
+
+	return u.Hostname(), nil
+}
+
+// probeMembers returns the status of the members reached at the endpoints
+// of b as reported by probe, once per member. Endpoints not reporting their
+// status are left out and only an error is returned when none does.
+func (b V3Backup) probeMembers(ctx context.Context, probe func(ctx context.Context, endpoint string) (member, error)) ([]member, error) {
+	seen := map[uint64]bool{}
+
+	var members []member
+	var errs []string
+	for _, endpoint := range SplitEndpoints(b.Endpoints) {
+		m, err := probe(ctx, endpoint)
+		if err != nil {
+			b.Logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("etcd endpoint %s is not healthy", endpoint), "reason", err)
+			errs = append(errs, fmt.Sprintf("%s: %s", endpoint, err))
+			continue
+		}
+		// Several endpoints may reach the same member.
+		if seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+
+		members = append(members, m)
+	}
+
+	if len(members) == 0 {
+		return nil, microerror.Maskf(executionFailedError, "none of the etcd endpoints is healthy: %s", strings.Join(errs, ", "))
+	}
+
+	return members, nil
+}
+
+// probeMember returns the status of the member reached at endpoint.
+func (b V3Backup) probeMember(ctx context.Context, endpoint string) (member, error) {
+	ctx, cancel := context.WithTimeout(ctx, memberProbeTimeout)
+	defer cancel()
+
+	s, err := b.etcdClient.Status(ctx, endpoint)
+	if err != nil {
+		return member{}, microerror.Mask(err)
+	}
+
+	m := member{
+		Endpoint:  endpoint,
+		ID:        s.Header.MemberId,
+		Leader:    s.Leader == s.Header.MemberId,
+		Revision:  s.Header.Revision,
+		RaftIndex: s.RaftIndex,
+	}
+
+	return m, nil
+}
+
+// defragMembers returns the members to defragment one after the other. The
+// leader is skipped when there are several members, so that defragmenting
+// does not block the member serving writes.
+func defragMembers(members []member) []member {
+	if len(members) == 1 {
+		return members
+	}
+
+	var defrag []member
+	for _, m := range members {
+		if !m.Leader {
+			defrag = append(defrag, m)
+		}
+	}
+
+	return defrag
+}
+
+// snapshotMember returns the member to take the snapshot from: the follower
+// which applied the most entries, or the leader when no follower is healthy.
+func snapshotMember(members []member) member {
+	var source *member
+	for i, m := range members {
+		if m.Leader {
+			continue
+		}
+		if source == nil || m.RaftIndex > source.RaftIndex || (m.RaftIndex == source.RaftIndex && m.Revision > source.Revision) {
+			source = &members[i]
+		}
+	}
+
+	if source == nil {
+		return members[0]
+	}
+
+	return *source
+}
@@ -0,0 +1,254 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+func Test_SplitEndpoints(t *testing.T) {
+	testCases := []struct {
+		name      string
+		endpoints string
+		expected  []string
+	}{
+		{
+			name:      "case 0: single endpoint",
+			endpoints: "https://127.0.0.1:2379",
+			expected:  []string{"https://127.0.0.1:2379"},
+		},
+		{
+			name:      "case 1: several endpoints with spaces",
+			endpoints: "https://10.0.0.1:2379, https://10.0.0.2:2379,",
+			expected:  []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"},
+		},
+		{
+			name:      "case 2: no endpoint",
+			endpoints: "",
+			expected:  nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			endpoints := SplitEndpoints(tc.endpoints)
+
+			if !reflect.DeepEqual(endpoints, tc.expected) {
+				t.Fatalf("endpoints == %v, want %v", endpoints, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_EndpointHost(t *testing.T) {
+	testCases := []struct {
+		name         string
+		endpoint     string
+		expected     string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:     "case 0: endpoint with scheme",
+			endpoint: "https://10.0.0.1:2379",
+			expected: "10.0.0.1",
+		},
+		{
+			name:     "case 1: IP endpoint without scheme",
+			endpoint: "127.0.0.1:2379",
+			expected: "127.0.0.1",
+		},
+		{
+			name:     "case 2: DNS endpoint without scheme",
+			endpoint: "etcd-0:2379",
+			expected: "etcd-0",
+		},
+		{
+			name:         "case 3: invalid endpoint",
+			endpoint:     "https://10.0.0.1:port",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			host, err := EndpointHost(tc.endpoint)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if host != tc.expected {
+				t.Fatalf("host == %q, want %q", host, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_members(t *testing.T) {
+	leader := member{Endpoint: "a", ID: 1, Leader: true, Revision: 12, RaftIndex: 120}
+	fresh := member{Endpoint: "b", ID: 2, Revision: 12, RaftIndex: 119}
+	stale := member{Endpoint: "c", ID: 3, Revision: 10, RaftIndex: 100}
+
+	testCases := []struct {
+		name             string
+		members          []member
+		expectedDefrag   []member
+		expectedSnapshot member
+	}{
+		{
+			name:             "case 0: single member",
+			members:          []member{leader},
+			expectedDefrag:   []member{leader},
+			expectedSnapshot: leader,
+		},
+		{
+			name:             "case 1: leader and followers",
+			members:          []member{stale, leader, fresh},
+			expectedDefrag:   []member{stale, fresh},
+			expectedSnapshot: fresh,
+		},
+		{
+			name:             "case 2: leader and a stale follower",
+			members:          []member{leader, stale},
+			expectedDefrag:   []member{stale},
+			expectedSnapshot: stale,
+		},
+		{
+			name:             "case 3: followers without leader",
+			members:          []member{stale, fresh},
+			expectedDefrag:   []member{stale, fresh},
+			expectedSnapshot: fresh,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			defrag := defragMembers(tc.members)
+			if !reflect.DeepEqual(defrag, tc.expectedDefrag) {
+				t.Fatalf("defrag == %v, want %v", defrag, tc.expectedDefrag)
+			}
+
+			snapshot := snapshotMember(tc.members)
+			if snapshot != tc.expectedSnapshot {
+				t.Fatalf("snapshot == %v, want %v", snapshot, tc.expectedSnapshot)
+			}
+		})
+	}
+}
+
+func Test_probeMembers(t *testing.T) {
+	leader := member{Endpoint: "https://10.0.0.1:2379", ID: 1, Leader: true, Revision: 12, RaftIndex: 120}
+	fresh := member{Endpoint: "https://10.0.0.2:2379", ID: 2, Revision: 12, RaftIndex: 119}
+	stale := member{Endpoint: "https://10.0.0.3:2379", ID: 3, Revision: 10, RaftIndex: 100}
+
+	testCases := []struct {
+		name           string
+		endpoints      string
+		statuses       map[string]member
+		expected       []member
+		expectedDefrag []member
+		errorMatcher   func(error) bool
+	}{
+		{
+			name:           "case 0: all members are healthy, the leader is not defragmented",
+			endpoints:      "https://10.0.0.1:2379,https://10.0.0.2:2379,https://10.0.0.3:2379",
+			statuses:       map[string]member{leader.Endpoint: leader, fresh.Endpoint: fresh, stale.Endpoint: stale},
+			expected:       []member{leader, fresh, stale},
+			expectedDefrag: []member{fresh, stale},
+			errorMatcher:   nil,
+		},
+		{
+			name:           "case 1: the first endpoint is down, the other ones are probed",
+			endpoints:      "https://10.0.0.2:2379,https://10.0.0.1:2379,https://10.0.0.3:2379",
+			statuses:       map[string]member{leader.Endpoint: leader, stale.Endpoint: stale},
+			expected:       []member{leader, stale},
+			expectedDefrag: []member{stale},
+			errorMatcher:   nil,
+		},
+		{
+			name:      "case 2: endpoints reaching the same member are probed once",
+			endpoints: "https://10.0.0.1:2379,https://etcd.example.com:2379,https://10.0.0.2:2379",
+			statuses: map[string]member{
+				leader.Endpoint:                 leader,
+				"https://etcd.example.com:2379": {Endpoint: "https://etcd.example.com:2379", ID: 1, Leader: true, Revision: 12, RaftIndex: 120},
+				fresh.Endpoint:                  fresh,
+			},
+			expected:       []member{leader, fresh},
+			expectedDefrag: []member{fresh},
+			errorMatcher:   nil,
+		},
+		{
+			name:           "case 3: only the leader is healthy and defragmented",
+			endpoints:      "https://10.0.0.1:2379,https://10.0.0.2:2379",
+			statuses:       map[string]member{leader.Endpoint: leader},
+			expected:       []member{leader},
+			expectedDefrag: []member{leader},
+			errorMatcher:   nil,
+		},
+		{
+			name:         "case 4: no endpoint is healthy",
+			endpoints:    "https://10.0.0.1:2379,https://10.0.0.2:2379",
+			statuses:     map[string]member{},
+			errorMatcher: func(err error) bool { return err != nil },
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			b := V3Backup{Endpoints: tc.endpoints, Logger: microloggertest.New()}
+			probe := func(ctx context.Context, endpoint string) (member, error) {
+				m, ok := tc.statuses[endpoint]
+				if !ok {
+					return member{}, errors.New("context deadline exceeded")
+				}
+
+				return m, nil
+			}
+
+			members, err := b.probeMembers(context.Background(), probe)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !reflect.DeepEqual(members, tc.expected) {
+				t.Fatalf("members == %v, want %v", members, tc.expected)
+			}
+
+			if tc.errorMatcher != nil {
+				return
+			}
+
+			defrag := defragMembers(members)
+			if !reflect.DeepEqual(defrag, tc.expectedDefrag) {
+				t.Fatalf("defrag == %v, want %v", defrag, tc.expectedDefrag)
+			}
+		})
+	}
+}
@@ -7,6 +7,11 @@ type BackupAttemptResult struct {
 	EncryptionTimeMeasurement int64
 	UploadTimeMeasurement     int64
 	Filename                  string
+	// FailedStage is the stage a failed attempt failed in.
+	FailedStage string
+	// Attempts is the number of attempts taken by the backup, set on the
+	// result of its latest attempt.
+	Attempts int
 }
 
 func NewSuccessfulBackupAttemptResult(backupSize int64, creationTime int64, encryptionTime int64, uploadTime int64, filename string) *BackupAttemptResult {
@@ -20,7 +25,7 @@ func NewSuccessfulBackupAttemptResult(backupSize int64, creationTime int64, encr
 	}
 }
 
-func NewFailedBackupAttemptResult() *BackupAttemptResult {
+func NewFailedBackupAttemptResult(stage string) *BackupAttemptResult {
 	return &BackupAttemptResult{
 		Successful:                false,
 		BackupSizeMeasurement:     -1,
@@ -28,5 +33,6 @@ func NewFailedBackupAttemptResult() *BackupAttemptResult {
 		EncryptionTimeMeasurement: -1,
 		UploadTimeMeasurement:     -1,
 		Filename:                  "",
+		FailedStage:               stage,
 	}
 }
@@ -0,0 +1,48 @@
+package objects
+
+import (
+	"github.com/giantswarm/microerror"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var (
+	scheme = runtime.NewScheme()
+	codecs = serializer.NewCodecFactory(scheme)
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+}
+
+// decode decodes an object the way the API server stored it. Built-in
+// resources are protobuf encoded and decoded with the types of the scheme.
+// Custom resources are JSON encoded and decoded without knowing their type.
+func decode(value []byte) (*unstructured.Unstructured, error) {
+	obj, gvk, err := codecs.UniversalDeserializer().Decode(value, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		obj, gvk, err = unstructured.UnstructuredJSONScheme.Decode(value, nil, nil)
+	}
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(*gvk)
+
+	return u, nil
+}
@@ -0,0 +1,97 @@
+package objects
+
+import (
+	"bytes"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ChangeType describes how an object changed between two snapshots.
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is an object which differs between two snapshots. Old is nil for
+// added objects, New is nil for removed objects.
+type Change struct {
+	Type     ChangeType
+	Key      string
+	Resource string
+	Old      *Object
+	New      *Object
+}
+
+// Diff compares the objects of two snapshots as returned by Read and returns
+// the changes sorted by key.
+func Diff(old []Object, new []Object) []Change {
+	var changes []Change
+
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case j == len(new) || (i < len(old) && old[i].Key < new[j].Key):
+			changes = append(changes, Change{Type: ChangeRemoved, Key: old[i].Key, Resource: old[i].Resource, Old: &old[i]})
+			i++
+		case i == len(old) || new[j].Key < old[i].Key:
+			changes = append(changes, Change{Type: ChangeAdded, Key: new[j].Key, Resource: new[j].Resource, New: &new[j]})
+			j++
+		default:
+			if !bytes.Equal(old[i].Value, new[j].Value) {
+				changes = append(changes, Change{Type: ChangeModified, Key: old[i].Key, Resource: old[i].Resource, Old: &old[i], New: &new[j]})
+			}
+			i++
+			j++
+		}
+	}
+
+	return changes
+}
+
+// Decode decodes the old and the new object of the change. The object
+// missing on one side is nil.
+func (c Change) Decode() (*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	var err error
+	var old, new *unstructured.Unstructured
+
+	if c.Old != nil {
+		old, err = c.Old.Decode()
+		if err != nil {
+			return nil, nil, microerror.Mask(err)
+		}
+	}
+	if c.New != nil {
+		new, err = c.New.Decode()
+		if err != nil {
+			return nil, nil, microerror.Mask(err)
+		}
+	}
+
+	return old, new, nil
+}
+
+// ObjectDiff returns a human readable diff of two revisions of an object,
+// ignoring the managed fields which change with every update.
+func ObjectDiff(old *unstructured.Unstructured, new *unstructured.Unstructured) string {
+	var o, n map[string]interface{}
+	if old != nil {
+		o = withoutManagedFields(old).Object
+	}
+	if new != nil {
+		n = withoutManagedFields(new).Object
+	}
+
+	return cmp.Diff(o, n)
+}
+
+func withoutManagedFields(u *unstructured.Unstructured) *unstructured.Unstructured {
+	c := u.DeepCopy()
+	c.SetManagedFields(nil)
+
+	return c
+}
@@ -0,0 +1,14 @@
+package objects
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var undecodableObjectError = &microerror.Error{
+	Kind: "undecodableObjectError",
+}
+
+// IsUndecodableObject asserts undecodableObjectError.
+func IsUndecodableObject(err error) bool {
+	return microerror.Cause(err) == undecodableObjectError
+}
@@ -0,0 +1,102 @@
+package objects
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// serverFields are set by the API server and make kubectl apply fail or
+// conflict when restoring an object.
+var serverFields = [][]string{
+	{"metadata", "creationTimestamp"},
+	{"metadata", "deletionGracePeriodSeconds"},
+	{"metadata", "deletionTimestamp"},
+	{"metadata", "generation"},
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "selfLink"},
+	{"metadata", "uid"},
+	{"status"},
+}
+
+// Applicable returns a copy of the object without the fields set by the API
+// server, ready for kubectl apply.
+func Applicable(u *unstructured.Unstructured) *unstructured.Unstructured {
+	c := u.DeepCopy()
+	for _, f := range serverFields {
+		unstructured.RemoveNestedField(c.Object, f...)
+	}
+
+	return c
+}
+
+// Portable returns a copy of the object ready to be applied to another
+// cluster. Owner references are removed as well, since the UIDs of the
+// owners change.
+func Portable(u *unstructured.Unstructured) *unstructured.Unstructured {
+	c := Applicable(u)
+	unstructured.RemoveNestedField(c.Object, "metadata", "ownerReferences")
+
+	return c
+}
+
+// WriteYAML writes the objects as a multi-document YAML stream.
+func WriteYAML(w io.Writer, objects []*unstructured.Unstructured) error {
+	for _, u := range objects {
+		b, err := yaml.Marshal(u.Object)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		_, err = fmt.Fprintf(w, "---\n%s", b)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// WriteFiles writes every object to its own YAML file below dir, laid out as
+// <group>/<kind>/<namespace>/<name>.yaml. Cluster-scoped objects have no
+// namespace directory and the core group is written as "core".
+func WriteFiles(dir string, objects []*unstructured.Unstructured) error {
+	for _, u := range objects {
+		fpath := filepath.Join(dir, Path(u))
+
+		err := os.MkdirAll(filepath.Dir(fpath), 0750)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		b, err := yaml.Marshal(u.Object)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = os.WriteFile(fpath, b, 0600)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// Path returns the relative path WriteFiles writes the object to.
+func Path(u *unstructured.Unstructured) string {
+	gvk := u.GroupVersionKind()
+
+	group := gvk.Group
+	if group == "" {
+		group = CoreGroup
+	}
+
+	return filepath.Join(group, gvk.Kind, u.GetNamespace(), u.GetName()+".yaml")
+}
@@ -0,0 +1,125 @@
+// Package objects reads the Kubernetes objects stored in an etcd snapshot,
+// so single resources can be restored with kubectl apply instead of
+// restoring the whole etcd cluster, and compares the objects of two
+// snapshots.
+package objects
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/artifact"
+)
+
+// RegistryPrefix is the prefix the Kubernetes API server stores its objects
+// under.
+const RegistryPrefix = "/registry/"
+
+// Object is a Kubernetes object as stored in an etcd snapshot.
+type Object struct {
+	// Key is the etcd key of the object, e.g.
+	// /registry/configmaps/kube-system/coredns.
+	Key string
+	// Resource is the resource the key is stored under, e.g. configmaps, or
+	// the group and the resource for custom resources, e.g.
+	// cert-manager.io/certificates.
+	Resource string
+	// ModRevision is the etcd revision the object was last modified in.
+	ModRevision int64
+	// Value is the object as stored by the API server, either protobuf or
+	// JSON encoded.
+	Value []byte
+}
+
+// Read returns the latest revision of all objects stored in the etcd
+// snapshot at fpath, sorted by key. Deleted objects are left out.
+func Read(fpath string) ([]Object, error) {
+	latest := map[string]Object{}
+
+	err := artifact.Revisions(fpath, func(kv *mvccpb.KeyValue, tombstone bool) error {
+		k := string(kv.Key)
+		if !strings.HasPrefix(k, RegistryPrefix) {
+			return nil
+		}
+
+		if tombstone {
+			delete(latest, k)
+			return nil
+		}
+
+		latest[k] = Object{
+			Key:         k,
+			Resource:    ResourceOf(k),
+			ModRevision: kv.ModRevision,
+			Value:       kv.Value,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	objects := make([]Object, 0, len(latest))
+	for _, o := range latest {
+		objects = append(objects, o)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+
+	return objects, nil
+}
+
+// storagePrefixes are the resources the API server stores under another
+// prefix than their name, by prefix.
+var storagePrefixes = map[string]string{
+	"controllers":        "replicationcontrollers",
+	"ingress":            "ingresses",
+	"minions":            "nodes",
+	"services/endpoints": "endpoints",
+	"services/specs":     "services",
+}
+
+// ResourceOf returns the resource an object key is stored under. Built-in
+// resources are stored as /registry/<resource>/..., custom resources as
+// /registry/<group>/<resource>/... and groups always contain a dot. Some
+// built-in resources are stored under another prefix, e.g. services under
+// /registry/services/specs/....
+func ResourceOf(key string) string {
+	prefix := storagePrefixOf(key)
+	if resource, ok := storagePrefixes[prefix]; ok {
+		return resource
+	}
+
+	return prefix
+}
+
+// storagePrefixOf returns the prefix of the resource of key, i.e. key without
+// RegistryPrefix, the namespace and the name.
+func storagePrefixOf(key string) string {
+	segments := strings.Split(strings.TrimPrefix(key, RegistryPrefix), "/")
+	if len(segments) > 2 {
+		prefix := segments[0] + "/" + segments[1]
+		_, special := storagePrefixes[prefix]
+		if special || strings.Contains(segments[0], ".") {
+			return prefix
+		}
+	}
+
+	return segments[0]
+}
+
+// Decode decodes the object.
+func (o Object) Decode() (*unstructured.Unstructured, error) {
+	u, err := decode(o.Value)
+	if err != nil {
+		return nil, microerror.Maskf(undecodableObjectError, "%#q: %s", o.Key, err)
+	}
+
+	return u, nil
+}
@@ -0,0 +1,376 @@
+package objects
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+)
+
+type revision struct {
+	key       string
+	value     []byte
+	tombstone bool
+}
+
+// writeSnapshot writes the revisions to a bbolt database laid out like an
+// etcd snapshot and returns its path.
+func writeSnapshot(t *testing.T, revisions []revision) string {
+	fpath := filepath.Join(t.TempDir(), "snapshot.db")
+	db, err := bolt.Open(fpath, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("key"))
+		if err != nil {
+			return err
+		}
+
+		for i, r := range revisions {
+			v, err := proto.Marshal(&mvccpb.KeyValue{Key: []byte(r.key), Value: r.value, ModRevision: int64(i + 2)})
+			if err != nil {
+				return err
+			}
+
+			rev := make([]byte, 17)
+			binary.BigEndian.PutUint64(rev, uint64(i+2)) // nolint:gosec
+			rev[8] = '_'
+			if r.tombstone {
+				rev = append(rev, 't')
+			}
+
+			err = b.Put(rev, v)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fpath
+}
+
+func configMap(t *testing.T, namespace string, name string, data map[string]string) []byte {
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			UID:             "7d1e9a5c-1f5e-4b7e-9a43-1e2d3c4b5a69",
+			ResourceVersion: "42",
+		},
+		Data: data,
+	}
+
+	var b bytes.Buffer
+	err := protobuf.NewSerializer(scheme, scheme).Encode(cm, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+func Test_ReadAndExport(t *testing.T) {
+	fpath := writeSnapshot(t, []revision{
+		{key: "/registry/configmaps/default/deleted", value: configMap(t, "default", "deleted", nil)},
+		{key: "/registry/configmaps/default/foo", value: configMap(t, "default", "foo", map[string]string{"a": "b"})},
+		{key: "/registry/configmaps/kube-system/bar", value: configMap(t, "kube-system", "bar", nil)},
+		{key: "/registry/example.com/widgets/default/w", value: []byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"w","namespace":"default","uid":"x"},"spec":{"size":3},"status":{"ready":true}}`)},
+		{key: "/registry/configmaps/default/deleted", tombstone: true},
+		{key: "compact_rev_key", value: []byte("x")},
+	})
+
+	objects, err := Read(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	expectedKeys := []string{
+		"/registry/configmaps/default/foo",
+		"/registry/configmaps/kube-system/bar",
+		"/registry/example.com/widgets/default/w",
+	}
+	if !cmp.Equal(keys, expectedKeys) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expectedKeys, keys))
+	}
+	if objects[2].Resource != "example.com/widgets" {
+		t.Fatalf("resource == %#q, want %#q", objects[2].Resource, "example.com/widgets")
+	}
+
+	testCases := []struct {
+		name         string
+		selector     Selector
+		expectedYAML string
+	}{
+		{
+			name:     "case 0: select a config map by namespace and name",
+			selector: Selector{Kind: "configmap", Namespace: "default", Name: "foo"},
+			expectedYAML: `---
+apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  name: foo
+  namespace: default
+`,
+		},
+		{
+			name:     "case 1: select the core group",
+			selector: Selector{Group: CoreGroup, Namespace: "kube-system"},
+			expectedYAML: `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: bar
+  namespace: kube-system
+`,
+		},
+		{
+			name:     "case 2: select a custom resource by group",
+			selector: Selector{Group: "example.com"},
+			expectedYAML: `---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+  namespace: default
+spec:
+  size: 3
+`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var b bytes.Buffer
+			for _, o := range objects {
+				u, err := o.Decode()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !tc.selector.Matches(u) {
+					continue
+				}
+
+				err = WriteYAML(&b, []*unstructured.Unstructured{Applicable(u)})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if b.String() != tc.expectedYAML {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedYAML, b.String()))
+			}
+		})
+	}
+}
+
+func Test_Diff(t *testing.T) {
+	old, err := Read(writeSnapshot(t, []revision{
+		{key: "/registry/configmaps/default/changed", value: configMap(t, "default", "changed", map[string]string{"a": "1"})},
+		{key: "/registry/configmaps/default/removed", value: configMap(t, "default", "removed", nil)},
+		{key: "/registry/configmaps/default/same", value: configMap(t, "default", "same", nil)},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := Read(writeSnapshot(t, []revision{
+		{key: "/registry/configmaps/default/added", value: configMap(t, "default", "added", nil)},
+		{key: "/registry/configmaps/default/changed", value: configMap(t, "default", "changed", map[string]string{"a": "2"})},
+		{key: "/registry/configmaps/default/same", value: configMap(t, "default", "same", nil)},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := Diff(old, new)
+
+	var summary []string
+	for _, c := range changes {
+		summary = append(summary, string(c.Type)+" "+c.Key)
+	}
+	expected := []string{
+		"added /registry/configmaps/default/added",
+		"modified /registry/configmaps/default/changed",
+		"removed /registry/configmaps/default/removed",
+	}
+	if !cmp.Equal(summary, expected) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, summary))
+	}
+
+	o, n, err := changes[1].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := ObjectDiff(o, n); !bytes.Contains([]byte(d), []byte(`"2"`)) {
+		t.Fatalf("diff == %q, want the changed value", d)
+	}
+}
+
+func Test_NewReport(t *testing.T) {
+	old := []Object{
+		{Key: "/registry/configmaps/default/a", Resource: "configmaps", Value: []byte("aaaa")},
+		{Key: "/registry/configmaps/default/b", Resource: "configmaps", Value: []byte("bb")},
+		{Key: "/registry/namespaces/default", Resource: "namespaces", Value: []byte("ns")},
+		{Key: "/registry/secrets/default/s", Resource: "secrets", Value: []byte("sss")},
+	}
+	new := []Object{
+		{Key: "/registry/configmaps/default/a", Resource: "configmaps", Value: []byte("aaaaaaaa")},
+		{Key: "/registry/configmaps/default/c", Resource: "configmaps", Value: []byte("c")},
+		{Key: "/registry/example.com/widgets/w", Resource: "example.com/widgets", Value: []byte("w")},
+		{Key: "/registry/namespaces/default", Resource: "namespaces", Value: []byte("ns")},
+	}
+
+	expected := Report{
+		Added:     2,
+		Removed:   2,
+		Modified:  1,
+		OldBytes:  11,
+		NewBytes:  12,
+		SizeDelta: 1,
+		Resources: []ResourceReport{
+			{
+				Resource:  "configmaps",
+				Added:     1,
+				Removed:   1,
+				Modified:  1,
+				OldBytes:  6,
+				NewBytes:  9,
+				SizeDelta: 3,
+				Changes: []ReportedChange{
+					{Type: ChangeModified, Key: "/registry/configmaps/default/a", Name: "default/a", OldSize: 4, NewSize: 8, SizeDelta: 4},
+					{Type: ChangeRemoved, Key: "/registry/configmaps/default/b", Name: "default/b", OldSize: 2, SizeDelta: -2},
+					{Type: ChangeAdded, Key: "/registry/configmaps/default/c", Name: "default/c", NewSize: 1, SizeDelta: 1},
+				},
+			},
+			{
+				Resource:  "example.com/widgets",
+				Added:     1,
+				NewBytes:  1,
+				SizeDelta: 1,
+				Changes: []ReportedChange{
+					{Type: ChangeAdded, Key: "/registry/example.com/widgets/w", Name: "w", NewSize: 1, SizeDelta: 1},
+				},
+			},
+			{
+				Resource: "namespaces",
+				OldBytes: 2,
+				NewBytes: 2,
+			},
+			{
+				Resource:  "secrets",
+				Removed:   1,
+				OldBytes:  3,
+				SizeDelta: -3,
+				Changes: []ReportedChange{
+					{Type: ChangeRemoved, Key: "/registry/secrets/default/s", Name: "default/s", OldSize: 3, SizeDelta: -3},
+				},
+			},
+		},
+	}
+
+	report := NewReport(old, new)
+	if !cmp.Equal(report, expected) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, report))
+	}
+	if len(report.Changed()) != 3 {
+		t.Fatalf("changed resources == %d, want 3", len(report.Changed()))
+	}
+}
+
+func Test_ResourceOfAndNameOf(t *testing.T) {
+	testCases := []struct {
+		name             string
+		key              string
+		expectedResource string
+		expectedName     string
+	}{
+		{
+			name:             "case 0: namespaced built-in resource",
+			key:              "/registry/configmaps/kube-system/coredns",
+			expectedResource: "configmaps",
+			expectedName:     "kube-system/coredns",
+		},
+		{
+			name:             "case 1: cluster-scoped built-in resource",
+			key:              "/registry/namespaces/default",
+			expectedResource: "namespaces",
+			expectedName:     "default",
+		},
+		{
+			name:             "case 2: custom resource",
+			key:              "/registry/cluster.x-k8s.io/clusters/org-acme/foo",
+			expectedResource: "cluster.x-k8s.io/clusters",
+			expectedName:     "org-acme/foo",
+		},
+		{
+			name:             "case 3: services",
+			key:              "/registry/services/specs/default/kubernetes",
+			expectedResource: "services",
+			expectedName:     "default/kubernetes",
+		},
+		{
+			name:             "case 4: endpoints",
+			key:              "/registry/services/endpoints/default/kubernetes",
+			expectedResource: "endpoints",
+			expectedName:     "default/kubernetes",
+		},
+		{
+			name:             "case 5: nodes",
+			key:              "/registry/minions/worker-1",
+			expectedResource: "nodes",
+			expectedName:     "worker-1",
+		},
+		{
+			name:             "case 6: replication controllers",
+			key:              "/registry/controllers/default/web",
+			expectedResource: "replicationcontrollers",
+			expectedName:     "default/web",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			resource := ResourceOf(tc.key)
+			if resource != tc.expectedResource {
+				t.Fatalf("resource == %q, want %q", resource, tc.expectedResource)
+			}
+
+			name := NameOf(tc.key)
+			if name != tc.expectedName {
+				t.Fatalf("name == %q, want %q", name, tc.expectedName)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package objects
+
+import (
+	"sort"
+	"strings"
+)
+
+// Report summarizes the changes between two snapshots by resource.
+type Report struct {
+	Added     int   `json:"added"`
+	Removed   int   `json:"removed"`
+	Modified  int   `json:"modified"`
+	OldBytes  int64 `json:"oldBytes"`
+	NewBytes  int64 `json:"newBytes"`
+	SizeDelta int64 `json:"sizeDelta"`
+
+	Resources []ResourceReport `json:"resources"`
+}
+
+// ResourceReport summarizes the changes of the objects of one resource. The
+// sizes cover all objects of the resource, changed or not.
+type ResourceReport struct {
+	Resource  string `json:"resource"`
+	Added     int    `json:"added"`
+	Removed   int    `json:"removed"`
+	Modified  int    `json:"modified"`
+	OldBytes  int64  `json:"oldBytes"`
+	NewBytes  int64  `json:"newBytes"`
+	SizeDelta int64  `json:"sizeDelta"`
+
+	Changes []ReportedChange `json:"changes"`
+}
+
+// ReportedChange is a changed object of a ResourceReport.
+type ReportedChange struct {
+	Type ChangeType `json:"type"`
+	Key  string     `json:"key"`
+	// Name is the namespace and the name of namespaced objects, e.g.
+	// kube-system/coredns, and the name of cluster-scoped objects.
+	Name      string `json:"name"`
+	OldSize   int64  `json:"oldSize"`
+	NewSize   int64  `json:"newSize"`
+	SizeDelta int64  `json:"sizeDelta"`
+}
+
+// NewReport compares the objects of two snapshots as returned by Read. Only
+// keys and values are compared, so objects do not need to be decoded.
+// Resources are sorted by name, their changes by key.
+func NewReport(old []Object, new []Object) Report {
+	resources := map[string]*ResourceReport{}
+	resource := func(name string) *ResourceReport {
+		r, ok := resources[name]
+		if !ok {
+			r = &ResourceReport{Resource: name}
+			resources[name] = r
+		}
+		return r
+	}
+
+	for _, o := range old {
+		resource(o.Resource).OldBytes += int64(len(o.Value))
+	}
+	for _, o := range new {
+		resource(o.Resource).NewBytes += int64(len(o.Value))
+	}
+
+	for _, c := range Diff(old, new) {
+		r := resource(c.Resource)
+
+		rc := ReportedChange{
+			Type: c.Type,
+			Key:  c.Key,
+			Name: NameOf(c.Key),
+		}
+		if c.Old != nil {
+			rc.OldSize = int64(len(c.Old.Value))
+		}
+		if c.New != nil {
+			rc.NewSize = int64(len(c.New.Value))
+		}
+		rc.SizeDelta = rc.NewSize - rc.OldSize
+
+		switch c.Type {
+		case ChangeAdded:
+			r.Added++
+		case ChangeRemoved:
+			r.Removed++
+		case ChangeModified:
+			r.Modified++
+		}
+		r.Changes = append(r.Changes, rc)
+	}
+
+	var report Report
+	for _, r := range resources {
+		r.SizeDelta = r.NewBytes - r.OldBytes
+
+		report.Added += r.Added
+		report.Removed += r.Removed
+		report.Modified += r.Modified
+		report.OldBytes += r.OldBytes
+		report.NewBytes += r.NewBytes
+
+		report.Resources = append(report.Resources, *r)
+	}
+	report.SizeDelta = report.NewBytes - report.OldBytes
+
+	sort.Slice(report.Resources, func(i, j int) bool {
+		return report.Resources[i].Resource < report.Resources[j].Resource
+	})
+
+	return report
+}
+
+// Changed returns the resources with at least one changed object.
+func (r Report) Changed() []ResourceReport {
+	var changed []ResourceReport
+	for _, rr := range r.Resources {
+		if len(rr.Changes) > 0 {
+			changed = append(changed, rr)
+		}
+	}
+
+	return changed
+}
+
+// NameOf returns the namespace and the name of the object stored at key,
+// e.g. kube-system/coredns, or the name of cluster-scoped objects.
+func NameOf(key string) string {
+	return strings.TrimPrefix(key, RegistryPrefix+storagePrefixOf(key)+"/")
+}
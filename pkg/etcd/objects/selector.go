@@ -0,0 +1,45 @@
+package objects
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CoreGroup selects the resources of the core API group, which is empty.
+const CoreGroup = "core"
+
+// Selector selects objects by group, kind, namespace and name. Empty fields
+// match everything, kinds match case-insensitively.
+type Selector struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// Matches tells whether the object is selected.
+func (s Selector) Matches(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+
+	if s.Group != "" {
+		group := s.Group
+		if group == CoreGroup {
+			group = ""
+		}
+		if gvk.Group != group {
+			return false
+		}
+	}
+	if s.Kind != "" && !strings.EqualFold(gvk.Kind, s.Kind) {
+		return false
+	}
+	if s.Namespace != "" && u.GetNamespace() != s.Namespace {
+		return false
+	}
+	if s.Name != "" && u.GetName() != s.Name {
+		return false
+	}
+
+	return true
+}
@@ -19,7 +19,10 @@ limitations under the License.
 package proxy
 
 import (
+	"fmt"
+	"io"
 	"net"
+	"sync"
 	"time"
 
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -32,21 +35,64 @@ type Conn struct {
 	stream        httpstream.Stream
 	readDeadline  time.Time
 	writeDeadline time.Time
+
+	// errorStream is set for connections sharing their port-forward
+	// connection, which is left open when they are closed.
+	errorStream httpstream.Stream
+	// release is called once when a connection sharing its port-forward
+	// connection is closed.
+	release func()
+
+	mutex  sync.Mutex
+	err    error
+	closed bool
 }
 
-// Read from the connection.
+// Read from the connection. Port-forward failures are returned instead of
+// the error of the stream they reset.
 func (c *Conn) Read(b []byte) (n int, err error) {
-	return c.stream.Read(b)
+	n, err = c.stream.Read(b)
+	if err != nil {
+		if forwardErr := c.forwardError(); forwardErr != nil {
+			return n, forwardErr
+		}
+	}
+	return n, err
 }
 
-// Close the underlying proxied connection.
+// Close the underlying proxied connection, or only the streams of the
+// connection when the port-forward connection is shared.
 func (c *Conn) Close() error {
-	return kerrors.NewAggregate([]error{c.stream.Close(), c.connection.Close()})
+	if c.errorStream == nil {
+		return kerrors.NewAggregate([]error{c.stream.Close(), c.connection.Close()})
+	}
+
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mutex.Unlock()
+
+	err := kerrors.NewAggregate([]error{c.stream.Reset(), c.errorStream.Reset()})
+	c.connection.RemoveStreams(c.stream, c.errorStream)
+	if c.release != nil {
+		c.release()
+	}
+	return err
 }
 
-// Write to the connection.
+// Write to the connection. Port-forward failures are returned instead of
+// the error of the stream they reset.
 func (c *Conn) Write(b []byte) (n int, err error) {
-	return c.stream.Write(b)
+	n, err = c.stream.Write(b)
+	if err != nil {
+		if forwardErr := c.forwardError(); forwardErr != nil {
+			return n, forwardErr
+		}
+	}
+	return n, err
 }
 
 // LocalAddr returns a fake address representing the proxied connection.
@@ -79,6 +125,38 @@ func (c *Conn) SetReadDeadline(t time.Time) error {
 	return nil
 }
 
+// forwardError returns the port-forward failure reported on the error stream.
+func (c *Conn) forwardError() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+// monitor reads the error stream, on which the API server reports failures
+// to forward the port, e.g. when the pod is gone, and resets the data stream
+// when one is reported.
+func (c *Conn) monitor(addr string, host string) {
+	message, err := io.ReadAll(c.errorStream)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch {
+	case c.closed:
+		return
+	case err != nil:
+		c.err = fmt.Errorf("error reading port-forward error stream of %s: %w", addr, err)
+	case len(message) > 0:
+		c.err = fmt.Errorf("port-forward to %s failed: %s", addr, message)
+	default:
+		return
+	}
+
+	streamErrorsTotal.WithLabelValues(host).Inc()
+	_ = c.stream.Reset()
+}
+
 // NewConn creates a new net/conn interface based on an underlying Kubernetes
 // API server proxy connection.
 func NewConn(connection httpstream.Connection, stream httpstream.Stream) *Conn {
@@ -87,3 +165,18 @@ func NewConn(connection httpstream.Connection, stream httpstream.Stream) *Conn {
 		stream:     stream,
 	}
 }
+
+// newPooledConn creates a net/conn interface over the streams of a shared
+// port-forward connection to the pod addr through the API server host, and
+// monitors its error stream. release is called when it is closed.
+func newPooledConn(connection httpstream.Connection, errorStream httpstream.Stream, stream httpstream.Stream, addr string, host string, release func()) *Conn {
+	c := &Conn{
+		connection:  connection,
+		stream:      stream,
+		errorStream: errorStream,
+		release:     release,
+	}
+	go c.monitor(addr, host)
+
+	return c
+}
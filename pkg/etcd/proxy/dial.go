@@ -23,36 +23,86 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	httpstreamspdy "k8s.io/apimachinery/pkg/util/httpstream/spdy"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/transport/spdy"
 )
 
-const defaultTimeout = 10 * time.Second
+const (
+	defaultTimeout = 10 * time.Second
+	// defaultKeepAlive is how often the port-forward connections are pinged
+	// when the Proxy sets no KeepAlive.
+	defaultKeepAlive = 5 * time.Second
+	// connectionIdleTimeout is how long a port-forward connection without
+	// streams is kept open to be reused.
+	connectionIdleTimeout = 5 * time.Minute
+)
 
-// Dialer creates connections using Kubernetes API Server port-forwarding.
+// Dialer creates connections using Kubernetes API Server port-forwarding. The
+// port-forward connection to a pod is shared by all connections to it, each
+// using its own streams.
 type Dialer struct {
 	proxy          Proxy
 	clientset      *kubernetes.Clientset
 	proxyTransport http.RoundTripper
 	upgrader       spdy.Upgrader
 	timeout        time.Duration
+
+	mutex       sync.Mutex
+	connections map[string]*connection
+	// connecting lets concurrent dials to the same pod share the creation
+	// of its port-forward connection, which is done without holding mutex.
+	connecting singleflight.Group
+	// upgrade creates a port-forward connection to the pod addr, see
+	// upgradeConnection.
+	upgrade func(addr string) (httpstream.Connection, error)
+	// open is the number of connections dialed and not closed yet. The
+	// dialer is only idle without open connections.
+	open int
+	// lastUsed is when a connection was last dialed or closed.
+	lastUsed time.Time
+}
+
+// connection is a port-forward connection to a pod.
+type connection struct {
+	httpstream.Connection
+	requestID int
 }
 
-// NewDialer creates a new dialer for a given API server scope.
+// NewDialer returns the dialer for a given API server scope. Dialers are
+// pooled, so the dialer of a scope is reused until it is idle for
+// dialerIdleTimeout.
 func NewDialer(p Proxy, options ...func(*Dialer) error) (*Dialer, error) {
+	d, err := dialers.get(p, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func newDialer(p Proxy, options ...func(*Dialer) error) (*Dialer, error) {
 	if p.Port == 0 {
 		return nil, errors.New("port required")
 	}
 
 	dialer := &Dialer{
-		proxy: p,
+		proxy:       p,
+		connections: map[string]*connection{},
+		lastUsed:    time.Now(),
 	}
+	dialer.upgrade = dialer.upgradeConnection
 
 	for _, option := range options {
 		err := option(dialer)
@@ -64,12 +114,19 @@ func NewDialer(p Proxy, options ...func(*Dialer) error) (*Dialer, error) {
 	if dialer.timeout == 0 {
 		dialer.timeout = defaultTimeout
 	}
-	p.KubeConfig.Timeout = dialer.timeout
-	clientset, err := kubernetes.NewForConfig(p.KubeConfig)
+	keepAlive := defaultKeepAlive
+	if p.KeepAlive != nil {
+		keepAlive = *p.KeepAlive
+	}
+
+	// The configuration of the caller is left untouched.
+	kubeConfig := rest.CopyConfig(p.KubeConfig)
+	kubeConfig.Timeout = dialer.timeout
+	clientset, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		return nil, err
 	}
-	proxyTransport, upgrader, err := spdy.RoundTripperFor(p.KubeConfig)
+	proxyTransport, upgrader, err := roundTripperFor(kubeConfig, keepAlive)
 	if err != nil {
 		return nil, err
 	}
@@ -79,29 +136,63 @@ func NewDialer(p Proxy, options ...func(*Dialer) error) (*Dialer, error) {
 	return dialer, nil
 }
 
+// roundTripperFor is spdy.RoundTripperFor pinging the connections every
+// keepAlive, so that they are not closed by load balancers in front of the
+// API server while idle.
+func roundTripperFor(config *rest.Config, keepAlive time.Duration) (http.RoundTripper, spdy.Upgrader, error) {
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		proxy = config.Proxy
+	}
+	upgradeRoundTripper, err := httpstreamspdy.NewRoundTripperWithConfig(httpstreamspdy.RoundTripperConfig{
+		TLS:        tlsConfig,
+		Proxier:    proxy,
+		PingPeriod: keepAlive,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapper, err := rest.HTTPWrappersForConfig(config, upgradeRoundTripper)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wrapper, upgradeRoundTripper, nil
+}
+
 // DialContextWithAddr is a GO grpc compliant dialer construct.
 func (d *Dialer) DialContextWithAddr(ctx context.Context, addr string) (net.Conn, error) {
 	return d.DialContext(ctx, scheme, addr)
 }
 
-// DialContext creates proxied port-forwarded connections.
-// ctx is currently unused, but fulfils the type signature used by GRPC.
-func (d *Dialer) DialContext(_ context.Context, network string, addr string) (net.Conn, error) {
-	req := d.clientset.CoreV1().RESTClient().
-		Post().
-		Resource(d.proxy.Kind).
-		Namespace(d.proxy.Namespace).
-		Name(addr).
-		SubResource("portforward")
+// DialContext creates proxied port-forwarded connections. ctx bounds the wait
+// for the port-forward connection to the pod.
+func (d *Dialer) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	start := time.Now()
+
+	conn, err := d.dial(ctx, addr)
+	if errors.Is(err, errStaleConnection) {
+		// The pooled connection broke since it was last used.
+		conn, err = d.dial(ctx, addr)
+	}
+	if err != nil {
+		dialFailuresTotal.WithLabelValues(d.proxy.KubeConfig.Host).Inc()
+		return nil, err
+	}
+	dialDuration.WithLabelValues(d.proxy.KubeConfig.Host).Observe(time.Since(start).Seconds())
 
-	dialer := spdy.NewDialer(d.upgrader, &http.Client{Transport: d.proxyTransport}, "POST", req.URL())
+	return conn, nil
+}
 
-	// Create a new connection from the dialer.
-	//
-	// Warning: Any early return should close this connection, otherwise we're going to leak them.
-	connection, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+var errStaleConnection = errors.New("stale port-forward connection")
+
+func (d *Dialer) dial(ctx context.Context, addr string) (net.Conn, error) {
+	c, requestID, reused, err := d.connection(ctx, addr)
 	if err != nil {
-		return nil, errors.Wrap(err, "error upgrading connection")
+		return nil, err
 	}
 
 	// Create the headers.
@@ -110,24 +201,19 @@ func (d *Dialer) DialContext(_ context.Context, network string, addr string) (ne
 	// Set the header port number to match the proxy one.
 	headers.Set(corev1.PortHeader, fmt.Sprintf("%d", d.proxy.Port))
 
-	// We only create a single stream over the connection
-	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	// Every pair of streams over the connection has its own request ID.
+	headers.Set(corev1.PortForwardRequestIDHeader, requestID)
 
 	// Create the error stream.
 	headers.Set(corev1.StreamType, corev1.StreamTypeError)
-	errorStream, err := connection.CreateStream(headers)
+	errorStream, err := c.CreateStream(headers)
 	if err != nil {
-		return nil, kerrors.NewAggregate([]error{
-			err,
-			connection.Close(),
-		})
+		return nil, d.dropConnection(addr, c, reused, err)
 	}
-	// Close the error stream right away, we're not writing to it.
+	// Close the writing side of the error stream, we're not writing to it.
+	// The port-forward errors are read from it by the Conn.
 	if err := errorStream.Close(); err != nil {
-		return nil, kerrors.NewAggregate([]error{
-			err,
-			connection.Close(),
-		})
+		return nil, d.dropConnection(addr, c, reused, err)
 	}
 
 	// Create the data stream.
@@ -135,16 +221,166 @@ func (d *Dialer) DialContext(_ context.Context, network string, addr string) (ne
 	// NOTE: Given that we're reusing the headers,
 	// we need to overwrite the stream type before creating it.
 	headers.Set(corev1.StreamType, corev1.StreamTypeData)
-	dataStream, err := connection.CreateStream(headers)
+	dataStream, err := c.CreateStream(headers)
 	if err != nil {
-		return nil, kerrors.NewAggregate([]error{
-			errors.Wrap(err, "error creating forwarding stream"),
-			connection.Close(),
-		})
+		return nil, d.dropConnection(addr, c, reused, errors.Wrap(err, "error creating forwarding stream"))
 	}
 
+	d.mutex.Lock()
+	d.open++
+	d.mutex.Unlock()
+
 	// Create the net.Conn and return.
-	return NewConn(connection, dataStream), nil
+	return newPooledConn(c.Connection, errorStream, dataStream, addr, d.proxy.KubeConfig.Host, d.release), nil
+}
+
+// release records that a connection dialed by d was closed.
+func (d *Dialer) release() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.open--
+	d.lastUsed = time.Now()
+}
+
+// connection returns the port-forward connection to the pod addr, creating
+// it when there is none or it was closed, and the request ID of the next
+// streams. Connections are created without holding mutex, so that an API
+// server not answering only blocks the dials to the same pod, and these stop
+// waiting once ctx is done.
+func (d *Dialer) connection(ctx context.Context, addr string) (*connection, string, bool, error) {
+	c, ok := d.pooledConnection(addr)
+	if ok {
+		return c, d.nextRequestID(c), true, nil
+	}
+
+	result := d.connecting.DoChan(addr, func() (interface{}, error) {
+		// The connection may have been created since it was looked up.
+		c, ok := d.pooledConnection(addr)
+		if ok {
+			return c, nil
+		}
+
+		upgraded, err := d.upgrade(addr)
+		if err != nil {
+			return nil, errors.Wrap(err, "error upgrading connection")
+		}
+		upgraded.SetIdleTimeout(connectionIdleTimeout)
+		connectionsTotal.WithLabelValues(d.proxy.KubeConfig.Host).Inc()
+
+		c = &connection{Connection: upgraded}
+		d.mutex.Lock()
+		d.connections[addr] = c
+		d.mutex.Unlock()
+
+		return c, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, "", false, errors.Wrap(ctx.Err(), "error upgrading connection")
+	case r := <-result:
+		if r.Err != nil {
+			return nil, "", false, r.Err
+		}
+		c := r.Val.(*connection)
+
+		return c, d.nextRequestID(c), false, nil
+	}
+}
+
+// pooledConnection returns the port-forward connection to the pod addr
+// unless there is none or it was closed.
+func (d *Dialer) pooledConnection(addr string) (*connection, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.lastUsed = time.Now()
+
+	c, ok := d.connections[addr]
+	if !ok {
+		return nil, false
+	}
+
+	select {
+	case <-c.CloseChan():
+		delete(d.connections, addr)
+		return nil, false
+	default:
+		return c, true
+	}
+}
+
+// nextRequestID returns the request ID of the next streams over c.
+func (d *Dialer) nextRequestID(c *connection) string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	requestID := strconv.Itoa(c.requestID)
+	c.requestID++
+
+	return requestID
+}
+
+// upgradeConnection creates a port-forward connection to the pod addr. The
+// upgrade request is bounded by the timeout of the dialer.
+func (d *Dialer) upgradeConnection(addr string) (httpstream.Connection, error) {
+	req := d.clientset.CoreV1().RESTClient().
+		Post().
+		Resource(d.proxy.Kind).
+		Namespace(d.proxy.Namespace).
+		Name(addr).
+		SubResource("portforward")
+
+	dialer := spdy.NewDialer(d.upgrader, &http.Client{Transport: d.proxyTransport, Timeout: d.timeout}, "POST", req.URL())
+
+	// Create a new connection from the dialer.
+	//
+	// Warning: Any early return should close this connection, otherwise we're going to leak them.
+	upgraded, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return upgraded, nil
+}
+
+// dropConnection closes the connection c to addr after creating a stream over
+// it failed. Failures of reused connections are stale connection errors, so
+// that the dial is retried over a new connection.
+func (d *Dialer) dropConnection(addr string, c *connection, reused bool, err error) error {
+	d.mutex.Lock()
+	if d.connections[addr] == c {
+		delete(d.connections, addr)
+	}
+	d.mutex.Unlock()
+
+	err = kerrors.NewAggregate([]error{err, c.Close()})
+	if reused {
+		return errors.Wrap(errStaleConnection, err.Error())
+	}
+
+	return err
+}
+
+// close closes all port-forward connections of the dialer.
+func (d *Dialer) close() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for addr, c := range d.connections {
+		_ = c.Close()
+		delete(d.connections, addr)
+	}
+}
+
+// isIdle returns whether the dialer has no open connections and was not used
+// for timeout.
+func (d *Dialer) isIdle(timeout time.Duration) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.open == 0 && time.Since(d.lastUsed) > timeout
 }
 
 // DialTimeout sets the timeout.
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/rest"
+)
+
+func Test_Dialer_connection(t *testing.T) {
+	release := make(chan struct{})
+	upgrading := make(chan struct{})
+	var upgrades atomic.Int32
+
+	d := &Dialer{
+		proxy:       Proxy{KubeConfig: &rest.Config{Host: "https://api.abc12.example.com"}},
+		connections: map[string]*connection{},
+		upgrade: func(addr string) (httpstream.Connection, error) {
+			upgrades.Add(1)
+			if addr == "etcd-slow" {
+				close(upgrading)
+				<-release
+			}
+
+			return &fakeConnection{closed: make(chan bool)}, nil
+		},
+	}
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	results := make([]*connection, 2)
+	requestIDs := make([]string, 2)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, requestID, _, err := d.connection(ctx, "etcd-slow")
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = c
+			requestIDs[i] = requestID
+		}()
+	}
+	<-upgrading
+
+	// Other pods are dialed while the connection to the slow one is upgraded.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _, err := d.connection(ctx, "etcd-fast")
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("pod etcd-fast is blocked by the connection to pod etcd-slow")
+	}
+
+	// Dials stop waiting for the connection once their context is done.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, _, err := d.connection(canceled, "etcd-slow")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error == %#v, want %#v", err, context.Canceled)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if results[0] == nil || results[0] != results[1] {
+		t.Fatalf("concurrent dials got different connections %p and %p", results[0], results[1])
+	}
+	if requestIDs[0] == requestIDs[1] {
+		t.Fatalf("concurrent dials got the same request ID %s", requestIDs[0])
+	}
+
+	// Pooled connections are reused.
+	c, _, reused, err := d.connection(ctx, "etcd-slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != results[0] || !reused {
+		t.Fatalf("connection was not reused")
+	}
+	if upgrades.Load() != 2 {
+		t.Fatalf("upgraded %d connections, want 2", upgrades.Load())
+	}
+}
+
+type fakeConnection struct {
+	closed chan bool
+}
+
+func (c *fakeConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeConnection) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func (c *fakeConnection) CloseChan() <-chan bool {
+	return c.closed
+}
+
+func (c *fakeConnection) SetIdleTimeout(timeout time.Duration) {}
+
+func (c *fakeConnection) RemoveStreams(streams ...httpstream.Stream) {}
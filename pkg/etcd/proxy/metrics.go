@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "etcd_backup"
+	metricsSubsystem = "proxy"
+
+	labelAPIServer = "api_server"
+)
+
+var (
+	dialDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "dial_duration_seconds",
+			Help:      "Duration of successful dials of etcd through the port-forward proxy of the API server.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		},
+		[]string{labelAPIServer},
+	)
+
+	dialFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "dial_failures_total",
+			Help:      "Number of failed dials of etcd through the port-forward proxy of the API server.",
+		},
+		[]string{labelAPIServer},
+	)
+
+	connectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "connections_total",
+			Help:      "Number of port-forward connections opened to the API server, which are shared by the dials to the same pod.",
+		},
+		[]string{labelAPIServer},
+	)
+
+	streamErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "stream_errors_total",
+			Help:      "Number of port-forward failures reported by the API server on established connections.",
+		},
+		[]string{labelAPIServer},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		dialDuration,
+		dialFailuresTotal,
+		connectionsTotal,
+		streamErrorsTotal,
+	)
+}
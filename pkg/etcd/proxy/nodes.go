@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	xproxy "golang.org/x/net/proxy"
+)
+
+// ContextDialer returns the function dialing the resources of p, either
+// through the API server port-forward or at the addresses of their nodes.
+func ContextDialer(p Proxy) (func(ctx context.Context, addr string) (net.Conn, error), error) {
+	if p.Nodes == nil {
+		d, err := NewDialer(p)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.DialContextWithAddr, nil
+	}
+
+	d, err := newNodeDialer(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.DialContext, nil
+}
+
+// nodeDialer dials the resources at the addresses of their nodes, directly
+// or through an HTTP CONNECT or SOCKS5 proxy.
+type nodeDialer struct {
+	nodes map[string]string
+	port  int
+	via   *url.URL
+
+	forward xproxy.ContextDialer
+}
+
+func newNodeDialer(p Proxy) (*nodeDialer, error) {
+	if p.Port == 0 {
+		return nil, errors.New("port required")
+	}
+
+	d := &nodeDialer{
+		nodes: p.Nodes,
+		port:  p.Port,
+		via:   p.Via,
+
+		forward: &net.Dialer{Timeout: defaultTimeout, KeepAlive: defaultKeepAlive},
+	}
+
+	if p.Via != nil {
+		switch p.Via.Scheme {
+		case "http":
+		case "socks5":
+			var auth *xproxy.Auth
+			if p.Via.User != nil {
+				password, _ := p.Via.User.Password()
+				auth = &xproxy.Auth{User: p.Via.User.Username(), Password: password}
+			}
+
+			socks, err := xproxy.SOCKS5("tcp", p.Via.Host, auth, d.forward.(*net.Dialer))
+			if err != nil {
+				return nil, errors.Wrap(err, "error creating SOCKS5 dialer")
+			}
+			d.forward = socks.(xproxy.ContextDialer)
+		default:
+			return nil, errors.Errorf("unsupported proxy scheme %q", p.Via.Scheme)
+		}
+	}
+
+	return d, nil
+}
+
+// DialContext dials the node of the resource named by the host of addr. The
+// port of addr is replaced with the port of the dialer.
+func (d *nodeDialer) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	name, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		// Endpoints named after resources usually have no port.
+		name = addr
+	}
+
+	node, ok := d.nodes[name]
+	if !ok {
+		return nil, errors.Errorf("no node address known for %q", name)
+	}
+	target := net.JoinHostPort(node, strconv.Itoa(d.port))
+
+	if d.via != nil && d.via.Scheme == "http" {
+		return d.connect(ctx, target)
+	}
+
+	conn, err := d.forward.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error dialing %s at %s", name, target)
+	}
+
+	return conn, nil
+}
+
+// connect opens a tunnel to target through the HTTP CONNECT proxy of the
+// dialer.
+func (d *nodeDialer) connect(ctx context.Context, target string) (net.Conn, error) {
+	conn, err := d.forward.DialContext(ctx, "tcp", d.via.Host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error dialing proxy %s", d.via.Host)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: http.Header{},
+	}
+	if d.via.User != nil {
+		password, _ := d.via.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.via.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	// The deadline of ctx also bounds the exchange with the proxy.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	err = req.Write(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "error writing CONNECT request to proxy %s", d.via.Host)
+	}
+
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "error reading CONNECT response of proxy %s", d.via.Host)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, errors.Errorf("proxy %s refused to connect to %s: %s", d.via.Host, target, res.Status)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were read into reader.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,154 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func Test_nodeDialer(t *testing.T) {
+	etcd := newEchoServer(t)
+	_, port, _ := net.SplitHostPort(etcd.Addr().String())
+	etcdPort, _ := strconv.Atoi(port)
+
+	connectProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpzZWNyZXQ=" {
+			http.Error(w, "unauthorized", http.StatusProxyAuthRequired)
+			return
+		}
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			_ = upstream.Close()
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			_, _ = io.Copy(upstream, conn)
+			_ = upstream.Close()
+		}()
+		_, _ = io.Copy(conn, upstream)
+		_ = conn.Close()
+	}))
+	t.Cleanup(connectProxy.Close)
+
+	proxyURL, _ := url.Parse(connectProxy.URL)
+	authenticated := *proxyURL
+	authenticated.User = url.UserPassword("user", "secret")
+
+	nodes := map[string]string{"etcd-abc12-cp-1": "127.0.0.1"}
+
+	testCases := []struct {
+		name    string
+		via     *url.URL
+		addr    string
+		success bool
+	}{
+		{
+			name:    "case 0: direct by pod name",
+			via:     nil,
+			addr:    "etcd-abc12-cp-1",
+			success: true,
+		},
+		{
+			name:    "case 1: direct with port",
+			via:     nil,
+			addr:    "etcd-abc12-cp-1:2379",
+			success: true,
+		},
+		{
+			name:    "case 2: unknown name",
+			via:     nil,
+			addr:    "etcd-abc12-cp-2",
+			success: false,
+		},
+		{
+			name:    "case 3: through HTTP CONNECT proxy",
+			via:     &authenticated,
+			addr:    "etcd-abc12-cp-1",
+			success: true,
+		},
+		{
+			name:    "case 4: proxy refusing to connect",
+			via:     proxyURL,
+			addr:    "etcd-abc12-cp-1",
+			success: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			dial, err := ContextDialer(Proxy{Nodes: nodes, Port: etcdPort, Via: tc.via})
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			conn, err := dial(context.Background(), tc.addr)
+			if !tc.success {
+				if err == nil {
+					_ = conn.Close()
+					t.Fatalf("error == nil, want non-nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+			defer conn.Close() //nolint:errcheck
+
+			_, err = conn.Write([]byte("ping"))
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+			b := make([]byte, 4)
+			_, err = io.ReadFull(conn, b)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+			if string(b) != "ping" {
+				t.Fatalf("read %q, want %q", b, "ping")
+			}
+		})
+	}
+}
+
+// newEchoServer listens on a local port and writes back what it reads.
+func newEchoServer(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	return l
+}
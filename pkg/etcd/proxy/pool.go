@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dialerIdleTimeout is how long a dialer is kept in the pool without open
+// connections and without being used.
+const dialerIdleTimeout = 10 * time.Minute
+
+// dialers is the pool of the dialers of all API server scopes.
+var dialers = &pool{dialers: map[dialerKey]*Dialer{}}
+
+// pool shares the dialers, and so their port-forward connections, between
+// the etcd clients of a workload cluster.
+type pool struct {
+	mutex   sync.Mutex
+	dialers map[dialerKey]*Dialer
+}
+
+// dialerKey identifies the API server scope of a dialer. Dialers with rotated
+// credentials are not reused.
+type dialerKey struct {
+	host        string
+	kind        string
+	namespace   string
+	port        int
+	keepAlive   time.Duration
+	credentials [sha256.Size]byte
+}
+
+func newDialerKey(p Proxy) dialerKey {
+	c := p.KubeConfig
+
+	k := dialerKey{
+		host:      c.Host,
+		kind:      p.Kind,
+		namespace: p.Namespace,
+		port:      p.Port,
+		credentials: sha256.Sum256(fmt.Appendf(nil, "%q %q %q %q %q %q %q %q %q",
+			c.BearerToken, c.BearerTokenFile, c.Username, c.Password,
+			c.CertData, c.CertFile, c.KeyData, c.KeyFile, c.CAData)),
+	}
+	if p.KeepAlive != nil {
+		k.keepAlive = *p.KeepAlive
+	}
+
+	return k
+}
+
+// get returns the dialer of the scope of p, creating it when there is none.
+// Dialers without open connections which were not used for
+// dialerIdleTimeout are closed.
+func (pl *pool) get(p Proxy, options ...func(*Dialer) error) (*Dialer, error) {
+	k := newDialerKey(p)
+
+	pl.mutex.Lock()
+	defer pl.mutex.Unlock()
+
+	for key, d := range pl.dialers {
+		if key != k && d.isIdle(dialerIdleTimeout) {
+			d.close()
+			delete(pl.dialers, key)
+		}
+	}
+
+	d, ok := pl.dialers[k]
+	if ok && len(options) == 0 {
+		return d, nil
+	}
+
+	d, err := newDialer(p, options...)
+	if err != nil {
+		return nil, err
+	}
+	// Dialers with options are not shared.
+	if len(options) == 0 {
+		pl.dialers[k] = d
+	}
+
+	return d, nil
+}
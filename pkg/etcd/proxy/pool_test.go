@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func Test_pool_get(t *testing.T) {
+	keepAlive := time.Minute
+	base := Proxy{
+		Kind:       "pods",
+		Namespace:  "kube-system",
+		KubeConfig: &rest.Config{Host: "https://api.abc12.example.com", BearerToken: "token"},
+		Port:       2379,
+	}
+
+	testCases := []struct {
+		name     string
+		other    Proxy
+		expected bool
+	}{
+		{
+			name:     "case 0: same scope",
+			other:    Proxy{Kind: "pods", Namespace: "kube-system", KubeConfig: &rest.Config{Host: "https://api.abc12.example.com", BearerToken: "token"}, Port: 2379},
+			expected: true,
+		},
+		{
+			name:     "case 1: rotated credentials",
+			other:    Proxy{Kind: "pods", Namespace: "kube-system", KubeConfig: &rest.Config{Host: "https://api.abc12.example.com", BearerToken: "rotated"}, Port: 2379},
+			expected: false,
+		},
+		{
+			name:     "case 2: other API server",
+			other:    Proxy{Kind: "pods", Namespace: "kube-system", KubeConfig: &rest.Config{Host: "https://api.def34.example.com", BearerToken: "token"}, Port: 2379},
+			expected: false,
+		},
+		{
+			name:     "case 3: other port",
+			other:    Proxy{Kind: "pods", Namespace: "kube-system", KubeConfig: &rest.Config{Host: "https://api.abc12.example.com", BearerToken: "token"}, Port: 2381},
+			expected: false,
+		},
+		{
+			name:     "case 4: other keep alive",
+			other:    Proxy{Kind: "pods", Namespace: "kube-system", KubeConfig: &rest.Config{Host: "https://api.abc12.example.com", BearerToken: "token"}, Port: 2379, KeepAlive: &keepAlive},
+			expected: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			p := &pool{dialers: map[dialerKey]*Dialer{}}
+
+			d, err := p.get(base)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+			other, err := p.get(tc.other)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			if (d == other) != tc.expected {
+				t.Fatalf("shared == %t, want %t", d == other, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_pool_evict(t *testing.T) {
+	idle := Proxy{Kind: "pods", Namespace: "kube-system", KubeConfig: &rest.Config{Host: "https://api.abc12.example.com", BearerToken: "token"}, Port: 2379}
+	other := Proxy{Kind: "pods", Namespace: "kube-system", KubeConfig: &rest.Config{Host: "https://api.def34.example.com", BearerToken: "token"}, Port: 2379}
+
+	testCases := []struct {
+		name     string
+		open     int
+		lastUsed time.Duration
+		expected bool
+	}{
+		{
+			name:     "case 0: idle dialer is evicted",
+			lastUsed: dialerIdleTimeout + time.Minute,
+			expected: false,
+		},
+		{
+			name:     "case 1: dialer with open connections is kept",
+			open:     1,
+			lastUsed: dialerIdleTimeout + time.Minute,
+			expected: true,
+		},
+		{
+			name:     "case 2: recently used dialer is kept",
+			lastUsed: time.Minute,
+			expected: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			p := &pool{dialers: map[dialerKey]*Dialer{}}
+
+			d, err := p.get(idle)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+			d.open = tc.open
+			d.lastUsed = time.Now().Add(-tc.lastUsed)
+
+			_, err = p.get(other)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			_, kept := p.dialers[newDialerKey(idle)]
+			if kept != tc.expected {
+				t.Fatalf("kept == %t, want %t", kept, tc.expected)
+			}
+		})
+	}
+}
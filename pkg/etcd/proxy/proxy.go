@@ -20,12 +20,14 @@ package proxy
 
 import (
 	"crypto/tls"
+	"net/url"
 	"time"
 
 	"k8s.io/client-go/rest"
 )
 
-// Proxy defines the API server port-forwarded proxy.
+// Proxy defines the API server port-forwarded proxy. When Nodes is set, the
+// nodes of the resources are dialed instead.
 type Proxy struct {
 
 	// Kind is the kind of Kubernetes resource
@@ -49,4 +51,19 @@ type Proxy struct {
 
 	// Port is the port to be forwarded from the relevant resource
 	Port int
+
+	// Nodes maps the names of the resources to the addresses of their
+	// nodes, which are dialed on Port instead of port-forwarding through the
+	// API server
+	Nodes map[string]string
+
+	// Via is the HTTP CONNECT ("http") or SOCKS5 ("socks5") proxy the
+	// addresses of Nodes are dialed through, nil to dial them directly
+	Via *url.URL
+
+	// Agent is set when the node agent listens on Port instead of etcd
+	Agent bool
+
+	// Upload is set when the node agents upload the backups themselves
+	Upload bool
 }
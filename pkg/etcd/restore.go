@@ -0,0 +1,107 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/key"
+)
+
+// RestoreOptions are passed to `etcdutl snapshot restore`.
+type RestoreOptions struct {
+	DataDir                  string
+	Name                     string
+	InitialCluster           string
+	InitialClusterToken      string
+	InitialAdvertisePeerURLs string
+	SkipHashCheck            bool
+}
+
+// CheckRestorable returns an error matching IsNotRestorable when the backup
+// at location can not be restored into etcd. Backups of kine datastores only
+// hold the latest revision of every key, without the metadata etcd needs to
+// start from them, and have no hash to verify.
+func CheckRestorable(location string) error {
+	a, ok := key.ParseFilename(path.Base(location))
+	if ok && a.Snapshot && a.Version == DatastoreKine {
+		return microerror.Maskf(notRestorableError, "%#q is a backup of a kine datastore, which can not be restored or verified, only inspected and exported with `inspect` and `objects export`", location)
+	}
+
+	return nil
+}
+
+// VerifySnapshot checks the integrity of a snapshot file by comparing the
+// SHA-256 etcd appends to every snapshot with the hash of its content. It
+// returns the size of the snapshot.
+func VerifySnapshot(fpath string) (int64, error) {
+	f, err := os.Open(fpath) //nolint:gosec
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+	if fi.Size() <= sha256.Size {
+		return 0, microerror.Maskf(invalidSnapshotError, "%#q is too small to be a snapshot", fpath)
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(h, io.LimitReader(f, fi.Size()-sha256.Size))
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	expected := make([]byte, sha256.Size)
+	_, err = io.ReadFull(f, expected)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	if !bytes.Equal(h.Sum(nil), expected) {
+		return 0, microerror.Maskf(invalidSnapshotError, "hash of %#q does not match", fpath)
+	}
+
+	return fi.Size(), nil
+}
+
+// RestoreSnapshot restores the snapshot into a new data directory using the
+// etcdutl binary shipped with the operator image.
+func RestoreSnapshot(ctx context.Context, fpath string, options RestoreOptions) ([]byte, error) {
+	if options.DataDir == "" {
+		return nil, microerror.Maskf(restoreFailedError, "data directory must not be empty")
+	}
+
+	args := []string{"snapshot", "restore", fpath, "--data-dir", options.DataDir}
+	if options.Name != "" {
+		args = append(args, "--name", options.Name)
+	}
+	if options.InitialCluster != "" {
+		args = append(args, "--initial-cluster", options.InitialCluster)
+	}
+	if options.InitialClusterToken != "" {
+		args = append(args, "--initial-cluster-token", options.InitialClusterToken)
+	}
+	if options.InitialAdvertisePeerURLs != "" {
+		args = append(args, "--initial-advertise-peer-urls", options.InitialAdvertisePeerURLs)
+	}
+	if options.SkipHashCheck {
+		args = append(args, "--skip-hash-check")
+	}
+
+	out, err := exec.CommandContext(ctx, "etcdutl", args...).CombinedOutput() //nolint:gosec
+	if err != nil {
+		return out, microerror.Maskf(restoreFailedError, "etcdutl snapshot restore failed: %s: %s", err, out)
+	}
+
+	return out, nil
+}
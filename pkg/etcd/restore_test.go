@@ -0,0 +1,115 @@
+package etcd
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func Test_VerifySnapshot(t *testing.T) {
+	content := []byte("etcd snapshot content")
+	h := sha256.Sum256(content)
+
+	testCases := []struct {
+		name         string
+		snapshot     []byte
+		expectedSize int64
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: valid snapshot",
+			snapshot:     append(append([]byte{}, content...), h[:]...),
+			expectedSize: int64(len(content) + sha256.Size),
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: corrupted snapshot",
+			snapshot:     append(append([]byte("x"), content...), h[:]...),
+			errorMatcher: IsInvalidSnapshot,
+		},
+		{
+			name:         "case 2: snapshot without hash",
+			snapshot:     h[:],
+			errorMatcher: IsInvalidSnapshot,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			db := filepath.Join(t.TempDir(), "snapshot.db")
+			err := os.WriteFile(db, tc.snapshot, 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			size, err := VerifySnapshot(db)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if size != tc.expectedSize {
+				t.Fatalf("size == %d, want %d", size, tc.expectedSize)
+			}
+		})
+	}
+}
+
+func Test_CheckRestorable(t *testing.T) {
+	testCases := []struct {
+		name         string
+		location     string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: etcd backup",
+			location:     "s3://backups/gauss-foo-v3-2024-05-01T12-00-00.db.tar.gz.enc",
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: embedded etcd backup",
+			location:     "gauss-foo-embedded-2024-05-01T12-00-00.db.tar.gz",
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 2: kine backup",
+			location:     "s3://backups/gauss-foo-kine-2024-05-01T12-00-00.db.tar.gz.enc",
+			errorMatcher: IsNotRestorable,
+		},
+		{
+			name:         "case 3: not named like a backup",
+			location:     "/tmp/snapshot.db",
+			errorMatcher: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			err := CheckRestorable(tc.location)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
@@ -1,8 +1,80 @@
 package etcd
 
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+const (
+	// DatastoreEtcd is an etcd cluster, backed up by V3Backup.
+	DatastoreEtcd = "etcd"
+	// DatastoreEmbedded is the etcd embedded in k3s and RKE2 servers, backed
+	// up by EmbeddedBackup.
+	DatastoreEmbedded = "embedded"
+	// DatastoreKine is a kine datastore, backed up by KineBackup.
+	DatastoreKine = "kine"
+)
+
 type Backupper interface {
-	Create() (string, error)
+	Create(ctx context.Context) (string, error)
 	Cleanup()
-	Encrypt() (string, error)
+	Encrypt(ctx context.Context) (string, error)
+	// Info describes the snapshot taken by the latest successful Create.
+	Info() SnapshotInfo
 	Version() string
 }
+
+type SnapshotInfo struct {
+	// Revision is the etcd revision the snapshot was taken at.
+	Revision int64
+	// Size is the size in bytes of the raw snapshot.
+	Size int64
+	// ArchiveSize is the size in bytes of the compressed snapshot archive.
+	ArchiveSize int64
+}
+
+// NewBackupper returns the Backupper of the datastore, which defaults to
+// DatastoreEtcd. etcd clusters reached through node agents are backed up by
+// AgentBackup.
+func NewBackupper(datastore string, tlsConfig *tls.Config, p *proxy.Proxy, encPass string, endpoints string, logger micrologger.Logger, prefix string) (Backupper, error) {
+	agentAccess := p != nil && p.Agent
+
+	switch {
+	case agentAccess && (datastore == "" || datastore == DatastoreEtcd):
+		b, err := NewAgentBackup(tlsConfig, p, encPass, endpoints, logger, prefix)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return b, nil
+	case agentAccess:
+		return nil, microerror.Maskf(invalidConfigError, "datastore %#q can not be backed up through node agents", datastore)
+	}
+
+	switch datastore {
+	case "", DatastoreEtcd:
+		b, err := NewV3Backup(tlsConfig, p, encPass, endpoints, logger, prefix)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return b, nil
+	case DatastoreEmbedded:
+		b, err := NewEmbeddedBackup(tlsConfig, p, encPass, endpoints, logger, prefix)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return b, nil
+	case DatastoreKine:
+		b, err := NewKineBackup(tlsConfig, p, encPass, endpoints, logger, prefix)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return b, nil
+	}
+
+	return nil, microerror.Maskf(invalidConfigError, "unknown datastore %#q", datastore)
+}
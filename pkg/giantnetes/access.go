@@ -0,0 +1,196 @@
+package giantnetes
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/agent"
+)
+
+const (
+	// AccessAnnotation selects how the etcd of a CAPI cluster with a kubeadm
+	// control plane is reached. It is set on the cluster object to one of
+	// the Access constants and defaults to AccessPortForward.
+	AccessAnnotation = "giantswarm.io/etcd-backup-operator-access"
+	// AccessProxyAnnotation is the URL of the HTTP CONNECT ("http://") or
+	// SOCKS5 ("socks5://") proxy the control plane nodes are reached
+	// through. It is required by AccessProxy and optional for AccessAgent
+	// and AccessAgentUpload.
+	AccessProxyAnnotation = "giantswarm.io/etcd-backup-operator-access-proxy"
+	// AccessProxySecretAnnotation names the secret in the namespace of the
+	// cluster holding the username and password of the proxy.
+	AccessProxySecretAnnotation = "giantswarm.io/etcd-backup-operator-access-proxy-secret"
+	// AccessAgentPortAnnotation is the port the node agents listen on,
+	// agent.DefaultPort by default.
+	AccessAgentPortAnnotation = "giantswarm.io/etcd-backup-operator-access-agent-port"
+
+	// AccessPortForward port-forwards to the etcd pods through the API
+	// server of the cluster.
+	AccessPortForward = "port-forward"
+	// AccessDirect dials etcd on the addresses of the control plane nodes.
+	AccessDirect = "direct"
+	// AccessProxy dials etcd on the addresses of the control plane nodes
+	// through the proxy of AccessProxyAnnotation.
+	AccessProxy = "proxy"
+	// AccessAgent streams the snapshots from the node agents on the
+	// addresses of the control plane nodes.
+	AccessAgent = "agent"
+	// AccessAgentUpload has the node agents on the addresses of the control
+	// plane nodes take, encrypt and upload the backups themselves.
+	AccessAgentUpload = "agent-upload"
+
+	proxySecretUsernameKey = "username"
+	proxySecretPasswordKey = "password"
+)
+
+// Access describes how the etcd of a cluster is reached.
+type Access struct {
+	Mode string
+	// Proxy is the proxy the control plane nodes are dialed through, nil
+	// to dial them directly.
+	Proxy *url.URL
+	// ProxySecret names the secret with the credentials of Proxy.
+	ProxySecret string
+	// AgentPort is the port of the node agents.
+	AgentPort int
+}
+
+// AccessFromAnnotations parses the annotations selecting how etcd is
+// reached. etcd is port-forwarded to when they are not set.
+func AccessFromAnnotations(annotations map[string]string) (Access, error) {
+	a := Access{
+		Mode:        annotations[AccessAnnotation],
+		ProxySecret: annotations[AccessProxySecretAnnotation],
+		AgentPort:   agent.DefaultPort,
+	}
+	if a.Mode == "" {
+		a.Mode = AccessPortForward
+	}
+
+	switch a.Mode {
+	case AccessPortForward, AccessDirect, AccessProxy, AccessAgent, AccessAgentUpload:
+	default:
+		return Access{}, microerror.Maskf(invalidConfigError, "annotation %#q has invalid value %#q, expected one of %s", AccessAnnotation, a.Mode, strings.Join([]string{AccessPortForward, AccessDirect, AccessProxy, AccessAgent, AccessAgentUpload}, ", "))
+	}
+
+	if v := annotations[AccessProxyAnnotation]; v != "" {
+		u, err := url.Parse(v)
+		if err != nil {
+			return Access{}, microerror.Maskf(invalidConfigError, "annotation %#q has invalid URL %#q", AccessProxyAnnotation, v)
+		}
+		if u.Scheme != "http" && u.Scheme != "socks5" {
+			return Access{}, microerror.Maskf(invalidConfigError, "annotation %#q must be an http:// or socks5:// URL", AccessProxyAnnotation)
+		}
+		if u.User != nil {
+			return Access{}, microerror.Maskf(invalidConfigError, "annotation %#q must not contain credentials, they are read from the secret of %#q", AccessProxyAnnotation, AccessProxySecretAnnotation)
+		}
+		a.Proxy = u
+	}
+	if a.Mode == AccessProxy && a.Proxy == nil {
+		return Access{}, microerror.Maskf(invalidConfigError, "annotation %#q must be set with access %#q", AccessProxyAnnotation, AccessProxy)
+	}
+	if (a.Mode == AccessPortForward || a.Mode == AccessDirect) && a.Proxy != nil {
+		return Access{}, microerror.Maskf(invalidConfigError, "annotation %#q is only used with access %#q, %#q and %#q", AccessProxyAnnotation, AccessProxy, AccessAgent, AccessAgentUpload)
+	}
+
+	if v := annotations[AccessAgentPortAnnotation]; v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port < 1 || port > 65535 {
+			return Access{}, microerror.Maskf(invalidConfigError, "annotation %#q has invalid port %#q", AccessAgentPortAnnotation, v)
+		}
+		a.AgentPort = port
+	}
+
+	return a, nil
+}
+
+// ViaAgent returns whether the node agents are talked to instead of etcd.
+func (a Access) ViaAgent() bool {
+	return a.Mode == AccessAgent || a.Mode == AccessAgentUpload
+}
+
+// controlPlaneNodes returns the addresses of the control plane nodes of c by
+// the names of their etcd pods.
+func (u *Utils) controlPlaneNodes(ctx context.Context, c *capi.Cluster) (map[string]string, error) {
+	var machines []capi.Machine
+	list := capi.MachineList{}
+	err := u.listPages(ctx, &list, func() {
+		machines = append(machines, list.Items...)
+	}, client.InNamespace(c.Namespace), client.MatchingLabels{capi.ClusterNameLabel: c.Name}, client.HasLabels{capi.MachineControlPlaneLabel})
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error listing control plane machines of cluster %#q with error %#q", c.Name, err)
+	}
+
+	nodes := controlPlaneNodes(machines)
+	if len(nodes) == 0 {
+		return nil, microerror.Maskf(noControlPlaneNodesError, "no control plane machine of cluster %#q has a node with an address", c.Name)
+	}
+
+	return nodes, nil
+}
+
+// controlPlaneNodes returns the internal, or else external, addresses of the
+// nodes of machines by the names of their etcd pods. Machines being deleted
+// or without node are left out.
+func controlPlaneNodes(machines []capi.Machine) map[string]string {
+	nodes := map[string]string{}
+	for _, m := range machines {
+		if m.DeletionTimestamp != nil || m.Status.NodeRef.Name == "" {
+			continue
+		}
+
+		var address string
+		for _, t := range []capi.MachineAddressType{capi.MachineInternalIP, capi.MachineExternalIP} {
+			for _, a := range m.Status.Addresses {
+				if address == "" && a.Type == t {
+					address = a.Address
+				}
+			}
+		}
+		if address == "" {
+			continue
+		}
+
+		nodes[etcdPodPrefix+m.Status.NodeRef.Name] = address
+	}
+
+	return nodes
+}
+
+// nodeEndpoints returns the names of the etcd pods of nodes as comma
+// separated endpoints.
+func nodeEndpoints(nodes map[string]string) string {
+	var names []string
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ",")
+}
+
+// proxyURL returns the proxy of a with the credentials of its secret.
+func (u *Utils) proxyURL(ctx context.Context, c *capi.Cluster, a Access) (*url.URL, error) {
+	if a.Proxy == nil || a.ProxySecret == "" {
+		return a.Proxy, nil
+	}
+
+	s := &v1.Secret{}
+	err := u.K8sClient.CtrlClient().Get(ctx, client.ObjectKey{Namespace: c.Namespace, Name: a.ProxySecret}, s)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "error getting proxy secret %s/%s of cluster %#q with error %#q", c.Namespace, a.ProxySecret, c.Name, err)
+	}
+
+	proxy := *a.Proxy
+	proxy.User = url.UserPassword(string(s.Data[proxySecretUsernameKey]), string(s.Data[proxySecretPasswordKey]))
+
+	return &proxy, nil
+}
@@ -0,0 +1,269 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"golang.org/x/sync/singleflight"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+const (
+	// discoveryTTL is how long the clients and TLS configurations of a
+	// cluster are reused before they are created again, e.g. to pick up a
+	// rotated kubeconfig.
+	discoveryTTL = 10 * time.Minute
+	// informerSyncTimeout bounds the initial listing of the etcd pods of a
+	// workload cluster.
+	informerSyncTimeout = 30 * time.Second
+	// listPageSize is the number of objects listed per request.
+	listPageSize = 500
+)
+
+// sharedCache is used by all Utils, which are created on every
+// reconciliation.
+var sharedCache = newDiscoveryCache()
+
+// discoveryCache keeps what is needed to reach the etcd of clusters between
+// reconciliations.
+type discoveryCache struct {
+	mutex      sync.Mutex
+	clusters   map[client.ObjectKey]*workloadCluster
+	tlsConfigs map[tlsConfigKey]cachedTLSConfig
+
+	// connecting lets concurrent callers share the connection to a workload
+	// cluster, which is made without holding mutex.
+	connecting singleflight.Group
+	// connect connects to a workload cluster, see connectWorkloadCluster.
+	connect func(ctx context.Context, c client.Reader, clusterKey client.ObjectKey) (*workloadCluster, error)
+}
+
+// workloadCluster holds the REST configuration of a workload cluster and a
+// reader serving its etcd pods from an informer.
+type workloadCluster struct {
+	restConfig *rest.Config
+	etcdPods   client.Reader
+	cancel     context.CancelFunc
+	expiry     time.Time
+}
+
+type tlsConfigKey struct {
+	name     string
+	source   certs.Source
+	insecure bool
+}
+
+type cachedTLSConfig struct {
+	tlsConfig *tls.Config
+	expiry    time.Time
+}
+
+func newDiscoveryCache() *discoveryCache {
+	return &discoveryCache{
+		clusters:   map[client.ObjectKey]*workloadCluster{},
+		tlsConfigs: map[tlsConfigKey]cachedTLSConfig{},
+		connect:    connectWorkloadCluster,
+	}
+}
+
+// workloadCluster returns the cached workload cluster of the CAPI cluster
+// clusterKey, or connects to it when it is not cached or expired. Connecting
+// may take up to informerSyncTimeout, so it does not block the other
+// clusters, and concurrent callers for the same cluster wait for the same
+// connection.
+func (d *discoveryCache) workloadCluster(ctx context.Context, logger micrologger.Logger, c client.Reader, clusterKey client.ObjectKey) (*workloadCluster, error) {
+	w, ok := d.cachedWorkloadCluster(clusterKey)
+	if ok {
+		return w, nil
+	}
+
+	v, err, _ := d.connecting.Do(clusterKey.String(), func() (interface{}, error) {
+		// The cluster may have been connected to since it was looked up.
+		w, ok := d.cachedWorkloadCluster(clusterKey)
+		if ok {
+			return w, nil
+		}
+
+		w, err := d.connect(ctx, c, clusterKey)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		d.mutex.Lock()
+		expired, ok := d.clusters[clusterKey]
+		if ok {
+			expired.cancel()
+		}
+		d.clusters[clusterKey] = w
+		d.mutex.Unlock()
+
+		logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("watching etcd pods of cluster %s", clusterKey.Name))
+
+		return w, nil
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return v.(*workloadCluster), nil
+}
+
+// cachedWorkloadCluster returns the workload cluster of clusterKey unless it
+// is not cached or expired.
+func (d *discoveryCache) cachedWorkloadCluster(clusterKey client.ObjectKey) (*workloadCluster, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	w, ok := d.clusters[clusterKey]
+	if !ok || !time.Now().Before(w.expiry) {
+		return nil, false
+	}
+
+	return w, true
+}
+
+// connectWorkloadCluster reads the kubeconfig of the CAPI cluster clusterKey
+// and watches the etcd pods of its workload cluster.
+func connectWorkloadCluster(ctx context.Context, c client.Reader, clusterKey client.ObjectKey) (*workloadCluster, error) {
+	restConfig, err := key.RESTConfig(ctx, c, clusterKey)
+	if err != nil {
+		return nil, microerror.Maskf(kubeconfigUnavailableError, "error fetching CAPI cluster rest config for cluster %#q with error %#q", clusterKey.Name, err)
+	}
+
+	w, err := newWorkloadCluster(ctx, restConfig)
+	if err != nil {
+		return nil, microerror.Maskf(kubeconfigUnavailableError, "error watching etcd pods of cluster %#q with error %#q", clusterKey.Name, err)
+	}
+
+	return w, nil
+}
+
+// newWorkloadCluster starts an informer on the etcd pods of the workload
+// cluster of restConfig and waits until it is synced. The informer runs until
+// the workload cluster is removed from the cache.
+func newWorkloadCluster(ctx context.Context, restConfig *rest.Config) (*workloadCluster, error) {
+	s := runtime.NewScheme()
+	err := v1.AddToScheme(s)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	informers, err := cache.New(restConfig, cache.Options{
+		Scheme: s,
+		ByObject: map[client.Object]cache.ByObject{
+			&v1.Pod{}: {
+				Namespaces: map[string]cache.Config{"kube-system": {}},
+				Label:      labels.SelectorFromSet(labels.Set{EtcdLabelComponentKey: EtcdLabelComponentValue, EtcdLabelTierKey: EtcdLabelTierValue}),
+			},
+		},
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// The informer outlives the reconciliation it is created in.
+	informerCtx, cancel := context.WithCancel(context.Background())
+	go informers.Start(informerCtx) //nolint:errcheck
+
+	syncCtx, syncCancel := context.WithTimeout(ctx, informerSyncTimeout)
+	defer syncCancel()
+
+	_, err = informers.GetInformer(syncCtx, &v1.Pod{})
+	if err != nil {
+		cancel()
+		return nil, microerror.Mask(err)
+	}
+	if !informers.WaitForCacheSync(syncCtx) {
+		cancel()
+		return nil, microerror.Maskf(executionFailedError, "etcd pods not synced within %s", informerSyncTimeout)
+	}
+
+	w := &workloadCluster{
+		restConfig: restConfig,
+		etcdPods:   informers,
+		cancel:     cancel,
+		expiry:     time.Now().Add(discoveryTTL),
+	}
+
+	return w, nil
+}
+
+// tlsConfig returns the cached TLS configuration of name for source, or
+// creates it with create when it is not cached or expired.
+func (d *discoveryCache) tlsConfig(name string, source certs.Source, insecure bool, create func() (*tls.Config, error)) (*tls.Config, error) {
+	k := tlsConfigKey{name: name, source: source, insecure: insecure}
+
+	d.mutex.Lock()
+	c, ok := d.tlsConfigs[k]
+	d.mutex.Unlock()
+	if ok && time.Now().Before(c.expiry) {
+		return c.tlsConfig, nil
+	}
+
+	tlsConfig, err := create()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	d.mutex.Lock()
+	d.tlsConfigs[k] = cachedTLSConfig{tlsConfig: tlsConfig, expiry: time.Now().Add(discoveryTTL)}
+	d.mutex.Unlock()
+
+	return tlsConfig, nil
+}
+
+// prune stops the informers of the workload clusters and drops the TLS
+// configurations of the clusters which are not in names.
+func (d *discoveryCache) prune(names ...string) {
+	keep := map[string]bool{}
+	for _, n := range names {
+		keep[n] = true
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for k, w := range d.clusters {
+		if !keep[k.Name] {
+			w.cancel()
+			delete(d.clusters, k)
+		}
+	}
+	for k := range d.tlsConfigs {
+		if !keep[k.name] {
+			delete(d.tlsConfigs, k)
+		}
+	}
+}
+
+// listPages lists the objects of list page by page, calling page after every
+// page is read into list.
+func (u *Utils) listPages(ctx context.Context, list client.ObjectList, page func(), opts ...client.ListOption) error {
+	var continueToken string
+	for {
+		err := u.K8sClient.CtrlClient().List(ctx, list, append(opts, client.Limit(listPageSize), client.Continue(continueToken))...)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		page()
+
+		continueToken = list.GetContinue()
+		if continueToken == "" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package giantnetes
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_discoveryCache_workloadCluster(t *testing.T) {
+	slow := client.ObjectKey{Namespace: "org-acme", Name: "slow"}
+	fast := client.ObjectKey{Namespace: "org-acme", Name: "fast"}
+
+	release := make(chan struct{})
+	connecting := make(chan struct{})
+	var connects atomic.Int32
+
+	d := newDiscoveryCache()
+	d.connect = func(ctx context.Context, c client.Reader, clusterKey client.ObjectKey) (*workloadCluster, error) {
+		connects.Add(1)
+		if clusterKey == slow {
+			close(connecting)
+			<-release
+		}
+
+		return &workloadCluster{cancel: func() {}, expiry: time.Now().Add(discoveryTTL)}, nil
+	}
+
+	ctx := context.Background()
+	logger := microloggertest.New()
+
+	var wg sync.WaitGroup
+	results := make([]*workloadCluster, 2)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, err := d.workloadCluster(ctx, logger, nil, slow)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = w
+		}()
+	}
+	<-connecting
+
+	// Other clusters are connected to while the slow one is connecting.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := d.workloadCluster(ctx, logger, nil, fast)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("cluster %s is blocked by the connection to cluster %s", fast.Name, slow.Name)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if results[0] == nil || results[0] != results[1] {
+		t.Fatalf("concurrent callers got different workload clusters %p and %p", results[0], results[1])
+	}
+
+	// Cached clusters are not connected to again.
+	w, err := d.workloadCluster(ctx, logger, nil, slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w != results[0] {
+		t.Fatalf("workload cluster was not cached")
+	}
+	if connects.Load() != 2 {
+		t.Fatalf("connected %d times, want 2", connects.Load())
+	}
+}
@@ -0,0 +1,165 @@
+package giantnetes
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/giantswarm/k8smetadata/pkg/label"
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	// DRBundleAnnotation enables ("true") or disables ("false") the DR
+	// bundle of a workload cluster. It can be set on the ETCDBackup CR as
+	// well as on the cluster object.
+	DRBundleAnnotation = "giantswarm.io/etcd-backup-operator-dr-bundle"
+)
+
+// capiBundleSecrets are the secrets CAPI keeps per cluster which are needed
+// to rebuild it with the existing certificates.
+var capiBundleSecrets = []secret.Purpose{
+	secret.ClusterCA,
+	secret.EtcdCA,
+	secret.FrontProxyCA,
+	secret.ServiceAccount,
+	secret.Kubeconfig,
+}
+
+// DRBundleFromAnnotations parses the DR bundle annotation. It returns nil
+// when the annotation is not set so that less specific settings apply.
+func DRBundleFromAnnotations(annotations map[string]string) (*bool, error) {
+	v, ok := annotations[DRBundleAnnotation]
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "annotation %#q has invalid value %#q", DRBundleAnnotation, v)
+	}
+
+	return &enabled, nil
+}
+
+// DRBundleObjects returns the management cluster objects needed to rebuild
+// the workload cluster of instance: its cluster object, its certificate and
+// kubeconfig secrets and, for CAPI clusters, its control plane object.
+// Secrets which do not exist are left out.
+func (u *Utils) DRBundleObjects(ctx context.Context, instance ETCDInstance) ([]*unstructured.Unstructured, error) {
+	if instance.cluster == nil {
+		return nil, microerror.Maskf(invalidConfigError, "instance %#q is not a workload cluster", instance.Name)
+	}
+	cluster := *instance.cluster
+
+	objs := []client.Object{cluster.object}
+
+	secrets, err := u.clusterSecrets(ctx, cluster.clusterKey, cluster.provider == CAPI)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	objs = append(objs, secrets...)
+
+	if cluster.provider == CAPI {
+		controlPlane, err := u.getControlPlane(ctx, cluster)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if controlPlane != nil {
+			objs = append(objs, controlPlane)
+		}
+	}
+
+	var bundle []*unstructured.Unstructured
+	for _, obj := range objs {
+		converted, err := toUnstructured(u.K8sClient.Scheme(), obj)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		bundle = append(bundle, converted)
+	}
+
+	return bundle, nil
+}
+
+// clusterSecrets returns the certificate and kubeconfig secrets of a
+// workload cluster: the CAPI cluster secrets if capiCluster is set, and the
+// calico-etcd-client secret. Secrets which do not exist are left out.
+func (u *Utils) clusterSecrets(ctx context.Context, clusterKey client.ObjectKey, capiCluster bool) ([]client.Object, error) {
+	ctrlClient := u.K8sClient.CtrlClient()
+
+	var objs []client.Object
+	if capiCluster {
+		for _, purpose := range capiBundleSecrets {
+			s := &v1.Secret{}
+			err := ctrlClient.Get(ctx, client.ObjectKey{Namespace: clusterKey.Namespace, Name: secret.Name(clusterKey.Name, purpose)}, s)
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			objs = append(objs, s)
+		}
+	}
+
+	secrets := v1.SecretList{}
+	err := ctrlClient.List(ctx, &secrets, client.MatchingLabels{
+		label.Cluster:    clusterKey.Name,
+		certificateLabel: certificateLabelValue,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	for i := range secrets.Items {
+		objs = append(objs, &secrets.Items[i])
+	}
+
+	return objs, nil
+}
+
+// getControlPlane returns the control plane object referenced by a CAPI
+// cluster, e.g. its KubeadmControlPlane, or nil if there is none.
+func (u *Utils) getControlPlane(ctx context.Context, cluster Cluster) (*unstructured.Unstructured, error) {
+	c, ok := cluster.object.(*capi.Cluster)
+	if !ok || !c.Spec.ControlPlaneRef.IsDefined() {
+		return nil, nil
+	}
+
+	controlPlane, err := u.getObject(ctx, c.Spec.ControlPlaneRef.GroupKind(), client.ObjectKey{Namespace: c.Namespace, Name: c.Spec.ControlPlaneRef.Name})
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error getting control plane %s %#q of cluster %#q with error %#q", c.Spec.ControlPlaneRef.Kind, c.Spec.ControlPlaneRef.Name, c.Name, err)
+	}
+
+	return controlPlane, nil
+}
+
+// toUnstructured converts a typed object. The API group, version and kind
+// are looked up in the scheme, typed objects read with the controller-runtime
+// client have none set.
+func toUnstructured(scheme *runtime.Scheme, obj client.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error converting %T %#q with error %#q", obj, obj.GetName(), err)
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+
+	return u, nil
+}
@@ -0,0 +1,149 @@
+package giantnetes
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/k8sclient/v8/pkg/k8sclienttest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_DRBundleFromAnnotations(t *testing.T) {
+	enabled := true
+	disabled := false
+
+	testCases := []struct {
+		name         string
+		annotations  map[string]string
+		expected     *bool
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: no annotation",
+			annotations:  nil,
+			expected:     nil,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: enabled",
+			annotations:  map[string]string{DRBundleAnnotation: "true"},
+			expected:     &enabled,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 2: disabled",
+			annotations:  map[string]string{DRBundleAnnotation: "false"},
+			expected:     &disabled,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 3: invalid value",
+			annotations:  map[string]string{DRBundleAnnotation: "sometimes"},
+			expected:     nil,
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			drBundle, err := DRBundleFromAnnotations(tc.annotations)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !cmp.Equal(drBundle, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, drBundle))
+			}
+		})
+	}
+}
+
+func Test_DRBundleObjects(t *testing.T) {
+	err := capi.AddToScheme(scheme.Scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		controlPlane schema.GroupVersionKind
+	}{
+		{
+			name:         "case 0: Kamaji control plane",
+			controlPlane: schema.GroupVersionKind{Group: "controlplane.cluster.x-k8s.io", Version: "v1alpha1", Kind: "KamajiControlPlane"},
+		},
+		{
+			name:         "case 1: k0smotron control plane",
+			controlPlane: schema.GroupVersionKind{Group: "controlplane.cluster.x-k8s.io", Version: "v1beta1", Kind: "K0smotronControlPlane"},
+		},
+		{
+			name:         "case 2: vcluster",
+			controlPlane: schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha1", Kind: "VCluster"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			cluster := &capi.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "org-acme", Name: "abc12"},
+				Spec: capi.ClusterSpec{
+					ControlPlaneRef: capi.ContractVersionedObjectReference{APIGroup: tc.controlPlane.Group, Kind: tc.controlPlane.Kind, Name: "abc12"},
+				},
+			}
+			controlPlane := &unstructured.Unstructured{}
+			controlPlane.SetGroupVersionKind(tc.controlPlane)
+			controlPlane.SetNamespace("org-acme")
+			controlPlane.SetName("abc12")
+
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{tc.controlPlane.GroupVersion()})
+			mapper.Add(tc.controlPlane, meta.RESTScopeNamespace)
+
+			u := &Utils{
+				logger: microloggertest.New(),
+				K8sClient: k8sclienttest.NewClients(k8sclienttest.ClientsConfig{
+					CtrlClient: fake.NewClientBuilder().WithRESTMapper(mapper).WithObjects(controlPlane).Build(),
+				}),
+			}
+			instance := ETCDInstance{
+				Name:    "abc12",
+				cluster: &Cluster{clusterKey: client.ObjectKeyFromObject(cluster), provider: CAPI, object: cluster},
+			}
+
+			bundle, err := u.DRBundleObjects(context.Background(), instance)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			var kinds []string
+			for _, obj := range bundle {
+				kinds = append(kinds, obj.GetKind())
+			}
+			expected := []string{"Cluster", tc.controlPlane.Kind}
+			if !cmp.Equal(kinds, expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(expected, kinds))
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	backupv1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+const (
+	endpointTLSCAKey  = "ca.crt"
+	endpointTLSCrtKey = "tls.crt"
+	endpointTLSKeyKey = "tls.key"
+	// The files in the etcd TLS directory of k3s and RKE2 servers.
+	embeddedTLSCAKey   = "server-ca.crt"
+	embeddedTLSCrtKey  = "client.crt"
+	embeddedTLSKeyKey  = "client.key"
+	kubeconfigKey      = "value"
+	endpointProxyPort  = 2379
+	endpointProbeLimit = 10 * time.Second
+)
+
+// listETCDEndpoints returns the ETCDEndpoints of all namespaces which are not
+// marked for deletion. No ETCDEndpoints are returned when the CRD is not
+// installed.
+func (u *Utils) listETCDEndpoints(ctx context.Context) ([]backupv1alpha1.ETCDEndpoint, error) {
+	var endpoints []backupv1alpha1.ETCDEndpoint
+	list := backupv1alpha1.ETCDEndpointList{}
+	err := u.listPages(ctx, &list, func() {
+		for _, e := range list.Items {
+			if e.DeletionTimestamp == nil {
+				endpoints = append(endpoints, e)
+			}
+		}
+	})
+	if err != nil && isMissingCRDError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error listing ETCDEndpoints with error %#q", err)
+	}
+
+	return endpoints, nil
+}
+
+// endpointInstance prepares the instance backing up the etcd cluster declared
+// by e.
+func (u *Utils) endpointInstance(ctx context.Context, e backupv1alpha1.ETCDEndpoint) (ETCDInstance, error) {
+	if len(e.Spec.Endpoints) == 0 {
+		return ETCDInstance{}, microerror.Maskf(invalidConfigError, "ETCDEndpoint %s/%s has no endpoints", e.Namespace, e.Name)
+	}
+
+	tlsConfig, err := u.getEndpointTLSCfg(ctx, e)
+	if err != nil {
+		return ETCDInstance{}, microerror.Mask(err)
+	}
+
+	p, err := u.getEndpointProxy(ctx, e, tlsConfig)
+	if err != nil {
+		return ETCDInstance{}, microerror.Mask(err)
+	}
+
+	endpoint := e.Spec.Endpoints[0]
+	if len(e.Spec.Endpoints) > 1 {
+		endpoint, err = u.firstHealthyEndpoint(ctx, e.Spec.Endpoints, tlsConfig, p)
+		if err != nil {
+			return ETCDInstance{}, microerror.Mask(err)
+		}
+	}
+
+	return ETCDInstance{
+		Name: e.Name,
+		ETCDv3: ETCDv3Settings{
+			Endpoints: endpoint,
+			TLSConfig: tlsConfig,
+			Proxy:     p,
+		},
+		Timeouts: TimeoutsFromPolicy(e.Spec.Backup),
+	}, nil
+}
+
+// TimeoutsFromPolicy returns the stage timeouts set in the backup policy of
+// an ETCDEndpoint. Stages without timeout are left at zero.
+func TimeoutsFromPolicy(policy backupv1alpha1.ETCDEndpointBackupPolicy) Timeouts {
+	var t Timeouts
+	if policy.Timeouts == nil {
+		return t
+	}
+
+	if policy.Timeouts.Create != nil {
+		t.Create = policy.Timeouts.Create.Duration
+	}
+	if policy.Timeouts.Encrypt != nil {
+		t.Encrypt = policy.Timeouts.Encrypt.Duration
+	}
+	if policy.Timeouts.Upload != nil {
+		t.Upload = policy.Timeouts.Upload.Duration
+	}
+
+	return t
+}
+
+// Fetch ETCD client certs for an ETCDEndpoint.
+func (u *Utils) getEndpointTLSCfg(ctx context.Context, e backupv1alpha1.ETCDEndpoint) (*tls.Config, error) {
+	source := certs.SecretSource{
+		Client:  u.K8sClient.CtrlClient(),
+		Secret:  client.ObjectKey{Namespace: e.Namespace, Name: e.Spec.TLS.SecretName},
+		CAKey:   endpointTLSCAKey,
+		CertKey: endpointTLSCrtKey,
+		KeyKey:  endpointTLSKeyKey,
+	}
+	if e.Spec.Datastore == etcd.DatastoreEmbedded {
+		source.CAKey = embeddedTLSCAKey
+		source.CertKey = embeddedTLSCrtKey
+		source.KeyKey = embeddedTLSKeyKey
+	}
+
+	var serverName certs.ServerName
+	if e.Spec.TLS.ServerName != "" {
+		serverName = certs.Fixed(e.Spec.TLS.ServerName)
+	}
+	if e.Spec.TLS.InsecureSkipVerify {
+		u.logger.LogCtx(ctx, "level", "warning", "msg", fmt.Sprintf("Verification of the etcd server certificate of ETCDEndpoint %s/%s is disabled", e.Namespace, e.Name))
+	}
+
+	tlsConfig, err := u.secretTLSConfig(ctx, e.Name, source, serverName, e.Spec.TLS.InsecureSkipVerify)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return tlsConfig, nil
+}
+
+// getEndpointProxy returns the port-forward proxy of an ETCDEndpoint, or nil
+// when its endpoints are dialed directly. Port-forwards through the
+// management cluster API are restricted to the namespace of the
+// ETCDEndpoint, so that it does not reach the pods of other tenants with the
+// permissions of the operator.
+func (u *Utils) getEndpointProxy(ctx context.Context, e backupv1alpha1.ETCDEndpoint, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	if e.Spec.Proxy == nil {
+		return nil, nil
+	}
+	if e.Spec.Proxy.KubeconfigSecretRef == nil && e.Spec.Proxy.Namespace != e.Namespace {
+		return nil, microerror.Maskf(invalidConfigError, "proxy namespace %#q of ETCDEndpoint %s/%s must be its own namespace without kubeconfigSecretRef", e.Spec.Proxy.Namespace, e.Namespace, e.Name)
+	}
+
+	restConfig := rest.CopyConfig(u.K8sClient.RESTConfig())
+	if ref := e.Spec.Proxy.KubeconfigSecretRef; ref != nil {
+		dataKey := ref.Key
+		if dataKey == "" {
+			dataKey = kubeconfigKey
+		}
+
+		s := &v1.Secret{}
+		err := u.K8sClient.CtrlClient().Get(ctx, client.ObjectKey{Namespace: e.Namespace, Name: ref.Name}, s)
+		if err != nil {
+			return nil, microerror.Maskf(kubeconfigUnavailableError, "error getting kubeconfig of ETCDEndpoint %s/%s with error %#q", e.Namespace, e.Name, err)
+		}
+
+		restConfig, err = clientcmd.RESTConfigFromKubeConfig(s.Data[dataKey])
+		if err != nil {
+			return nil, microerror.Maskf(kubeconfigUnavailableError, "invalid kubeconfig in %#q of secret %s/%s with error %#q", dataKey, e.Namespace, ref.Name, err)
+		}
+	}
+
+	port := e.Spec.Proxy.Port
+	if port == 0 {
+		port = endpointProxyPort
+	}
+
+	p := &proxy.Proxy{
+		Kind:       "pods",
+		Namespace:  e.Spec.Proxy.Namespace,
+		KubeConfig: restConfig,
+		TLSConfig:  tlsConfig,
+		Port:       port,
+	}
+
+	return p, nil
+}
+
+// firstHealthyEndpoint returns the first of endpoints reporting its status.
+func (u *Utils) firstHealthyEndpoint(ctx context.Context, endpoints []string, tlsConfig *tls.Config, p *proxy.Proxy) (string, error) {
+	for _, endpoint := range endpoints {
+		err := probeEndpoint(ctx, endpoint, tlsConfig, p)
+		if err != nil {
+			u.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("etcd endpoint %s is not healthy", endpoint), "reason", err)
+			continue
+		}
+
+		return endpoint, nil
+	}
+
+	return "", microerror.Maskf(noHealthyEndpointError, "none of the endpoints %v is healthy", endpoints)
+}
+
+func probeEndpoint(ctx context.Context, endpoint string, tlsConfig *tls.Config, p *proxy.Proxy) error {
+	c, err := etcd.NewV3Client(endpoint, tlsConfig, p)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer c.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(ctx, endpointProbeLimit)
+	defer cancel()
+
+	_, err = c.Status(ctx, endpoint)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package giantnetes
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/k8sclient/v8/pkg/k8sclienttest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	backupv1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+)
+
+func Test_TimeoutsFromPolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   backupv1alpha1.ETCDEndpointBackupPolicy
+		expected Timeouts
+	}{
+		{
+			name:     "case 0: no timeouts",
+			policy:   backupv1alpha1.ETCDEndpointBackupPolicy{},
+			expected: Timeouts{},
+		},
+		{
+			name: "case 1: all stages set",
+			policy: backupv1alpha1.ETCDEndpointBackupPolicy{
+				Timeouts: &backupv1alpha1.ETCDEndpointTimeouts{
+					Create:  &metav1.Duration{Duration: 15 * time.Minute},
+					Encrypt: &metav1.Duration{Duration: 90 * time.Second},
+					Upload:  &metav1.Duration{Duration: time.Hour},
+				},
+			},
+			expected: Timeouts{
+				Create:  15 * time.Minute,
+				Encrypt: 90 * time.Second,
+				Upload:  time.Hour,
+			},
+		},
+		{
+			name: "case 2: single stage set",
+			policy: backupv1alpha1.ETCDEndpointBackupPolicy{
+				Timeouts: &backupv1alpha1.ETCDEndpointTimeouts{
+					Upload: &metav1.Duration{Duration: 5 * time.Minute},
+				},
+			},
+			expected: Timeouts{
+				Upload: 5 * time.Minute,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			timeouts := TimeoutsFromPolicy(tc.policy)
+
+			if !cmp.Equal(timeouts, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, timeouts))
+			}
+		})
+	}
+}
+
+func Test_getEndpointProxy(t *testing.T) {
+	kubeconfig := []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: vault
+  cluster:
+    server: https://api.vault.example.com
+contexts:
+- name: vault
+  context:
+    cluster: vault
+    user: vault
+current-context: vault
+users:
+- name: vault
+  user:
+    token: token
+`)
+
+	testCases := []struct {
+		name              string
+		proxy             *backupv1alpha1.ETCDEndpointProxy
+		expectedNamespace string
+		expectedHost      string
+		errorMatcher      func(error) bool
+	}{
+		{
+			name: "case 0: no proxy",
+		},
+		{
+			name:              "case 1: proxy through the management cluster in the namespace of the ETCDEndpoint",
+			proxy:             &backupv1alpha1.ETCDEndpointProxy{Namespace: "org-acme"},
+			expectedNamespace: "org-acme",
+			expectedHost:      "https://api.management.example.com",
+		},
+		{
+			name:         "case 2: proxy through the management cluster in another namespace",
+			proxy:        &backupv1alpha1.ETCDEndpointProxy{Namespace: "kube-system"},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 3: proxy through a referenced cluster in another namespace",
+			proxy: &backupv1alpha1.ETCDEndpointProxy{
+				KubeconfigSecretRef: &backupv1alpha1.SecretKeyReference{Name: "vault-kubeconfig"},
+				Namespace:           "vault",
+			},
+			expectedNamespace: "vault",
+			expectedHost:      "https://api.vault.example.com",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "org-acme", Name: "vault-kubeconfig"},
+				Data:       map[string][]byte{kubeconfigKey: kubeconfig},
+			}
+			u := &Utils{
+				logger: microloggertest.New(),
+				K8sClient: k8sclienttest.NewClients(k8sclienttest.ClientsConfig{
+					CtrlClient: fake.NewClientBuilder().WithObjects(secret).Build(),
+					RestConfig: &rest.Config{Host: "https://api.management.example.com"},
+				}),
+			}
+			e := backupv1alpha1.ETCDEndpoint{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "org-acme", Name: "vault"},
+				Spec:       backupv1alpha1.ETCDEndpointSpec{Proxy: tc.proxy},
+			}
+
+			p, err := u.getEndpointProxy(context.Background(), e, nil)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.expectedHost == "" {
+				if p != nil {
+					t.Fatalf("proxy == %#v, want nil", p)
+				}
+				return
+			}
+			if p.Namespace != tc.expectedNamespace {
+				t.Fatalf("namespace == %q, want %q", p.Namespace, tc.expectedNamespace)
+			}
+			if p.KubeConfig.Host != tc.expectedHost {
+				t.Fatalf("host == %q, want %q", p.KubeConfig.Host, tc.expectedHost)
+			}
+		})
+	}
+}
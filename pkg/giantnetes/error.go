@@ -36,3 +36,75 @@ var unableToGetTenantClustersError = &microerror.Error{
 func IsUnableToGetTenantClusters(err error) bool {
 	return microerror.Cause(err) == unableToGetTenantClustersError
 }
+
+var missingTLSSecretError = &microerror.Error{
+	Kind: "missingTLSSecretError",
+}
+
+// IsMissingTLSSecret asserts missingTLSSecretError.
+func IsMissingTLSSecret(err error) bool {
+	return microerror.Cause(err) == missingTLSSecretError
+}
+
+var invalidTLSSecretError = &microerror.Error{
+	Kind: "invalidTLSSecretError",
+}
+
+// IsInvalidTLSSecret asserts invalidTLSSecretError.
+func IsInvalidTLSSecret(err error) bool {
+	return microerror.Cause(err) == invalidTLSSecretError
+}
+
+var kubeconfigUnavailableError = &microerror.Error{
+	Kind: "kubeconfigUnavailableError",
+}
+
+// IsKubeconfigUnavailable asserts kubeconfigUnavailableError.
+func IsKubeconfigUnavailable(err error) bool {
+	return microerror.Cause(err) == kubeconfigUnavailableError
+}
+
+var noEtcdPodsError = &microerror.Error{
+	Kind: "noEtcdPodsError",
+}
+
+// IsNoEtcdPods asserts noEtcdPodsError.
+func IsNoEtcdPods(err error) bool {
+	return microerror.Cause(err) == noEtcdPodsError
+}
+
+var noHealthyEndpointError = &microerror.Error{
+	Kind: "noHealthyEndpointError",
+}
+
+// IsNoHealthyEndpoint asserts noHealthyEndpointError.
+func IsNoHealthyEndpoint(err error) bool {
+	return microerror.Cause(err) == noHealthyEndpointError
+}
+
+var unsupportedDatastoreError = &microerror.Error{
+	Kind: "unsupportedDatastoreError",
+}
+
+// IsUnsupportedDatastore asserts unsupportedDatastoreError.
+func IsUnsupportedDatastore(err error) bool {
+	return microerror.Cause(err) == unsupportedDatastoreError
+}
+
+var noControlPlaneNodesError = &microerror.Error{
+	Kind: "noControlPlaneNodesError",
+}
+
+// IsNoControlPlaneNodes asserts noControlPlaneNodesError.
+func IsNoControlPlaneNodes(err error) bool {
+	return microerror.Cause(err) == noControlPlaneNodesError
+}
+
+var clusterNotFoundError = &microerror.Error{
+	Kind: "clusterNotFoundError",
+}
+
+// IsClusterNotFound asserts clusterNotFoundError.
+func IsClusterNotFound(err error) bool {
+	return microerror.Cause(err) == clusterNotFoundError
+}
@@ -0,0 +1,60 @@
+package giantnetes
+
+import (
+	"fmt"
+)
+
+const (
+	// Reasons why a discovered cluster can not be backed up.
+	FailureReasonUnknownProvider       = "UnknownProvider"
+	FailureReasonClusterCheckFailed    = "ClusterCheckFailed"
+	FailureReasonInvalidConfig         = "InvalidConfig"
+	FailureReasonMissingTLSSecret      = "MissingTLSSecret"
+	FailureReasonInvalidTLSSecret      = "InvalidTLSSecret"
+	FailureReasonTLSUnavailable        = "TLSUnavailable"
+	FailureReasonKubeconfigUnavailable = "KubeconfigUnavailable"
+	FailureReasonNoEtcdPods            = "NoEtcdPods"
+	FailureReasonNoHealthyEndpoint     = "NoHealthyEndpoint"
+	FailureReasonNoControlPlaneNodes   = "NoControlPlaneNodes"
+	FailureReasonUnsupportedDatastore  = "UnsupportedDatastore"
+	FailureReasonEndpointUnavailable   = "EndpointUnavailable"
+	FailureReasonProxyUnavailable      = "ProxyUnavailable"
+)
+
+// DiscoveryFailure explains why a discovered cluster can not be backed up.
+type DiscoveryFailure struct {
+	// Reason is one of the FailureReason constants.
+	Reason  string
+	Message string
+}
+
+func (f DiscoveryFailure) String() string {
+	return fmt.Sprintf("%s: %s", f.Reason, f.Message)
+}
+
+// newDiscoveryFailure classifies err, which happened while preparing the
+// backup of a cluster. fallback is the reason of errors which are not
+// classified.
+func newDiscoveryFailure(err error, fallback string) *DiscoveryFailure {
+	reason := fallback
+	switch {
+	case IsMissingTLSSecret(err):
+		reason = FailureReasonMissingTLSSecret
+	case IsInvalidTLSSecret(err):
+		reason = FailureReasonInvalidTLSSecret
+	case IsKubeconfigUnavailable(err):
+		reason = FailureReasonKubeconfigUnavailable
+	case IsNoEtcdPods(err):
+		reason = FailureReasonNoEtcdPods
+	case IsNoHealthyEndpoint(err):
+		reason = FailureReasonNoHealthyEndpoint
+	case IsNoControlPlaneNodes(err):
+		reason = FailureReasonNoControlPlaneNodes
+	case IsUnsupportedDatastore(err):
+		reason = FailureReasonUnsupportedDatastore
+	case IsInvalidConfig(err):
+		reason = FailureReasonInvalidConfig
+	}
+
+	return &DiscoveryFailure{Reason: reason, Message: err.Error()}
+}
@@ -0,0 +1,64 @@
+package giantnetes
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+)
+
+func Test_newDiscoveryFailure(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		fallback string
+		expected string
+	}{
+		{
+			name:     "case 0: missing TLS secret",
+			err:      microerror.Maskf(missingTLSSecretError, "secret not found"),
+			fallback: FailureReasonTLSUnavailable,
+			expected: FailureReasonMissingTLSSecret,
+		},
+		{
+			name:     "case 1: masked kubeconfig error",
+			err:      microerror.Mask(microerror.Maskf(kubeconfigUnavailableError, "secret not found")),
+			fallback: FailureReasonEndpointUnavailable,
+			expected: FailureReasonKubeconfigUnavailable,
+		},
+		{
+			name:     "case 2: no etcd pods",
+			err:      microerror.Maskf(noEtcdPodsError, "no pods"),
+			fallback: FailureReasonEndpointUnavailable,
+			expected: FailureReasonNoEtcdPods,
+		},
+		{
+			name:     "case 3: unclassified error",
+			err:      microerror.Maskf(executionFailedError, "timeout"),
+			fallback: FailureReasonProxyUnavailable,
+			expected: FailureReasonProxyUnavailable,
+		},
+		{
+			name:     "case 4: plain error",
+			err:      errors.New("timeout"),
+			fallback: FailureReasonClusterCheckFailed,
+			expected: FailureReasonClusterCheckFailed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			f := newDiscoveryFailure(tc.err, tc.fallback)
+
+			if f.Reason != tc.expected {
+				t.Fatalf("reason == %#q, want %#q", f.Reason, tc.expected)
+			}
+			if f.Message != tc.err.Error() {
+				t.Fatalf("message == %#q, want %#q", f.Message, tc.err.Error())
+			}
+		})
+	}
+}
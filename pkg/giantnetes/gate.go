@@ -0,0 +1,157 @@
+package giantnetes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kcp "sigs.k8s.io/cluster-api/api/controlplane/kubeadm/v1beta2"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ForceBackupAnnotation forces ("true") the backup of clusters whose
+	// control plane is not ready to be backed up, e.g. while it is rolling
+	// out. It is set on the ETCDBackup.
+	ForceBackupAnnotation = "giantswarm.io/etcd-backup-operator-force"
+
+	// Reasons why the backup of a cluster is deferred.
+	DeferralReasonControlPlaneEndpointNotSet = "ControlPlaneEndpointNotSet"
+	DeferralReasonEtcdClusterNotHealthy      = "EtcdClusterNotHealthy"
+	DeferralReasonRollingOut                 = "RollingOut"
+	DeferralReasonScalingUp                  = "ScalingUp"
+	DeferralReasonScalingDown                = "ScalingDown"
+	DeferralReasonRemediating                = "Remediating"
+	DeferralReasonReplicasNotUpToDate        = "ReplicasNotUpToDate"
+)
+
+// Deferral explains why the backup of a cluster is deferred.
+type Deferral struct {
+	// Reason is one of the DeferralReason constants.
+	Reason  string
+	Message string
+}
+
+func (d Deferral) String() string {
+	return fmt.Sprintf("backup deferred (%s): %s", d.Reason, d.Message)
+}
+
+// ForceBackupFromAnnotations parses the annotation forcing backups of
+// clusters whose control plane is not ready. Backups are not forced when the
+// annotation is not set.
+func ForceBackupFromAnnotations(annotations map[string]string) (bool, error) {
+	v, ok := annotations[ForceBackupAnnotation]
+	if !ok || v == "" {
+		return false, nil
+	}
+
+	force, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, microerror.Maskf(invalidConfigError, "annotation %#q has invalid value %#q", ForceBackupAnnotation, v)
+	}
+
+	return force, nil
+}
+
+// ControlPlaneDeferral checks whether the control plane of a CAPI workload
+// cluster can be backed up. It returns why the backup is deferred, or nil
+// when it can be taken. Only the control plane endpoint is checked for
+// control planes which are not KubeadmControlPlanes, and instances which are
+// not CAPI clusters are never deferred.
+func (u *Utils) ControlPlaneDeferral(ctx context.Context, instance ETCDInstance) (*Deferral, error) {
+	if instance.cluster == nil {
+		return nil, nil
+	}
+	c, ok := instance.cluster.object.(*capi.Cluster)
+	if !ok {
+		return nil, nil
+	}
+
+	var controlPlane *kcp.KubeadmControlPlane
+	if c.Spec.ControlPlaneRef.Kind == "KubeadmControlPlane" {
+		obj, err := u.getObject(ctx, schema.GroupKind{Group: kcp.GroupVersion.Group, Kind: c.Spec.ControlPlaneRef.Kind}, client.ObjectKey{Namespace: c.Namespace, Name: c.Spec.ControlPlaneRef.Name})
+		if err != nil {
+			return nil, microerror.Maskf(executionFailedError, "error getting KubeadmControlPlane of cluster %#q with error %#q", c.Name, err)
+		}
+
+		controlPlane = &kcp.KubeadmControlPlane{}
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, controlPlane)
+		if err != nil {
+			return nil, microerror.Maskf(executionFailedError, "invalid KubeadmControlPlane of cluster %#q with error %#q", c.Name, err)
+		}
+	}
+
+	return controlPlaneDeferral(c, controlPlane), nil
+}
+
+// controlPlaneDeferral returns why the backup of c is deferred given its
+// KubeadmControlPlane, which is nil for other control planes.
+func controlPlaneDeferral(c *capi.Cluster, controlPlane *kcp.KubeadmControlPlane) *Deferral {
+	// The etcd pods are reached through the API server of the cluster.
+	if !c.Spec.ControlPlaneEndpoint.IsValid() {
+		return &Deferral{Reason: DeferralReasonControlPlaneEndpointNotSet, Message: fmt.Sprintf("control plane endpoint of cluster %#q is not set", c.Name)}
+	}
+
+	if controlPlane == nil {
+		return nil
+	}
+
+	conditions := controlPlane.Status.Conditions
+	if meta.IsStatusConditionFalse(conditions, kcp.KubeadmControlPlaneEtcdClusterHealthyCondition) {
+		return &Deferral{Reason: DeferralReasonEtcdClusterNotHealthy, Message: conditionMessage(conditions, kcp.KubeadmControlPlaneEtcdClusterHealthyCondition, "etcd cluster is not healthy")}
+	}
+
+	// Members are added and removed while the control plane changes, and
+	// snapshots may be taken from a member being removed.
+	for _, progress := range []struct {
+		condition string
+		reason    string
+	}{
+		{condition: kcp.KubeadmControlPlaneRollingOutCondition, reason: DeferralReasonRollingOut},
+		{condition: kcp.KubeadmControlPlaneScalingUpCondition, reason: DeferralReasonScalingUp},
+		{condition: kcp.KubeadmControlPlaneScalingDownCondition, reason: DeferralReasonScalingDown},
+		{condition: kcp.KubeadmControlPlaneRemediatingCondition, reason: DeferralReasonRemediating},
+	} {
+		if meta.IsStatusConditionTrue(conditions, progress.condition) {
+			return &Deferral{Reason: progress.reason, Message: conditionMessage(conditions, progress.condition, fmt.Sprintf("control plane is %s", progress.condition))}
+		}
+	}
+
+	// Controllers not reporting the conditions above still report the
+	// replicas.
+	if controlPlane.Spec.Replicas != nil {
+		desired := *controlPlane.Spec.Replicas
+		for _, replicas := range []*int32{controlPlane.Status.Replicas, controlPlane.Status.UpToDateReplicas, controlPlane.Status.ReadyReplicas} {
+			if replicas != nil && *replicas != desired {
+				return &Deferral{Reason: DeferralReasonReplicasNotUpToDate, Message: fmt.Sprintf("%d replicas desired, %d replicas, %d up to date, %d ready", desired, int32Value(controlPlane.Status.Replicas), int32Value(controlPlane.Status.UpToDateReplicas), int32Value(controlPlane.Status.ReadyReplicas))}
+			}
+		}
+	}
+
+	return nil
+}
+
+// conditionMessage returns the message of the condition conditionType, or
+// fallback when it has none.
+func conditionMessage(conditions []metav1.Condition, conditionType string, fallback string) string {
+	c := meta.FindStatusCondition(conditions, conditionType)
+	if c == nil || c.Message == "" {
+		return fallback
+	}
+
+	return c.Message
+}
+
+func int32Value(v *int32) int32 {
+	if v == nil {
+		return 0
+	}
+
+	return *v
+}
@@ -0,0 +1,156 @@
+package giantnetes
+
+import (
+	"strconv"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kcp "sigs.k8s.io/cluster-api/api/controlplane/kubeadm/v1beta2"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+)
+
+func Test_controlPlaneDeferral(t *testing.T) {
+	cluster := &capi.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "abc12"},
+		Spec: capi.ClusterSpec{
+			ControlPlaneEndpoint: capi.APIEndpoint{Host: "api.abc12.example.com", Port: 443},
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		cluster      *capi.Cluster
+		controlPlane *kcp.KubeadmControlPlane
+		expected     string
+	}{
+		{
+			name:         "case 0: control plane endpoint not set",
+			cluster:      &capi.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "abc12"}},
+			controlPlane: nil,
+			expected:     DeferralReasonControlPlaneEndpointNotSet,
+		},
+		{
+			name:         "case 1: other control plane",
+			cluster:      cluster,
+			controlPlane: nil,
+			expected:     "",
+		},
+		{
+			name:         "case 2: healthy control plane",
+			cluster:      cluster,
+			controlPlane: newKCP(3, 3, metav1.Condition{Type: kcp.KubeadmControlPlaneEtcdClusterHealthyCondition, Status: metav1.ConditionTrue}),
+			expected:     "",
+		},
+		{
+			name:         "case 3: etcd cluster not healthy",
+			cluster:      cluster,
+			controlPlane: newKCP(3, 3, metav1.Condition{Type: kcp.KubeadmControlPlaneEtcdClusterHealthyCondition, Status: metav1.ConditionFalse}),
+			expected:     DeferralReasonEtcdClusterNotHealthy,
+		},
+		{
+			name:         "case 4: etcd cluster health unknown",
+			cluster:      cluster,
+			controlPlane: newKCP(3, 3, metav1.Condition{Type: kcp.KubeadmControlPlaneEtcdClusterHealthyCondition, Status: metav1.ConditionUnknown}),
+			expected:     "",
+		},
+		{
+			name:         "case 5: rolling out",
+			cluster:      cluster,
+			controlPlane: newKCP(3, 3, metav1.Condition{Type: kcp.KubeadmControlPlaneRollingOutCondition, Status: metav1.ConditionTrue}),
+			expected:     DeferralReasonRollingOut,
+		},
+		{
+			name:         "case 6: scaling down",
+			cluster:      cluster,
+			controlPlane: newKCP(3, 4, metav1.Condition{Type: kcp.KubeadmControlPlaneScalingDownCondition, Status: metav1.ConditionTrue}),
+			expected:     DeferralReasonScalingDown,
+		},
+		{
+			name:         "case 7: replicas not up to date without conditions",
+			cluster:      cluster,
+			controlPlane: newKCP(3, 4),
+			expected:     DeferralReasonReplicasNotUpToDate,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			deferral := controlPlaneDeferral(tc.cluster, tc.controlPlane)
+
+			var reason string
+			if deferral != nil {
+				reason = deferral.Reason
+			}
+			if reason != tc.expected {
+				t.Fatalf("reason == %#q, want %#q", reason, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_ForceBackupFromAnnotations(t *testing.T) {
+	testCases := []struct {
+		name         string
+		annotations  map[string]string
+		expected     bool
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: no annotation",
+			annotations:  nil,
+			expected:     false,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: forced",
+			annotations:  map[string]string{ForceBackupAnnotation: "true"},
+			expected:     true,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 2: invalid value",
+			annotations:  map[string]string{ForceBackupAnnotation: "always"},
+			expected:     false,
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			force, err := ForceBackupFromAnnotations(tc.annotations)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if force != tc.expected {
+				t.Fatalf("force == %t, want %t", force, tc.expected)
+			}
+		})
+	}
+}
+
+// newKCP returns a KubeadmControlPlane with desired replicas of which
+// replicas exist and are up to date and ready.
+func newKCP(desired int32, replicas int32, conditions ...metav1.Condition) *kcp.KubeadmControlPlane {
+	return &kcp.KubeadmControlPlane{
+		Spec: kcp.KubeadmControlPlaneSpec{Replicas: &desired},
+		Status: kcp.KubeadmControlPlaneStatus{
+			Conditions:       conditions,
+			Replicas:         &replicas,
+			UpToDateReplicas: &replicas,
+			ReadyReplicas:    &replicas,
+		},
+	}
+}
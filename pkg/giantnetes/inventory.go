@@ -0,0 +1,88 @@
+package giantnetes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// inventoryKind is a kind exported into the inventory of the management
+// cluster.
+type inventoryKind struct {
+	schema.GroupKind
+	// cluster is set for kinds representing a workload cluster, whose
+	// secrets are exported too.
+	cluster bool
+	capi    bool
+}
+
+var inventoryKinds = []inventoryKind{
+	{GroupKind: schema.GroupKind{Group: "cluster.x-k8s.io", Kind: "Cluster"}, cluster: true, capi: true},
+	{GroupKind: schema.GroupKind{Group: "cluster.x-k8s.io", Kind: "MachineDeployment"}},
+	{GroupKind: schema.GroupKind{Group: "controlplane.cluster.x-k8s.io", Kind: "KubeadmControlPlane"}},
+	{GroupKind: schema.GroupKind{Group: "infrastructure.giantswarm.io", Kind: "AWSCluster"}, cluster: true},
+	{GroupKind: schema.GroupKind{Group: "provider.giantswarm.io", Kind: "AzureConfig"}, cluster: true},
+	{GroupKind: schema.GroupKind{Group: "provider.giantswarm.io", Kind: "KVMConfig"}, cluster: true},
+}
+
+// Inventory returns the CAPI and provider objects of the management cluster
+// and the certificate and kubeconfig secrets of the workload clusters they
+// represent. Kinds whose CRD is not installed are left out.
+func (u *Utils) Inventory(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	ctrlClient := u.K8sClient.CtrlClient()
+
+	var inventory []*unstructured.Unstructured
+	seen := map[string]bool{}
+	add := func(obj *unstructured.Unstructured) {
+		k := obj.GroupVersionKind().GroupKind().String() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+		if !seen[k] {
+			seen[k] = true
+			inventory = append(inventory, obj)
+		}
+	}
+
+	for _, kind := range inventoryKinds {
+		mapping, err := ctrlClient.RESTMapper().RESTMapping(kind.GroupKind)
+		if meta.IsNoMatchError(err) {
+			u.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Skipping %s in inventory, its CRD is not installed", kind.GroupKind))
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(mapping.GroupVersionKind.GroupVersion().WithKind(mapping.GroupVersionKind.Kind + "List"))
+		err = ctrlClient.List(ctx, list)
+		if err != nil {
+			return nil, microerror.Maskf(executionFailedError, "error listing %s with error %#q", kind.GroupKind, err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			add(obj)
+
+			if !kind.cluster {
+				continue
+			}
+
+			secrets, err := u.clusterSecrets(ctx, client.ObjectKeyFromObject(obj), kind.capi)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			for _, s := range secrets {
+				converted, err := toUnstructured(u.K8sClient.Scheme(), s)
+				if err != nil {
+					return nil, microerror.Mask(err)
+				}
+				add(converted)
+			}
+		}
+	}
+
+	return inventory, nil
+}
@@ -0,0 +1,66 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// ClusterProvider discovers the workload clusters of one infrastructure
+// provider and prepares what is needed to connect to their etcd.
+type ClusterProvider interface {
+	// Name identifies the provider. It is stored in the clusters it lists.
+	Name() string
+	// List returns the clusters of the provider which can be backed up, i.e.
+	// which are not being deleted. An error matching isMissingCRDError means
+	// the provider is not installed.
+	List(ctx context.Context) ([]Cluster, error)
+	// IsSkipped returns whether the backup of cluster is disabled.
+	IsSkipped(ctx context.Context, cluster Cluster) (bool, error)
+	// IsSupported returns whether cluster is recent enough to be backed up.
+	IsSupported(ctx context.Context, cluster Cluster) (bool, error)
+	// TLSConfig returns the client TLS configuration for the etcd of
+	// cluster, which does not verify the server certificate if insecure is
+	// set.
+	TLSConfig(ctx context.Context, cluster Cluster, insecure bool) (*tls.Config, error)
+	// Endpoints returns the etcd endpoint of cluster to back up.
+	Endpoints(ctx context.Context, cluster Cluster) (string, error)
+	// Dialer returns the proxy to reach the etcd of cluster, or nil when its
+	// endpoint is dialed directly.
+	Dialer(ctx context.Context, cluster Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error)
+}
+
+// sharedProvider is implemented by the providers of clusters whose etcd may
+// be shared with other clusters.
+type sharedProvider interface {
+	// Instance returns the name of the instance backing up the etcd of
+	// cluster and whether it is shared, in which case the name is the same
+	// for all clusters sharing it. The name of cluster is returned when its
+	// etcd is not shared.
+	Instance(ctx context.Context, cluster Cluster) (string, bool, error)
+}
+
+// newClusterProviders returns the providers whose workload clusters are
+// backed up. Clusters are listed in this order.
+func newClusterProviders(u *Utils) []ClusterProvider {
+	return []ClusterProvider{
+		&awsCAPIProvider{u: u},
+		&azureProvider{u: u},
+		&kvmProvider{u: u},
+		&capiProvider{u: u, discoveries: newEtcdDiscoveries(u)},
+	}
+}
+
+// clusterProvider returns the provider which listed cluster.
+func (u *Utils) clusterProvider(cluster Cluster) (ClusterProvider, error) {
+	for _, p := range u.providers {
+		if p.Name() == cluster.provider {
+			return p, nil
+		}
+	}
+
+	return nil, microerror.Maskf(invalidConfigError, "unknown provider %#q of cluster %#q", cluster.provider, cluster.clusterKey.Name)
+}
@@ -0,0 +1,90 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/giantswarm/apiextensions/v6/pkg/apis/infrastructure/v1alpha3"
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// awsCAPIProvider discovers the clusters of the Giant Swarm AWS provider,
+// which are represented by AWSClusters.
+type awsCAPIProvider struct {
+	u *Utils
+}
+
+func (p *awsCAPIProvider) Name() string {
+	return awsCAPI
+}
+
+func (p *awsCAPIProvider) List(ctx context.Context) ([]Cluster, error) {
+	var clusters []Cluster
+	crdList := v1alpha3.AWSClusterList{}
+	err := p.u.listPages(ctx, &crdList, func() {
+		for _, awsClusterObj := range crdList.Items {
+			// Only backup cluster if it was not marked for delete.
+			if awsClusterObj.DeletionTimestamp == nil {
+				clusters = append(clusters, Cluster{clusterKey: client.ObjectKey{Name: awsClusterObj.Name, Namespace: awsClusterObj.Namespace}, provider: awsCAPI, annotations: awsClusterObj.Annotations, object: &awsClusterObj})
+			}
+		}
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return clusters, nil
+}
+
+func (p *awsCAPIProvider) IsSkipped(ctx context.Context, cluster Cluster) (bool, error) {
+	crd, err := p.get(ctx, cluster)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return crd.Annotations[skipEtcdBackupAnnotation] == "true", nil
+}
+
+func (p *awsCAPIProvider) IsSupported(ctx context.Context, cluster Cluster) (bool, error) {
+	// Cluster API AWS backups are always supported.
+	return true, nil
+}
+
+func (p *awsCAPIProvider) TLSConfig(ctx context.Context, cluster Cluster, insecure bool) (*tls.Config, error) {
+	t, err := p.u.getLegacyEtcdTLSCfg(ctx, cluster.clusterKey, insecure)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return t, nil
+}
+
+func (p *awsCAPIProvider) Endpoints(ctx context.Context, cluster Cluster) (string, error) {
+	crd, err := p.get(ctx, cluster)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	if crd.Spec.Cluster.DNS.Domain == "" {
+		return "", microerror.Maskf(executionFailedError, "awscluster %#q does not have any cluster domain set in spec.cluster.dns.domain", cluster.clusterKey.Name)
+	}
+
+	return AwsCAPIEtcdEndpoint(cluster.clusterKey.Name, crd.Spec.Cluster.DNS.Domain), nil
+}
+
+func (p *awsCAPIProvider) Dialer(ctx context.Context, cluster Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	// no proxy needed for legacy clusters
+	return nil, nil
+}
+
+func (p *awsCAPIProvider) get(ctx context.Context, cluster Cluster) (*v1alpha3.AWSCluster, error) {
+	crd := &v1alpha3.AWSCluster{}
+	err := p.u.K8sClient.CtrlClient().Get(ctx, cluster.clusterKey, crd)
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error getting aws crd for guest cluster %#q with error %#q", cluster.clusterKey.Name, err)
+	}
+
+	return crd, nil
+}
@@ -0,0 +1,101 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/coreos/go-semver/semver"
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/k8smetadata/pkg/label"
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// azureProvider discovers the clusters of the Giant Swarm Azure provider,
+// which are represented by AzureConfigs.
+type azureProvider struct {
+	u *Utils
+}
+
+func (p *azureProvider) Name() string {
+	return azure
+}
+
+func (p *azureProvider) List(ctx context.Context) ([]Cluster, error) {
+	var clusters []Cluster
+	crdList := providerv1alpha1.AzureConfigList{}
+	err := p.u.listPages(ctx, &crdList, func() {
+		for _, azureConfig := range crdList.Items {
+			// Only backup cluster if it was not marked for delete.
+			if azureConfig.DeletionTimestamp == nil {
+				clusters = append(clusters, Cluster{clusterKey: client.ObjectKey{Name: azureConfig.Name, Namespace: azureConfig.Namespace}, provider: azure, annotations: azureConfig.Annotations, object: &azureConfig})
+			}
+		}
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return clusters, nil
+}
+
+func (p *azureProvider) IsSkipped(ctx context.Context, cluster Cluster) (bool, error) {
+	return false, nil
+}
+
+func (p *azureProvider) IsSupported(ctx context.Context, cluster Cluster) (bool, error) {
+	crd, err := p.get(ctx, cluster)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	var version string
+	{
+		version = crd.Spec.VersionBundle.Version
+		if version == "" {
+			// CAPI clusters still have an AzureConfig, but they don't have the Spec.VersionBundle.Version field set.
+			// They save the version in a label.
+			version = crd.Labels[label.ReleaseVersion]
+		}
+	}
+	if version == "" {
+		return false, microerror.Maskf(executionFailedError, "failed to get cluster version from AzureConfig %#q", cluster.clusterKey.Name)
+	}
+
+	return stringVersionCmp(version, semver.New("0.0.0"), azureSupportFrom)
+}
+
+func (p *azureProvider) TLSConfig(ctx context.Context, cluster Cluster, insecure bool) (*tls.Config, error) {
+	t, err := p.u.getLegacyEtcdTLSCfg(ctx, cluster.clusterKey, insecure)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return t, nil
+}
+
+func (p *azureProvider) Endpoints(ctx context.Context, cluster Cluster) (string, error) {
+	crd, err := p.get(ctx, cluster)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return AzureEtcdEndpoint(crd.Spec.Cluster.Etcd.Domain), nil
+}
+
+func (p *azureProvider) Dialer(ctx context.Context, cluster Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	// no proxy needed for legacy clusters
+	return nil, nil
+}
+
+func (p *azureProvider) get(ctx context.Context, cluster Cluster) (*providerv1alpha1.AzureConfig, error) {
+	crd := &providerv1alpha1.AzureConfig{}
+	err := p.u.K8sClient.CtrlClient().Get(ctx, cluster.clusterKey, crd)
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error getting azure crd for guest cluster %#q with error %#q", cluster.clusterKey.Name, err)
+	}
+
+	return crd, nil
+}
@@ -0,0 +1,336 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// capiProvider discovers Cluster API clusters. How their etcd is reached
+// depends on the provider of their control plane.
+type capiProvider struct {
+	u *Utils
+
+	discoveries map[string]etcdDiscovery
+}
+
+// etcdDiscovery resolves the etcd of the CAPI clusters whose control plane is
+// of one kind.
+type etcdDiscovery interface {
+	// TLSSource returns the source of the etcd client certificates and the
+	// name expected in the server certificate, nil if it is the name dialed.
+	TLSSource(ctx context.Context, c *capi.Cluster) (certs.Source, certs.ServerName, error)
+	// Endpoints returns the etcd endpoint to back up.
+	Endpoints(ctx context.Context, c *capi.Cluster) (string, error)
+	// Dialer returns the proxy to reach etcd, or nil when the endpoint is
+	// dialed directly.
+	Dialer(ctx context.Context, c *capi.Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error)
+}
+
+// sharedDiscovery is implemented by the etcd discoveries of control planes
+// whose etcd may be shared by several clusters.
+type sharedDiscovery interface {
+	// Instance returns the name of the instance backing up the etcd of c,
+	// which is the same for all clusters sharing it.
+	Instance(ctx context.Context, c *capi.Cluster) (string, error)
+}
+
+// newEtcdDiscoveries returns the etcd discoveries by kind of control plane.
+// Clusters with other control planes are expected to run etcd as static pods
+// like kubeadm does.
+func newEtcdDiscoveries(u *Utils) map[string]etcdDiscovery {
+	return map[string]etcdDiscovery{
+		"KubeadmControlPlane":   &kubeadmDiscovery{u: u},
+		"KamajiControlPlane":    &kamajiDiscovery{u: u},
+		"K0smotronControlPlane": &k0smotronDiscovery{u: u},
+		"VCluster":              &vclusterDiscovery{u: u},
+	}
+}
+
+func (p *capiProvider) Name() string {
+	return CAPI
+}
+
+func (p *capiProvider) List(ctx context.Context) ([]Cluster, error) {
+	var clusters []Cluster
+	crdList := capi.ClusterList{}
+	err := p.u.listPages(ctx, &crdList, func() {
+		for _, cluster := range crdList.Items {
+			// Only backup cluster if it was not marked for delete.
+			// and if the control and infrastructure is ready
+			if cluster.DeletionTimestamp == nil &&
+				cluster.Status.Initialization.ControlPlaneInitialized != nil && *cluster.Status.Initialization.ControlPlaneInitialized &&
+				cluster.Status.Initialization.InfrastructureProvisioned != nil && *cluster.Status.Initialization.InfrastructureProvisioned {
+				clusters = append(clusters, Cluster{clusterKey: client.ObjectKey{Name: cluster.Name, Namespace: cluster.Namespace}, provider: CAPI, annotations: cluster.Annotations, object: &cluster})
+			}
+		}
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return clusters, nil
+}
+
+func (p *capiProvider) IsSkipped(ctx context.Context, cluster Cluster) (bool, error) {
+	return false, nil
+}
+
+func (p *capiProvider) IsSupported(ctx context.Context, cluster Cluster) (bool, error) {
+	// CAPI backups are always supported.
+	return true, nil
+}
+
+func (p *capiProvider) TLSConfig(ctx context.Context, cluster Cluster, insecure bool) (*tls.Config, error) {
+	c, d, err := p.discovery(cluster)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	source, serverName, err := d.TLSSource(ctx, c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	tlsConfig, err := p.u.secretTLSConfig(ctx, cluster.clusterKey.Name, source, serverName, insecure)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return tlsConfig, nil
+}
+
+func (p *capiProvider) Endpoints(ctx context.Context, cluster Cluster) (string, error) {
+	c, d, err := p.discovery(cluster)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	a, err := p.access(cluster, d)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	if a.Mode != AccessPortForward {
+		// All members are backed up from, named after their etcd pods.
+		nodes, err := p.u.controlPlaneNodes(ctx, c)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		return nodeEndpoints(nodes), nil
+	}
+
+	endpoint, err := d.Endpoints(ctx, c)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return endpoint, nil
+}
+
+func (p *capiProvider) Dialer(ctx context.Context, cluster Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	c, d, err := p.discovery(cluster)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	a, err := p.access(cluster, d)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if a.Mode != AccessPortForward {
+		pr, err := p.nodeDialer(ctx, c, a, tlsConfig)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return pr, nil
+	}
+
+	pr, err := d.Dialer(ctx, c, tlsConfig)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return pr, nil
+}
+
+// Instance returns the name of the instance backing up the etcd of cluster
+// and whether it may be shared, which depends on the control plane.
+func (p *capiProvider) Instance(ctx context.Context, cluster Cluster) (string, bool, error) {
+	c, d, err := p.discovery(cluster)
+	if err != nil {
+		return "", false, microerror.Mask(err)
+	}
+
+	s, ok := d.(sharedDiscovery)
+	if !ok {
+		return cluster.clusterKey.Name, false, nil
+	}
+
+	name, err := s.Instance(ctx, c)
+	if err != nil {
+		return "", false, microerror.Mask(err)
+	}
+
+	return name, true, nil
+}
+
+// discovery returns the CAPI cluster object of cluster and the etcd
+// discovery of its control plane.
+func (p *capiProvider) discovery(cluster Cluster) (*capi.Cluster, etcdDiscovery, error) {
+	c, ok := cluster.object.(*capi.Cluster)
+	if !ok {
+		return nil, nil, microerror.Maskf(invalidConfigError, "cluster %#q is not a CAPI cluster", cluster.clusterKey.Name)
+	}
+
+	d, ok := p.discoveries[c.Spec.ControlPlaneRef.Kind]
+	if !ok {
+		d = p.discoveries["KubeadmControlPlane"]
+	}
+
+	return c, d, nil
+}
+
+// access returns how the etcd of cluster is reached. Other access modes than
+// port-forwarding are only supported by kubeadm control planes, whose etcd
+// runs on the control plane nodes.
+func (p *capiProvider) access(cluster Cluster, d etcdDiscovery) (Access, error) {
+	if cluster.access != nil {
+		return *cluster.access, nil
+	}
+
+	a, err := AccessFromAnnotations(cluster.annotations)
+	if err != nil {
+		return Access{}, microerror.Mask(err)
+	}
+
+	_, kubeadm := d.(*kubeadmDiscovery)
+	if a.Mode != AccessPortForward && !kubeadm {
+		return Access{}, microerror.Maskf(invalidConfigError, "access %#q of cluster %#q is only supported with kubeadm control planes", a.Mode, cluster.clusterKey.Name)
+	}
+
+	return a, nil
+}
+
+// nodeDialer returns the proxy dialing the etcd members, or their agents, on
+// the addresses of the control plane nodes of c.
+func (p *capiProvider) nodeDialer(ctx context.Context, c *capi.Cluster, a Access, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	nodes, err := p.u.controlPlaneNodes(ctx, c)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	via, err := p.u.proxyURL(ctx, c, a)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	pr := &proxy.Proxy{
+		TLSConfig: tlsConfig,
+		Port:      2379,
+		Nodes:     nodes,
+		Via:       via,
+	}
+	if a.ViaAgent() {
+		pr.Port = a.AgentPort
+		pr.Agent = true
+		pr.Upload = a.Mode == AccessAgentUpload
+	}
+
+	return pr, nil
+}
+
+// kubeadmDiscovery reaches the etcd static pods of kubeadm control planes
+// through a port-forward of the API server of the workload cluster.
+type kubeadmDiscovery struct {
+	u *Utils
+}
+
+// TLSSource returns the etcd secret of the cluster. Its certificate is the
+// etcd CA of the cluster, which signs the server certificates of the members
+// and is presented as client certificate.
+func (d *kubeadmDiscovery) TLSSource(ctx context.Context, c *capi.Cluster) (certs.Source, certs.ServerName, error) {
+	source := certs.SecretSource{
+		Client: d.u.K8sClient.CtrlClient(),
+		Secret: client.ObjectKey{
+			Namespace: c.Namespace,
+			Name:      fmt.Sprintf("%s-etcd", c.Name),
+		},
+		CAKey:   secret.TLSCrtDataName,
+		CertKey: secret.TLSCrtDataName,
+		KeyKey:  secret.TLSKeyDataName,
+	}
+
+	// The etcd pods are dialed by name through the port-forward proxy.
+	return source, EtcdPodServerName, nil
+}
+
+// Endpoints returns the name of an etcd pod of the workload cluster, read
+// from the informer of the cached workload cluster.
+func (d *kubeadmDiscovery) Endpoints(ctx context.Context, c *capi.Cluster) (string, error) {
+	w, err := d.u.cache.workloadCluster(ctx, d.u.logger, d.u.K8sClient.CtrlClient(), client.ObjectKeyFromObject(c))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	podList := v1.PodList{}
+	err = w.etcdPods.List(ctx, &podList, client.InNamespace(metav1.NamespaceSystem), client.MatchingLabels{EtcdLabelComponentKey: EtcdLabelComponentValue, EtcdLabelTierKey: EtcdLabelTierValue})
+	if err != nil {
+		return "", microerror.Maskf(executionFailedError, "error listing etcd pods of CAPI cluster %#q with error %#q", c.Name, err)
+	}
+
+	if len(podList.Items) == 0 {
+		return "", microerror.Maskf(noEtcdPodsError, "error getting etcd endpoint, no etcd pods found in cluster %#q", c.Name)
+	}
+
+	return podList.Items[0].Name, nil
+}
+
+func (d *kubeadmDiscovery) Dialer(ctx context.Context, c *capi.Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	w, err := d.u.cache.workloadCluster(ctx, d.u.logger, d.u.K8sClient.CtrlClient(), client.ObjectKeyFromObject(c))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	p := &proxy.Proxy{
+		Kind:       "pods",
+		Namespace:  metav1.NamespaceSystem,
+		KubeConfig: rest.CopyConfig(w.restConfig),
+		TLSConfig:  tlsConfig,
+		Port:       2379,
+	}
+
+	return p, nil
+}
+
+// getObject reads an object whose kind is looked up with the REST mapper, for
+// kinds of providers whose Go types are not vendored.
+func (u *Utils) getObject(ctx context.Context, gk schema.GroupKind, objectKey client.ObjectKey) (*unstructured.Unstructured, error) {
+	mapping, err := u.K8sClient.CtrlClient().RESTMapper().RESTMapping(gk)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(mapping.GroupVersionKind)
+	err = u.K8sClient.CtrlClient().Get(ctx, objectKey, obj)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,47 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// k0smotronDiscovery reaches the etcd of k0smotron control planes, which runs
+// as the StatefulSet kmc-<name>-etcd in the management cluster and is dialed
+// through its service.
+type k0smotronDiscovery struct {
+	u *Utils
+}
+
+// TLSSource returns the etcd secret of the cluster, which holds the etcd CA
+// like the one of kubeadm control planes.
+func (d *k0smotronDiscovery) TLSSource(ctx context.Context, c *capi.Cluster) (certs.Source, certs.ServerName, error) {
+	source := certs.SecretSource{
+		Client: d.u.K8sClient.CtrlClient(),
+		Secret: client.ObjectKey{
+			Namespace: c.Namespace,
+			Name:      fmt.Sprintf("%s-etcd", c.Name),
+		},
+		CAKey:   secret.TLSCrtDataName,
+		CertKey: secret.TLSCrtDataName,
+		KeyKey:  secret.TLSKeyDataName,
+	}
+
+	// The service is dialed by the DNS name in the certificate.
+	return source, nil, nil
+}
+
+func (d *k0smotronDiscovery) Endpoints(ctx context.Context, c *capi.Cluster) (string, error) {
+	return fmt.Sprintf("https://kmc-%s-etcd.%s.svc:2379", c.Spec.ControlPlaneRef.Name, c.Namespace), nil
+}
+
+func (d *k0smotronDiscovery) Dialer(ctx context.Context, c *capi.Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	return nil, nil
+}
@@ -0,0 +1,153 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+const (
+	kamajiDriverEtcd = "etcd"
+)
+
+var (
+	kamajiTenantControlPlane = schema.GroupKind{Group: "kamaji.clastix.io", Kind: "TenantControlPlane"}
+	kamajiDataStore          = schema.GroupKind{Group: "kamaji.clastix.io", Kind: "DataStore"}
+)
+
+// kamajiDiscovery reaches the etcd DataStore of Kamaji control planes, which
+// runs in the management cluster and is dialed directly. A DataStore may be
+// shared by several clusters, so it is backed up once by an instance named
+// after it.
+type kamajiDiscovery struct {
+	u *Utils
+}
+
+func (d *kamajiDiscovery) TLSSource(ctx context.Context, c *capi.Cluster) (certs.Source, certs.ServerName, error) {
+	ds, err := d.dataStore(ctx, c)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	source := certs.SecretKeysSource{Client: d.u.K8sClient.CtrlClient()}
+	for _, ref := range []struct {
+		key    *certs.SecretKey
+		fields []string
+	}{
+		{&source.CA, []string{"spec", "tlsConfig", "certificateAuthority", "certificate"}},
+		{&source.Cert, []string{"spec", "tlsConfig", "clientCertificate", "certificate"}},
+		{&source.Key, []string{"spec", "tlsConfig", "clientCertificate", "privateKey"}},
+	} {
+		*ref.key, err = kamajiSecretKey(ds, ref.fields...)
+		if err != nil {
+			return nil, nil, microerror.Mask(err)
+		}
+	}
+
+	// The DataStore endpoints are dialed by the DNS name in their
+	// certificate.
+	return source, nil, nil
+}
+
+// Endpoints returns the endpoints of the DataStore, comma separated.
+func (d *kamajiDiscovery) Endpoints(ctx context.Context, c *capi.Cluster) (string, error) {
+	ds, err := d.dataStore(ctx, c)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	endpoints, err := kamajiEndpoints(ds)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return endpoints, nil
+}
+
+func (d *kamajiDiscovery) Dialer(ctx context.Context, c *capi.Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	return nil, nil
+}
+
+// Instance returns the name of the DataStore of c.
+func (d *kamajiDiscovery) Instance(ctx context.Context, c *capi.Cluster) (string, error) {
+	ds, err := d.dataStore(ctx, c)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return ds.GetName(), nil
+}
+
+// dataStore returns the DataStore of the TenantControlPlane of c, which is
+// named like its KamajiControlPlane.
+func (d *kamajiDiscovery) dataStore(ctx context.Context, c *capi.Cluster) (*unstructured.Unstructured, error) {
+	tcp, err := d.u.getObject(ctx, kamajiTenantControlPlane, client.ObjectKey{Namespace: c.Namespace, Name: c.Spec.ControlPlaneRef.Name})
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error getting TenantControlPlane of cluster %#q with error %#q", c.Name, err)
+	}
+
+	name, _, _ := unstructured.NestedString(tcp.Object, "status", "storage", "dataStoreName")
+	if name == "" {
+		name, _, _ = unstructured.NestedString(tcp.Object, "spec", "dataStore")
+	}
+	if name == "" {
+		return nil, microerror.Maskf(executionFailedError, "TenantControlPlane of cluster %#q has no DataStore", c.Name)
+	}
+
+	ds, err := d.u.getObject(ctx, kamajiDataStore, client.ObjectKey{Name: name})
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error getting DataStore %#q of cluster %#q with error %#q", name, c.Name, err)
+	}
+
+	driver, _, _ := unstructured.NestedString(ds.Object, "spec", "driver")
+	if driver != kamajiDriverEtcd {
+		return nil, microerror.Maskf(unsupportedDatastoreError, "DataStore %#q of cluster %#q uses driver %#q, only %#q can be backed up", name, c.Name, driver, kamajiDriverEtcd)
+	}
+
+	return ds, nil
+}
+
+// kamajiEndpoints returns the endpoints of ds as URLs, comma separated.
+// Endpoints without scheme are dialed with https.
+func kamajiEndpoints(ds *unstructured.Unstructured) (string, error) {
+	endpoints, _, err := unstructured.NestedStringSlice(ds.Object, "spec", "endpoints")
+	if err != nil {
+		return "", microerror.Maskf(executionFailedError, "invalid endpoints of DataStore %#q with error %#q", ds.GetName(), err)
+	}
+	if len(endpoints) == 0 {
+		return "", microerror.Maskf(executionFailedError, "DataStore %#q has no endpoints", ds.GetName())
+	}
+
+	for i, e := range endpoints {
+		if !strings.Contains(e, "://") {
+			endpoints[i] = "https://" + e
+		}
+	}
+
+	return strings.Join(endpoints, ","), nil
+}
+
+// kamajiSecretKey returns the secret key referenced by the content reference
+// of ds at fields. Inline content is not supported.
+func kamajiSecretKey(ds *unstructured.Unstructured, fields ...string) (certs.SecretKey, error) {
+	ref, ok, _ := unstructured.NestedStringMap(ds.Object, append(fields, "secretReference")...)
+	if !ok || ref["name"] == "" || ref["keyPath"] == "" {
+		return certs.SecretKey{}, microerror.Maskf(missingTLSSecretError, "DataStore %#q has no secret reference in %s", ds.GetName(), strings.Join(fields, "."))
+	}
+
+	key := certs.SecretKey{
+		Secret: client.ObjectKey{Namespace: ref["namespace"], Name: ref["name"]},
+		Key:    ref["keyPath"],
+	}
+
+	return key, nil
+}
@@ -0,0 +1,76 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+const (
+	vclusterCAKey   = "etcd-ca.crt"
+	vclusterCertKey = "apiserver-etcd-client.crt"
+	vclusterKeyKey  = "apiserver-etcd-client.key"
+)
+
+// vclusterDiscovery reaches the etcd of vclusters, which runs in the
+// namespace of the vcluster in the management cluster, either deployed as
+// the StatefulSet <name>-etcd or embedded in the vcluster pods.
+type vclusterDiscovery struct {
+	u *Utils
+}
+
+// TLSSource returns the certificates secret of the vcluster, in which the
+// kubeadm certificate paths are flattened.
+func (d *vclusterDiscovery) TLSSource(ctx context.Context, c *capi.Cluster) (certs.Source, certs.ServerName, error) {
+	source := certs.SecretSource{
+		Client:  d.u.K8sClient.CtrlClient(),
+		Secret:  client.ObjectKey{Namespace: c.Namespace, Name: fmt.Sprintf("%s-certs", c.Spec.ControlPlaneRef.Name)},
+		CAKey:   vclusterCAKey,
+		CertKey: vclusterCertKey,
+		KeyKey:  vclusterKeyKey,
+	}
+
+	// The etcd pods are reached on localhost through the port-forward proxy.
+	return source, certs.Fixed("localhost"), nil
+}
+
+// Endpoints returns the name of the first etcd pod, or of the first vcluster
+// pod when etcd is embedded.
+func (d *vclusterDiscovery) Endpoints(ctx context.Context, c *capi.Cluster) (string, error) {
+	name := c.Spec.ControlPlaneRef.Name
+
+	for _, pod := range []string{fmt.Sprintf("%s-etcd-0", name), fmt.Sprintf("%s-0", name)} {
+		err := d.u.K8sClient.CtrlClient().Get(ctx, client.ObjectKey{Namespace: c.Namespace, Name: pod}, &v1.Pod{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", microerror.Maskf(executionFailedError, "error getting pod %s/%s of vcluster %#q with error %#q", c.Namespace, pod, c.Name, err)
+		}
+
+		return pod, nil
+	}
+
+	return "", microerror.Maskf(noEtcdPodsError, "error getting etcd endpoint, no etcd pods found for vcluster %#q", c.Name)
+}
+
+func (d *vclusterDiscovery) Dialer(ctx context.Context, c *capi.Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	p := &proxy.Proxy{
+		Kind:       "pods",
+		Namespace:  c.Namespace,
+		KubeConfig: rest.CopyConfig(d.u.K8sClient.RESTConfig()),
+		TLSConfig:  tlsConfig,
+		Port:       2379,
+	}
+
+	return p, nil
+}
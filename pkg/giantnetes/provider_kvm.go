@@ -0,0 +1,73 @@
+package giantnetes
+
+import (
+	"context"
+	"crypto/tls"
+
+	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// kvmProvider discovers the clusters of the Giant Swarm KVM provider, which
+// are represented by KVMConfigs.
+type kvmProvider struct {
+	u *Utils
+}
+
+func (p *kvmProvider) Name() string {
+	return kvm
+}
+
+func (p *kvmProvider) List(ctx context.Context) ([]Cluster, error) {
+	var clusters []Cluster
+	crdList := providerv1alpha1.KVMConfigList{}
+	err := p.u.listPages(ctx, &crdList, func() {
+		for _, kvmConfig := range crdList.Items {
+			// Only backup cluster if it was not marked for delete.
+			if kvmConfig.DeletionTimestamp == nil {
+				clusters = append(clusters, Cluster{clusterKey: client.ObjectKey{Name: kvmConfig.Name, Namespace: kvmConfig.Namespace}, provider: kvm, annotations: kvmConfig.Annotations, object: &kvmConfig})
+			}
+		}
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return clusters, nil
+}
+
+func (p *kvmProvider) IsSkipped(ctx context.Context, cluster Cluster) (bool, error) {
+	return false, nil
+}
+
+func (p *kvmProvider) IsSupported(ctx context.Context, cluster Cluster) (bool, error) {
+	// KVM backups are always supported.
+	return true, nil
+}
+
+func (p *kvmProvider) TLSConfig(ctx context.Context, cluster Cluster, insecure bool) (*tls.Config, error) {
+	t, err := p.u.getLegacyEtcdTLSCfg(ctx, cluster.clusterKey, insecure)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return t, nil
+}
+
+func (p *kvmProvider) Endpoints(ctx context.Context, cluster Cluster) (string, error) {
+	crd := providerv1alpha1.KVMConfig{}
+	err := p.u.K8sClient.CtrlClient().Get(ctx, cluster.clusterKey, &crd)
+	if err != nil {
+		return "", microerror.Maskf(executionFailedError, "error getting kvm crd for guest cluster %#q with error %#q", cluster.clusterKey.Name, err)
+	}
+
+	return KVMEtcdEndpoint(crd.Spec.Cluster.Etcd.Domain), nil
+}
+
+func (p *kvmProvider) Dialer(ctx context.Context, cluster Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
+	// no proxy needed for legacy clusters
+	return nil, nil
+}
@@ -0,0 +1,252 @@
+package giantnetes
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+)
+
+func Test_clusterProvider(t *testing.T) {
+	u := &Utils{}
+	u.providers = newClusterProviders(u)
+
+	testCases := []struct {
+		name         string
+		provider     string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: AWS cluster",
+			provider:     awsCAPI,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: Azure cluster",
+			provider:     azure,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 2: KVM cluster",
+			provider:     kvm,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 3: CAPI cluster",
+			provider:     CAPI,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 4: unknown provider",
+			provider:     "openstack",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			p, err := u.clusterProvider(Cluster{clusterKey: client.ObjectKey{Name: "foo"}, provider: tc.provider})
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if err == nil && p.Name() != tc.provider {
+				t.Fatalf("provider == %#q, want %#q", p.Name(), tc.provider)
+			}
+		})
+	}
+}
+
+func Test_capiProvider_discovery(t *testing.T) {
+	u := &Utils{}
+	p := &capiProvider{u: u, discoveries: newEtcdDiscoveries(u)}
+
+	testCases := []struct {
+		name     string
+		kind     string
+		expected etcdDiscovery
+	}{
+		{
+			name:     "case 0: kubeadm control plane",
+			kind:     "KubeadmControlPlane",
+			expected: &kubeadmDiscovery{},
+		},
+		{
+			name:     "case 1: Kamaji control plane",
+			kind:     "KamajiControlPlane",
+			expected: &kamajiDiscovery{},
+		},
+		{
+			name:     "case 2: k0smotron control plane",
+			kind:     "K0smotronControlPlane",
+			expected: &k0smotronDiscovery{},
+		},
+		{
+			name:     "case 3: vcluster",
+			kind:     "VCluster",
+			expected: &vclusterDiscovery{},
+		},
+		{
+			name:     "case 4: unknown control plane falls back to kubeadm",
+			kind:     "TalosControlPlane",
+			expected: &kubeadmDiscovery{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			c := &capi.Cluster{}
+			c.Spec.ControlPlaneRef.Kind = tc.kind
+
+			_, d, err := p.discovery(Cluster{clusterKey: client.ObjectKey{Name: "foo"}, provider: CAPI, object: c})
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			if reflect.TypeOf(d) != reflect.TypeOf(tc.expected) {
+				t.Fatalf("discovery == %T, want %T", d, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_kamajiSecretKey(t *testing.T) {
+	testCases := []struct {
+		name         string
+		ref          map[string]interface{}
+		expected     certs.SecretKey
+		errorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: secret reference",
+			ref: map[string]interface{}{
+				"secretReference": map[string]interface{}{
+					"name":      "etcd-certs",
+					"namespace": "kamaji-system",
+					"keyPath":   "ca.crt",
+				},
+			},
+			expected: certs.SecretKey{
+				Secret: client.ObjectKey{Namespace: "kamaji-system", Name: "etcd-certs"},
+				Key:    "ca.crt",
+			},
+			errorMatcher: nil,
+		},
+		{
+			name: "case 1: inline content",
+			ref: map[string]interface{}{
+				"content": "Y2EK",
+			},
+			errorMatcher: func(err error) bool { return err != nil },
+		},
+		{
+			name: "case 2: secret reference without key path",
+			ref: map[string]interface{}{
+				"secretReference": map[string]interface{}{
+					"name":      "etcd-certs",
+					"namespace": "kamaji-system",
+				},
+			},
+			errorMatcher: func(err error) bool { return err != nil },
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			ds := &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"tlsConfig": map[string]interface{}{
+						"certificateAuthority": map[string]interface{}{
+							"certificate": tc.ref,
+						},
+					},
+				},
+			}}
+
+			key, err := kamajiSecretKey(ds, "spec", "tlsConfig", "certificateAuthority", "certificate")
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if key != tc.expected {
+				t.Fatalf("key == %#v, want %#v", key, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_kamajiEndpoints(t *testing.T) {
+	testCases := []struct {
+		name         string
+		endpoints    []interface{}
+		expected     string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: all endpoints are backed up from",
+			endpoints:    []interface{}{"etcd-0.etcd.kamaji-system.svc:2379", "etcd-1.etcd.kamaji-system.svc:2379", "https://etcd-2.etcd.kamaji-system.svc:2379"},
+			expected:     "https://etcd-0.etcd.kamaji-system.svc:2379,https://etcd-1.etcd.kamaji-system.svc:2379,https://etcd-2.etcd.kamaji-system.svc:2379",
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: no endpoints",
+			errorMatcher: func(err error) bool { return err != nil },
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			ds := &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"endpoints": tc.endpoints,
+				},
+			}}
+
+			endpoints, err := kamajiEndpoints(ds)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if endpoints != tc.expected {
+				t.Fatalf("endpoints == %q, want %q", endpoints, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package giantnetes
+
+import (
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// Annotations used to override the stage timeouts of a backup. They can be
+	// set on the ETCDBackup CR as well as on the cluster object of a workload
+	// cluster. The values are Go durations, e.g. "15m".
+	CreateTimeoutAnnotation  = "giantswarm.io/etcd-backup-operator-create-timeout"
+	EncryptTimeoutAnnotation = "giantswarm.io/etcd-backup-operator-encrypt-timeout"
+	UploadTimeoutAnnotation  = "giantswarm.io/etcd-backup-operator-upload-timeout"
+)
+
+// TimeoutsFromAnnotations parses the stage timeout annotations. Stages
+// without annotation are left at zero so the result can be merged on top of
+// less specific settings.
+func TimeoutsFromAnnotations(annotations map[string]string) (Timeouts, error) {
+	var t Timeouts
+
+	for annotation, d := range map[string]*time.Duration{
+		CreateTimeoutAnnotation:  &t.Create,
+		EncryptTimeoutAnnotation: &t.Encrypt,
+		UploadTimeoutAnnotation:  &t.Upload,
+	} {
+		v, ok := annotations[annotation]
+		if !ok || v == "" {
+			continue
+		}
+
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return Timeouts{}, microerror.Maskf(invalidConfigError, "annotation %#q has invalid duration %#q", annotation, v)
+		}
+		if parsed < 0 {
+			return Timeouts{}, microerror.Maskf(invalidConfigError, "annotation %#q must not be negative", annotation)
+		}
+
+		*d = parsed
+	}
+
+	return t, nil
+}
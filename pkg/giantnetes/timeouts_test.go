@@ -0,0 +1,100 @@
+package giantnetes
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_TimeoutsFromAnnotations(t *testing.T) {
+	testCases := []struct {
+		name         string
+		annotations  map[string]string
+		expected     Timeouts
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: no annotations",
+			annotations:  nil,
+			expected:     Timeouts{},
+			errorMatcher: nil,
+		},
+		{
+			name: "case 1: all stages annotated",
+			annotations: map[string]string{
+				CreateTimeoutAnnotation:  "15m",
+				EncryptTimeoutAnnotation: "90s",
+				UploadTimeoutAnnotation:  "1h",
+			},
+			expected: Timeouts{
+				Create:  15 * time.Minute,
+				Encrypt: 90 * time.Second,
+				Upload:  time.Hour,
+			},
+			errorMatcher: nil,
+		},
+		{
+			name: "case 2: single stage annotated",
+			annotations: map[string]string{
+				UploadTimeoutAnnotation: "5m",
+			},
+			expected: Timeouts{
+				Upload: 5 * time.Minute,
+			},
+			errorMatcher: nil,
+		},
+		{
+			name: "case 3: invalid duration",
+			annotations: map[string]string{
+				CreateTimeoutAnnotation: "tomorrow",
+			},
+			expected:     Timeouts{},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 4: negative duration",
+			annotations: map[string]string{
+				EncryptTimeoutAnnotation: "-1m",
+			},
+			expected:     Timeouts{},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			timeouts, err := TimeoutsFromAnnotations(tc.annotations)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !cmp.Equal(timeouts, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, timeouts))
+			}
+		})
+	}
+}
+
+func Test_Timeouts_Merge(t *testing.T) {
+	defaults := Timeouts{Create: time.Hour, Encrypt: time.Minute, Upload: time.Hour}
+	override := Timeouts{Encrypt: 5 * time.Minute}
+
+	merged := defaults.Merge(override)
+
+	expected := Timeouts{Create: time.Hour, Encrypt: 5 * time.Minute, Upload: time.Hour}
+	if !cmp.Equal(merged, expected) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, merged))
+	}
+}
@@ -0,0 +1,41 @@
+package giantnetes
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// InsecureSkipTLSVerifyAnnotation disables ("true") the verification of
+	// the etcd server certificate of a workload cluster, for providers whose
+	// certificates can not be verified. It is set on the cluster object.
+	InsecureSkipTLSVerifyAnnotation = "giantswarm.io/etcd-backup-operator-insecure-skip-tls-verify"
+
+	etcdPodPrefix = "etcd-"
+)
+
+// InsecureSkipTLSVerifyFromAnnotations parses the annotation disabling the
+// verification of the etcd server certificate. Verification stays enabled
+// when the annotation is not set.
+func InsecureSkipTLSVerifyFromAnnotations(annotations map[string]string) (bool, error) {
+	v, ok := annotations[InsecureSkipTLSVerifyAnnotation]
+	if !ok || v == "" {
+		return false, nil
+	}
+
+	insecure, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, microerror.Maskf(invalidConfigError, "annotation %#q has invalid value %#q", InsecureSkipTLSVerifyAnnotation, v)
+	}
+
+	return insecure, nil
+}
+
+// EtcdPodServerName returns the name expected in the server certificate of
+// the etcd static pod named pod. kubeadm names the pods after their node and
+// puts the name of the node in the certificates.
+func EtcdPodServerName(pod string) string {
+	return strings.TrimPrefix(pod, etcdPodPrefix)
+}
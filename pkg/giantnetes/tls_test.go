@@ -0,0 +1,63 @@
+package giantnetes
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_InsecureSkipTLSVerifyFromAnnotations(t *testing.T) {
+	testCases := []struct {
+		name         string
+		annotations  map[string]string
+		expected     bool
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: no annotation",
+			annotations:  nil,
+			expected:     false,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: verification disabled",
+			annotations:  map[string]string{InsecureSkipTLSVerifyAnnotation: "true"},
+			expected:     true,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 2: verification enabled",
+			annotations:  map[string]string{InsecureSkipTLSVerifyAnnotation: "false"},
+			expected:     false,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 3: invalid value",
+			annotations:  map[string]string{InsecureSkipTLSVerifyAnnotation: "maybe"},
+			expected:     false,
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			insecure, err := InsecureSkipTLSVerifyFromAnnotations(tc.annotations)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if insecure != tc.expected {
+				t.Fatalf("insecure == %t, want %t", insecure, tc.expected)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package giantnetes
 
 import (
 	"crypto/tls"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
 )
@@ -10,11 +13,57 @@ type ETCDv3Settings struct {
 	Endpoints string
 	Proxy     *proxy.Proxy
 	TLSConfig *tls.Config
+	// Datastore selects the Backupper, see etcd.NewBackupper. It is empty
+	// for etcd clusters.
+	Datastore string
 }
 
 type ETCDInstance struct {
-	Name   string
-	ETCDv3 ETCDv3Settings
+	Name     string
+	ETCDv3   ETCDv3Settings
+	Timeouts Timeouts
+	// DRBundle is set when the cluster is annotated to enable or disable
+	// its DR bundle.
+	DRBundle *bool
+	// Force takes the backup even when the control plane of the cluster is
+	// not ready to be backed up, see ControlPlaneDeferral.
+	Force bool
+	// Failure is set when the cluster was discovered but can not be backed
+	// up, e.g. because its etcd certificates are missing.
+	Failure *DiscoveryFailure
+	// Tenants are the workload clusters sharing the etcd backed up by the
+	// instance, e.g. the clusters of a Kamaji DataStore. The instance is
+	// named after the etcd, and configured by the annotations of the first
+	// tenant.
+	Tenants []string
+
+	// cluster is the workload cluster of the instance, nil for the
+	// management cluster, ETCDEndpoints and etcd shared by Tenants.
+	cluster *Cluster
+}
+
+// Timeouts bounds the duration of every stage of a backup attempt. A zero
+// value means no timeout is configured for the corresponding stage.
+type Timeouts struct {
+	Create  time.Duration
+	Encrypt time.Duration
+	Upload  time.Duration
+}
+
+// ClusterInfo describes a workload cluster regardless of whether it can be
+// backed up.
+type ClusterInfo struct {
+	Name              string
+	Annotations       map[string]string
+	CreationTimestamp time.Time
+	// Object is the provider specific object representing the cluster, e.g.
+	// the CAPI Cluster.
+	Object  client.Object
+	Skipped bool
+	// Instance is the name of the instance backing up the etcd of the
+	// cluster when it is shared with other clusters, e.g. the name of its
+	// Kamaji DataStore, and empty otherwise.
+	Instance string
 }
 
 type TLSClientConfig struct {
@@ -23,6 +72,29 @@ type TLSClientConfig struct {
 	CrtData []byte
 }
 
+// IsWorkloadCluster returns whether the instance is a workload cluster, as
+// opposed to the management cluster, an ETCDEndpoint or etcd shared by
+// several clusters.
+func (i ETCDInstance) IsWorkloadCluster() bool {
+	return i.cluster != nil
+}
+
 func (s ETCDv3Settings) AreComplete() bool {
 	return s.Endpoints != "" && s.TLSConfig != nil
 }
+
+// Merge returns a copy of t where every stage timeout set in override
+// replaces the one in t.
+func (t Timeouts) Merge(override Timeouts) Timeouts {
+	if override.Create != 0 {
+		t.Create = override.Create
+	}
+	if override.Encrypt != 0 {
+		t.Encrypt = override.Encrypt
+	}
+	if override.Upload != 0 {
+		t.Upload = override.Upload
+	}
+
+	return t
+}
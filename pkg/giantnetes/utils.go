@@ -5,21 +5,19 @@ import (
 	"crypto/tls"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
-	"github.com/giantswarm/apiextensions/v6/pkg/apis/infrastructure/v1alpha3"
-	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/k8sclient/v8/pkg/k8sclient"
 	"github.com/giantswarm/k8smetadata/pkg/label"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
-	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
 	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
 )
 
@@ -28,16 +26,27 @@ const (
 	certificateLabelValue = "calico-etcd-client"
 
 	skipEtcdBackupAnnotation = "giantswarm.io/etcd-backup-operator-skip-backup"
+
+	// secretCertsMaxAge is how long etcd client certificates read from
+	// secrets are used before they are read again.
+	secretCertsMaxAge = 5 * time.Minute
 )
 
 type Utils struct {
 	logger    micrologger.Logger
 	K8sClient k8sclient.Interface
+
+	providers []ClusterProvider
+	cache     *discoveryCache
 }
 
 type Cluster struct {
-	clusterKey client.ObjectKey
-	provider   string
+	clusterKey  client.ObjectKey
+	provider    string
+	annotations map[string]string
+	object      client.Object
+	// access overrides the access mode of the annotations.
+	access *Access
 }
 
 func NewUtils(logger micrologger.Logger, client k8sclient.Interface) (*Utils, error) {
@@ -48,29 +57,90 @@ func NewUtils(logger micrologger.Logger, client k8sclient.Interface) (*Utils, er
 		return nil, microerror.Maskf(invalidConfigError, "client must not be empty")
 	}
 
-	return &Utils{
+	u := &Utils{
 		logger:    logger,
 		K8sClient: client,
-	}, nil
+		cache:     sharedCache,
+	}
+	u.providers = newClusterProviders(u)
+
+	return u, nil
 }
 
+// GetTenantClusters returns the instances of the workload clusters and the
+// ETCDEndpoints which are backed up. Clusters which can not be backed up are
+// returned with the reason in Failure.
 func (u *Utils) GetTenantClusters(ctx context.Context) ([]ETCDInstance, error) {
+	return u.tenantClusters(ctx, "")
+}
+
+// GetTenantCluster returns the instance of the workload cluster or the
+// ETCDEndpoint with the given name like GetTenantClusters, without preparing
+// the other ones.
+func (u *Utils) GetTenantCluster(ctx context.Context, name string) (ETCDInstance, error) {
+	instances, err := u.tenantClusters(ctx, name)
+	if err != nil {
+		return ETCDInstance{}, microerror.Mask(err)
+	}
+	if len(instances) == 0 {
+		return ETCDInstance{}, microerror.Maskf(clusterNotFoundError, "cluster %#q is not backed up", name)
+	}
+
+	return instances[0], nil
+}
+
+// tenantClusters returns the instances of the clusters named name, or of all
+// clusters when name is empty.
+func (u *Utils) tenantClusters(ctx context.Context, name string) ([]ETCDInstance, error) {
 	var instances []ETCDInstance
 
-	clusterList, err := u.getAllWorkloadClusters(ctx, u.K8sClient.CtrlClient())
+	clusterList, err := u.getAllWorkloadClusters(ctx)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
 	u.logger.LogCtx(ctx, "level", "debug", fmt.Sprintf("Found %d tenant clusters", len(clusterList)))
 
+	// The certificate metrics of clusters which are not backed up anymore
+	// are removed, once all clusters were discovered.
+	discovered := []string{key.ManagementCluster}
+	defer func() {
+		if name != "" {
+			return
+		}
+		certs.Prune(discovered...)
+		u.cache.prune(discovered...)
+	}()
+
+	// Clusters sharing their etcd, e.g. Kamaji clusters sharing a DataStore,
+	// are backed up once by the instance at the index kept by its name.
+	shared := map[string]int{}
+
 	for _, cluster := range clusterList {
+		provider, err := u.clusterProvider(cluster)
+		if err != nil {
+			if name != "" && cluster.clusterKey.Name != name {
+				continue
+			}
+			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to find provider of cluster %s", cluster.clusterKey.Name), "reason", err)
+			instances = append(instances, failedInstance(cluster, err, FailureReasonUnknownProvider))
+			continue
+		}
+
+		instanceName, isShared, instanceErr := u.instance(ctx, provider, cluster)
+		if instanceErr != nil {
+			instanceName = cluster.clusterKey.Name
+		}
+		if name != "" && instanceName != name {
+			continue
+		}
 		u.logger.LogCtx(ctx, "level", "debug", fmt.Sprintf("Preparing instance entry for tenant clusters %s", cluster.clusterKey.Name))
 
 		// Check if the cluster backup should be skipped
-		backupSkipped, err := u.isClusterSkipped(ctx, cluster)
+		backupSkipped, err := provider.IsSkipped(ctx, cluster)
 		if err != nil {
 			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to check if backup should be skipped for cluster %s", cluster.clusterKey.Name), "reason", err)
+			instances = append(instances, failedInstance(cluster, err, FailureReasonClusterCheckFailed))
 			continue
 		}
 		if backupSkipped {
@@ -79,209 +149,298 @@ func (u *Utils) GetTenantClusters(ctx context.Context) ([]ETCDInstance, error) {
 		}
 
 		// Check if the cluster release version has support for ETCD backup.
-		versionSupported, err := u.checkClusterVersionSupport(ctx, cluster)
+		versionSupported, err := provider.IsSupported(ctx, cluster)
 		if err != nil {
 			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to check release version for cluster %s", cluster.clusterKey.Name), "reason", err)
+			instances = append(instances, failedInstance(cluster, err, FailureReasonClusterCheckFailed))
 			continue
 		}
 		if !versionSupported {
 			u.logger.LogCtx(ctx, "level", "warning", "msg", fmt.Sprintf("Cluster %s is too old for etcd backup. Skipping.", cluster.clusterKey.Name))
 			continue
 		}
+		discovered = append(discovered, cluster.clusterKey.Name)
 
-		// Prepare ETCD tls config.
-		tlsConfig, err := u.getEtcdTLSCfg(ctx, cluster)
-		if err != nil {
-			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to fetch etcd certs for cluster %s", cluster.clusterKey.Name), "reason", err)
+		if instanceErr != nil {
+			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to find etcd of cluster %s", cluster.clusterKey.Name), "reason", instanceErr)
+			instances = append(instances, failedInstance(cluster, instanceErr, FailureReasonEndpointUnavailable))
 			continue
 		}
 
-		// Fetch ETCD endpoint.
-		etcdEndpoint, err := u.getEtcdEndpoint(ctx, cluster)
-		if err != nil {
-			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to fetch etcd endpoint for cluster %s", cluster.clusterKey.Name), "reason", err)
+		i, ok := shared[instanceName]
+		if ok && isShared {
+			u.logger.LogCtx(ctx, "level", "debug", "msg", fmt.Sprintf("Cluster %s shares the etcd of instance %s", cluster.clusterKey.Name, instanceName))
+			instances[i].Tenants = append(instances[i].Tenants, cluster.clusterKey.Name)
 			continue
 		}
+		if ok || isShared && hasInstance(instances, instanceName) {
+			err = microerror.Maskf(invalidConfigError, "instance %#q of cluster %#q is named like another instance", instanceName, cluster.clusterKey.Name)
+			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to prepare instance for cluster %s", cluster.clusterKey.Name), "reason", err)
+			instances = append(instances, failedInstance(cluster, err, FailureReasonInvalidConfig))
+			continue
+		}
+
+		instance := u.clusterInstance(ctx, provider, cluster)
+		if isShared {
+			// The instance backs up the etcd of its tenants rather than a
+			// workload cluster.
+			instance.Name = instanceName
+			instance.Tenants = []string{cluster.clusterKey.Name}
+			instance.cluster = nil
+			shared[instanceName] = len(instances)
+		}
+		instances = append(instances, instance)
+	}
+
+	endpoints, err := u.listETCDEndpoints(ctx)
+	if err != nil {
+		u.logger.LogCtx(ctx, "level", "error", "msg", "Failed to list ETCDEndpoints", "reason", err)
+	}
+
+	for _, e := range endpoints {
+		if name != "" && e.Name != name {
+			continue
+		}
+		if e.Spec.Backup.Suspend {
+			u.logger.LogCtx(ctx, "level", "debug", "msg", fmt.Sprintf("Backup for ETCDEndpoint %s/%s is suspended", e.Namespace, e.Name))
+			continue
+		}
+		if hasInstance(instances, e.Name) {
+			// The instance is reported under the qualified name of the
+			// ETCDEndpoint, so that it does not replace the other one.
+			err := microerror.Maskf(invalidConfigError, "ETCDEndpoint %s/%s is named like another instance", e.Namespace, e.Name)
+			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to prepare instance for ETCDEndpoint %s/%s", e.Namespace, e.Name), "reason", err)
+			instances = append(instances, ETCDInstance{Name: fmt.Sprintf("%s/%s", e.Namespace, e.Name), Failure: newDiscoveryFailure(err, FailureReasonInvalidConfig)})
+			continue
+		}
+		discovered = append(discovered, e.Name)
 
-		// prepare etcd proxy
-		p, err := u.getEtcdProxy(ctx, cluster, tlsConfig)
+		instance, err := u.endpointInstance(ctx, e)
 		if err != nil {
-			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to prepare etcd proxy for cluster %s", cluster.clusterKey.Name), "reason", err)
+			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to prepare instance for ETCDEndpoint %s/%s", e.Namespace, e.Name), "reason", err)
+			instances = append(instances, ETCDInstance{Name: e.Name, Failure: newDiscoveryFailure(err, FailureReasonEndpointUnavailable)})
 			continue
 		}
 
-		instances = append(instances, ETCDInstance{
-			Name: cluster.clusterKey.Name,
-			ETCDv3: ETCDv3Settings{
-				Endpoints: etcdEndpoint,
-				TLSConfig: tlsConfig,
-				Proxy:     p,
-			},
-		})
+		instances = append(instances, instance)
 	}
+
 	return instances, nil
 }
 
-// isClusterSkipped checks if cluster should be skipped from guest cluster backup.
-func (u *Utils) isClusterSkipped(ctx context.Context, cluster Cluster) (bool, error) {
-	crdClient := u.K8sClient.CtrlClient()
+// clusterInstance prepares the instance backing up the etcd of cluster. The
+// instance is returned with the reason in Failure when it can not be backed
+// up.
+func (u *Utils) clusterInstance(ctx context.Context, provider ClusterProvider, cluster Cluster) ETCDInstance {
+	// Prepare ETCD tls config.
+	tlsConfig, err := u.getEtcdTLSCfg(ctx, provider, cluster)
+	if err != nil {
+		u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to fetch etcd certs for cluster %s", cluster.clusterKey.Name), "reason", err)
+		return failedInstance(cluster, err, FailureReasonTLSUnavailable)
+	}
 
-	switch cluster.provider {
-	case awsCAPI:
-		crd := v1alpha3.AWSCluster{}
-		err := crdClient.Get(ctx, cluster.clusterKey, &crd)
-		if err != nil {
-			return false, microerror.Maskf(executionFailedError, "error getting aws crd for guest cluster %#q with error %#q", cluster.clusterKey.Name, err)
-		}
+	// Fetch ETCD endpoint.
+	etcdEndpoint, err := provider.Endpoints(ctx, cluster)
+	if err != nil {
+		u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to fetch etcd endpoint for cluster %s", cluster.clusterKey.Name), "reason", err)
+		return failedInstance(cluster, err, FailureReasonEndpointUnavailable)
+	}
 
-		if crd.Annotations[skipEtcdBackupAnnotation] == "true" {
-			return true, nil
-		}
-	case azure:
-		return false, nil
-	case kvm:
-		return false, nil
-	case CAPI:
-		return false, nil
+	// prepare etcd proxy
+	p, err := provider.Dialer(ctx, cluster, tlsConfig)
+	if err != nil {
+		u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to prepare etcd proxy for cluster %s", cluster.clusterKey.Name), "reason", err)
+		return failedInstance(cluster, err, FailureReasonProxyUnavailable)
+	}
+
+	timeouts, err := TimeoutsFromAnnotations(cluster.annotations)
+	if err != nil {
+		u.logger.LogCtx(ctx, "level", "warning", "msg", fmt.Sprintf("Ignoring timeout annotations for cluster %s", cluster.clusterKey.Name), "reason", err)
+		timeouts = Timeouts{}
+	}
+
+	drBundle, err := DRBundleFromAnnotations(cluster.annotations)
+	if err != nil {
+		u.logger.LogCtx(ctx, "level", "warning", "msg", fmt.Sprintf("Ignoring DR bundle annotation for cluster %s", cluster.clusterKey.Name), "reason", err)
+	}
+
+	return ETCDInstance{
+		Name: cluster.clusterKey.Name,
+		ETCDv3: ETCDv3Settings{
+			Endpoints: etcdEndpoint,
+			TLSConfig: tlsConfig,
+			Proxy:     p,
+		},
+		Timeouts: timeouts,
+		DRBundle: drBundle,
+
+		cluster: &cluster,
 	}
-	return false, nil
 }
 
-// Check if cluster release version has guest cluster backup support.
-func (u *Utils) checkClusterVersionSupport(ctx context.Context, cluster Cluster) (bool, error) {
-	crdClient := u.K8sClient.CtrlClient()
+// instance returns the name of the instance backing up the etcd of cluster
+// and whether it may be shared with other clusters.
+func (u *Utils) instance(ctx context.Context, provider ClusterProvider, cluster Cluster) (string, bool, error) {
+	s, ok := provider.(sharedProvider)
+	if !ok {
+		return cluster.clusterKey.Name, false, nil
+	}
 
-	switch cluster.provider {
-	case awsCAPI:
-		{
-			// Cluster API AWS backups are always supported.
-			return true, nil
-		}
-	case azure:
-		{
-			crd := providerv1alpha1.AzureConfig{}
-			err := crdClient.Get(ctx, cluster.clusterKey, &crd)
-			if err != nil {
-				return false, microerror.Maskf(executionFailedError, "failed to get azure crd %#q with error %#q", cluster.clusterKey.Name, err)
-			}
-			var version string
-			{
-				version = crd.Spec.VersionBundle.Version
-				if version == "" {
-					// CAPI clusters still have an AzureConfig, but they don't have the Spec.VersionBundle.Version field set.
-					// They save the version in a label.
-					version = crd.Labels[label.ReleaseVersion]
-				}
-			}
-			if version == "" {
-				return false, microerror.Maskf(executionFailedError, "failed to get cluster version from AzureConfig %#q", cluster.clusterKey.Name)
-			}
-			return stringVersionCmp(version, semver.New("0.0.0"), azureSupportFrom)
-		}
-	case kvm:
-		{
-			// KVM backups are always supported.
-			return true, nil
-		}
-	case CAPI:
-		{
-			// CAPI backups are always supported.
-			return true, nil
-		}
+	name, isShared, err := s.Instance(ctx, cluster)
+	if err != nil {
+		return "", false, microerror.Mask(err)
 	}
-	return false, nil
+
+	return name, isShared, nil
 }
 
-func (u *Utils) getEtcdTLSCfg(ctx context.Context, cluster Cluster) (*tls.Config, error) {
-	if cluster.provider == CAPI {
-		t, err := u.getCAPIEtcdTLSCfg(ctx, cluster.clusterKey)
+// ListClusters returns all workload clusters and ETCDEndpoints, including the
+// ones whose backup is skipped, without preparing anything needed to connect
+// to their etcd. Clusters sharing their etcd are returned with the name of
+// the instance backing it up.
+func (u *Utils) ListClusters(ctx context.Context) ([]ClusterInfo, error) {
+	clusterList, err := u.getAllWorkloadClusters(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var clusters []ClusterInfo
+	for _, cluster := range clusterList {
+		provider, err := u.clusterProvider(cluster)
 		if err != nil {
-			return nil, microerror.Mask(err)
+			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to find provider of cluster %s", cluster.clusterKey.Name), "reason", err)
+			continue
 		}
-		return t, nil
-	} else {
-		t, err := u.getLegacyEtcdTLSCfg(ctx, cluster.clusterKey)
+
+		backupSkipped, err := provider.IsSkipped(ctx, cluster)
+		if err != nil {
+			u.logger.LogCtx(ctx, "level", "error", "msg", fmt.Sprintf("Failed to check if backup should be skipped for cluster %s", cluster.clusterKey.Name), "reason", err)
+			continue
+		}
+
+		info := ClusterInfo{
+			Name:              cluster.clusterKey.Name,
+			Annotations:       cluster.annotations,
+			CreationTimestamp: cluster.object.GetCreationTimestamp().Time,
+			Object:            cluster.object,
+			Skipped:           backupSkipped,
+		}
+
+		instanceName, isShared, err := u.instance(ctx, provider, cluster)
 		if err != nil {
-			return nil, microerror.Mask(err)
+			u.logger.LogCtx(ctx, "level", "debug", "msg", fmt.Sprintf("Failed to find etcd of cluster %s", cluster.clusterKey.Name), "reason", err)
+		} else if isShared {
+			info.Instance = instanceName
 		}
-		return t, nil
+
+		clusters = append(clusters, info)
 	}
-}
 
-// Fetch ETCD client certs.
-func (u *Utils) getLegacyEtcdTLSCfg(ctx context.Context, clusterKey client.ObjectKey) (*tls.Config, error) {
-	k8sClient := u.K8sClient.CtrlClient()
-	secrets := v1.SecretList{}
-	err := k8sClient.List(ctx, &secrets, client.MatchingLabels{
-		label.Cluster:    clusterKey.Name,
-		certificateLabel: certificateLabelValue,
-	})
+	endpoints, err := u.listETCDEndpoints(ctx)
 	if err != nil {
-		return nil, microerror.Maskf(executionFailedError, "error getting etcd client certificates for guest cluster %#q with error %#q", clusterKey.Name, err)
+		u.logger.LogCtx(ctx, "level", "error", "msg", "Failed to list ETCDEndpoints", "reason", err)
 	}
 
-	if len(secrets.Items) != 1 {
-		return nil, microerror.Maskf(executionFailedError, "expected exactly 1 secret with %s=%q and %s=%q, got %d", label.Cluster, clusterKey.Name, certificateLabel, certificateLabelValue, len(secrets.Items))
+	for _, e := range endpoints {
+		clusters = append(clusters, ClusterInfo{
+			Name:              e.Name,
+			Annotations:       e.Annotations,
+			CreationTimestamp: e.CreationTimestamp.Time,
+			Object:            &e,
+			Skipped:           e.Spec.Backup.Suspend,
+		})
 	}
 
-	s := secrets.Items[0]
+	return clusters, nil
+}
 
-	tlsConfig, err := key.PrepareTLSConfig(s.Data["ca"], s.Data["crt"], s.Data["key"])
+// CAPIETCDv3Settings returns the TLS configuration and the port-forward proxy,
+// or the proxy to the control plane nodes, to reach the etcd pods of a CAPI
+// cluster. Endpoints is left empty, callers dial the etcd pods by name.
+func (u *Utils) CAPIETCDv3Settings(ctx context.Context, clusterKey client.ObjectKey) (ETCDv3Settings, error) {
+	capiCluster := &capi.Cluster{}
+	err := u.K8sClient.CtrlClient().Get(ctx, clusterKey, capiCluster)
 	if err != nil {
-		return nil, microerror.Mask(err)
+		return ETCDv3Settings{}, microerror.Maskf(executionFailedError, "error getting CAPI cluster %s/%s with error %#q", clusterKey.Namespace, clusterKey.Name, err)
 	}
 
-	return tlsConfig, nil
-}
+	cluster := Cluster{clusterKey: clusterKey, provider: CAPI, annotations: capiCluster.Annotations, object: capiCluster}
+	// Restores talk to etcd itself, which node agents do not serve, so the
+	// etcd pods are port-forwarded to instead.
+	a, err := AccessFromAnnotations(capiCluster.Annotations)
+	if err == nil && a.ViaAgent() {
+		cluster.access = &Access{Mode: AccessPortForward}
+	}
 
-// Fetch ETCD client certs.
-func (u *Utils) getEtcdProxy(ctx context.Context, cluster Cluster, tlsConfig *tls.Config) (*proxy.Proxy, error) {
-	if cluster.provider == CAPI {
-		ctrClient := u.K8sClient.CtrlClient()
+	provider, err := u.clusterProvider(cluster)
+	if err != nil {
+		return ETCDv3Settings{}, microerror.Mask(err)
+	}
 
-		targetClusterRESTConfig, err := key.RESTConfig(ctx, ctrClient, cluster.clusterKey)
-		if err != nil {
-			return nil, microerror.Maskf(executionFailedError, "error fetching CAPI cluster rest config for cluster  %#q with error %#q", cluster.clusterKey.Name, err)
-		}
+	tlsConfig, err := u.getEtcdTLSCfg(ctx, provider, cluster)
+	if err != nil {
+		return ETCDv3Settings{}, microerror.Mask(err)
+	}
 
-		p := &proxy.Proxy{
-			Kind:       "pods",
-			Namespace:  metav1.NamespaceSystem,
-			KubeConfig: targetClusterRESTConfig,
-			TLSConfig:  tlsConfig,
-			Port:       2379,
-		}
+	p, err := provider.Dialer(ctx, cluster, tlsConfig)
+	if err != nil {
+		return ETCDv3Settings{}, microerror.Mask(err)
+	}
 
-		return p, nil
+	return ETCDv3Settings{TLSConfig: tlsConfig, Proxy: p}, nil
+}
 
-	} else {
-		// no proxy needed for legacy clusters
-		return nil, nil
+// getEtcdTLSCfg returns the TLS configuration of provider for cluster,
+// honouring the annotation disabling the verification of the server
+// certificate.
+func (u *Utils) getEtcdTLSCfg(ctx context.Context, provider ClusterProvider, cluster Cluster) (*tls.Config, error) {
+	insecure, err := InsecureSkipTLSVerifyFromAnnotations(cluster.annotations)
+	if err != nil {
+		u.logger.LogCtx(ctx, "level", "warning", "msg", fmt.Sprintf("Ignoring TLS verification annotation for cluster %s", cluster.clusterKey.Name), "reason", err)
+	}
+	if insecure {
+		u.logger.LogCtx(ctx, "level", "warning", "msg", fmt.Sprintf("Verification of the etcd server certificate of cluster %s is disabled", cluster.clusterKey.Name))
+	}
+
+	t, err := provider.TLSConfig(ctx, cluster, insecure)
+	if err != nil {
+		return nil, microerror.Mask(err)
 	}
-}
 
-// Fetch ETCD client certs for CAPI cluster.
-func (u *Utils) getCAPIEtcdTLSCfg(ctx context.Context, clusterKey client.ObjectKey) (*tls.Config, error) {
-	ctrlClient := u.K8sClient.CtrlClient()
+	return t, nil
+}
 
-	etcdCerts := &v1.Secret{}
-	etcdCertsObjectKey := client.ObjectKey{
-		Namespace: clusterKey.Namespace,
-		Name:      fmt.Sprintf("%s-etcd", clusterKey.Name),
+// Fetch ETCD client certs of the clusters of the Giant Swarm providers.
+func (u *Utils) getLegacyEtcdTLSCfg(ctx context.Context, clusterKey client.ObjectKey, insecure bool) (*tls.Config, error) {
+	k8sClient := u.K8sClient.CtrlClient()
+	secrets := v1.SecretList{}
+	err := k8sClient.List(ctx, &secrets, client.MatchingLabels{
+		label.Cluster:    clusterKey.Name,
+		certificateLabel: certificateLabelValue,
+	})
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error getting etcd client certificates for guest cluster %#q with error %#q", clusterKey.Name, err)
 	}
-	if err := ctrlClient.Get(ctx, etcdCertsObjectKey, etcdCerts); err != nil {
-		return nil, microerror.Mask(err)
+
+	if len(secrets.Items) == 0 {
+		return nil, microerror.Maskf(missingTLSSecretError, "no secret with %s=%q and %s=%q", label.Cluster, clusterKey.Name, certificateLabel, certificateLabelValue)
 	}
-	crtData, ok := etcdCerts.Data[secret.TLSCrtDataName]
-	if !ok {
-		return nil, microerror.Maskf(executionFailedError, "etcd tls crt does not exist for cluster %s/%s", clusterKey.Namespace, clusterKey.Name)
+	if len(secrets.Items) != 1 {
+		return nil, microerror.Maskf(executionFailedError, "expected exactly 1 secret with %s=%q and %s=%q, got %d", label.Cluster, clusterKey.Name, certificateLabel, certificateLabelValue, len(secrets.Items))
 	}
-	keyData, ok := etcdCerts.Data[secret.TLSKeyDataName]
-	if !ok {
-		return nil, microerror.Maskf(executionFailedError, "etcd tls key does not exist for cluster %s/%s", clusterKey.Namespace, clusterKey.Name)
+
+	s := secrets.Items[0]
+
+	source := certs.SecretSource{
+		Client:  k8sClient,
+		Secret:  client.ObjectKeyFromObject(&s),
+		CAKey:   "ca",
+		CertKey: "crt",
+		KeyKey:  "key",
 	}
 
-	tlsConfig, err := key.PrepareTLSConfig(crtData, crtData, keyData)
+	// Legacy etcd endpoints are dialed by the DNS name in their certificate.
+	tlsConfig, err := u.secretTLSConfig(ctx, clusterKey.Name, source, nil, insecure)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
@@ -289,160 +448,57 @@ func (u *Utils) getCAPIEtcdTLSCfg(ctx context.Context, clusterKey client.ObjectK
 	return tlsConfig, nil
 }
 
-// Fetch guest cluster ETCD endpoint.
-func (u *Utils) getEtcdEndpoint(ctx context.Context, cluster Cluster) (string, error) {
-	var etcdEndpoint string
-	crdClient := u.K8sClient.CtrlClient()
-
-	switch cluster.provider {
-	case awsCAPI:
-		{
-			crd := v1alpha3.AWSCluster{}
-			err := crdClient.Get(ctx, cluster.clusterKey, &crd)
-			if err != nil {
-				return "", microerror.Maskf(executionFailedError, "error getting aws crd for guest cluster %#q with error %#q", cluster.clusterKey.Name, err)
-			}
-			if crd.Spec.Cluster.DNS.Domain == "" {
-				return "", microerror.Maskf(executionFailedError, "awscluster %#q does not have any cluster domain set in spec.cluster.dns.domain", cluster.clusterKey.Name)
-			}
-			etcdEndpoint = AwsCAPIEtcdEndpoint(cluster.clusterKey.Name, crd.Spec.Cluster.DNS.Domain)
-			break
-		}
-	case azure:
-		{
-			crd := providerv1alpha1.AzureConfig{}
-			err := crdClient.Get(ctx, cluster.clusterKey, &crd)
-			if err != nil {
-				return "", microerror.Maskf(executionFailedError, "error getting azure crd for guest cluster %#q with error %#q", cluster.clusterKey.Name, err)
-			}
-			etcdEndpoint = AzureEtcdEndpoint(crd.Spec.Cluster.Etcd.Domain)
-			break
-		}
-	case kvm:
-		{
-			crd := providerv1alpha1.KVMConfig{}
-			err := crdClient.Get(ctx, cluster.clusterKey, &crd)
-			if err != nil {
-				return "", microerror.Maskf(executionFailedError, "error getting kvm crd for guest cluster %#q with error %#q", cluster.clusterKey.Name, err)
-			}
-			etcdEndpoint = KVMEtcdEndpoint(crd.Spec.Cluster.Etcd.Domain)
-			break
-		}
-	case CAPI:
-		{
-			// for CAPI endpoint we need to fetch workload cluster k8s client and look for etcd pods
-			targetClusterRESTConfig, err := key.RESTConfig(ctx, crdClient, cluster.clusterKey)
-			if err != nil {
-				return "", microerror.Maskf(executionFailedError, "error fetching CAPI cluster rest config for cluster  %#q with error %#q", cluster.clusterKey.Name, err)
-			}
-
-			targetCtrlClient, err := key.GetCtrlClient(targetClusterRESTConfig)
-			if err != nil {
-				return "", microerror.Maskf(executionFailedError, "error creating CAPI cluster kubernetes client for cluster  %#q with error %#q", cluster.clusterKey.Name, err)
-			}
-
-			// Specify the label for etcd pods
-			labelSelector := client.MatchingLabels(map[string]string{EtcdLabelComponentKey: EtcdLabelComponentValue, EtcdLabelTierKey: EtcdLabelTierValue})
+// secretTLSConfig returns a TLS configuration presenting the client
+// certificate of source, reloaded when used for longer than
+// secretCertsMaxAge, and verifying the server certificate against its CA
+// unless insecure is set. The configuration is reused for discoveryTTL.
+func (u *Utils) secretTLSConfig(ctx context.Context, name string, source certs.Source, serverName certs.ServerName, insecure bool) (*tls.Config, error) {
+	tlsConfig, err := u.cache.tlsConfig(name, source, insecure, func() (*tls.Config, error) {
+		return u.newSecretTLSConfig(ctx, name, source, serverName, insecure)
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 
-			// List pods with the specified label
-			podList := v1.PodList{}
-			err = targetCtrlClient.List(ctx, &podList, labelSelector)
-			if err != nil {
-				return "", microerror.Maskf(executionFailedError, "error creating CAPI cluster kubernetes client for cluster  %#q with error %#q", cluster.clusterKey.Name, err)
-			}
+	return tlsConfig, nil
+}
 
-			if len(podList.Items) == 0 {
-				return "", microerror.Maskf(executionFailedError, "error getting etcd endpoint, no etcd pods found in cluster  %#q with error %#q", cluster.clusterKey.Name, err)
-			}
+func (u *Utils) newSecretTLSConfig(ctx context.Context, name string, source certs.Source, serverName certs.ServerName, insecure bool) (*tls.Config, error) {
+	c := certs.Config{
+		Logger:             u.logger,
+		Source:             source,
+		Name:               name,
+		MaxAge:             secretCertsMaxAge,
+		ServerName:         serverName,
+		InsecureSkipVerify: insecure,
+	}
 
-			etcdEndpoint = podList.Items[0].Name
-			break
-		}
+	reloader, err := certs.New(ctx, c)
+	if apierrors.IsNotFound(err) {
+		return nil, microerror.Maskf(missingTLSSecretError, "error loading etcd client certificates of %#q with error %#q", name, err)
+	} else if certs.IsInvalidCertificate(err) {
+		return nil, microerror.Maskf(invalidTLSSecretError, "error loading etcd client certificates of %#q with error %#q", name, err)
+	} else if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "error loading etcd client certificates of %#q with error %#q", name, err)
 	}
 
-	// We already check for unknown provider at the start.
-	return etcdEndpoint, nil
+	return reloader.TLSConfig(), nil
 }
 
 // Fetch all workload clusters IDs in host cluster.
-func (u *Utils) getAllWorkloadClusters(ctx context.Context, crdCLient client.Client) ([]Cluster, error) {
+func (u *Utils) getAllWorkloadClusters(ctx context.Context) ([]Cluster, error) {
 	var clusterList []Cluster
 	anySuccess := false
 
-	// AWS Cluster API
-	{
-		crdList := v1alpha3.AWSClusterList{}
-		err := crdCLient.List(ctx, &crdList)
-		if err == nil {
-			anySuccess = true
-			for _, awsClusterObj := range crdList.Items {
-				// Only backup cluster if it was not marked for delete.
-				if awsClusterObj.DeletionTimestamp == nil {
-					clusterList = append(clusterList, Cluster{clusterKey: client.ObjectKey{Name: awsClusterObj.Name, Namespace: awsClusterObj.Namespace}, provider: awsCAPI})
-				}
-			}
-		} else if isMissingCRDError(err) {
-			// ignore missing CRD/KIND error as its expected that single MC do not have all provider CRs
-		} else {
-			u.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Error listing AWSClusters: %s", err))
-		}
-	}
-
-	// Azure
-	{
-		crdList := providerv1alpha1.AzureConfigList{}
-		err := crdCLient.List(ctx, &crdList)
+	for _, provider := range u.providers {
+		clusters, err := provider.List(ctx)
 		if err == nil {
 			anySuccess = true
-			for _, azureConfig := range crdList.Items {
-				// Only backup cluster if it was not marked for delete.
-				if azureConfig.DeletionTimestamp == nil {
-					clusterList = append(clusterList, Cluster{clusterKey: client.ObjectKey{Name: azureConfig.Name, Namespace: azureConfig.Namespace}, provider: azure})
-				}
-			}
+			clusterList = append(clusterList, clusters...)
 		} else if isMissingCRDError(err) {
 			// ignore missing CRD/KIND error as its expected that single MC do not have all provider CRs
 		} else {
-			u.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Error listing AzureConfigs: %s", err))
-		}
-	}
-
-	// KVM
-	{
-		crdList := providerv1alpha1.KVMConfigList{}
-		err := crdCLient.List(ctx, &crdList)
-		if err == nil {
-			anySuccess = true
-			for _, kvmConfig := range crdList.Items {
-				// Only backup cluster if it was not marked for delete.
-				if kvmConfig.DeletionTimestamp == nil {
-					clusterList = append(clusterList, Cluster{clusterKey: client.ObjectKey{Name: kvmConfig.Name, Namespace: kvmConfig.Namespace}, provider: kvm})
-				}
-			}
-		} else if isMissingCRDError(err) {
-			// ignore missing CRD/KIND error as its expected that single MC do not have all provider CRs
-		} else {
-			u.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Error listing KVMConfigs: %s", err))
-		}
-	}
-
-	// CAPI
-	{
-		crdList := capi.ClusterList{}
-		err := crdCLient.List(ctx, &crdList)
-		if err == nil {
-			anySuccess = true
-			for _, cluster := range crdList.Items {
-				// Only backup cluster if it was not marked for delete.
-				// and if the control and infrastructure is ready
-				if cluster.DeletionTimestamp == nil &&
-					cluster.Status.Initialization.ControlPlaneInitialized != nil && *cluster.Status.Initialization.ControlPlaneInitialized &&
-					cluster.Status.Initialization.InfrastructureProvisioned != nil && *cluster.Status.Initialization.InfrastructureProvisioned {
-					clusterList = append(clusterList, Cluster{clusterKey: client.ObjectKey{Name: cluster.Name, Namespace: cluster.Namespace}, provider: CAPI})
-				}
-			}
-		} else {
-			u.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Error listing CAPI Clusters: %s", err))
+			u.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Error listing %s clusters: %s", provider.Name(), err))
 		}
 	}
 
@@ -455,6 +511,27 @@ func (u *Utils) getAllWorkloadClusters(ctx context.Context, crdCLient client.Cli
 	return clusterList, nil
 }
 
+// failedInstance returns the instance of a cluster which can not be backed up
+// because of err.
+func failedInstance(cluster Cluster, err error, fallback string) ETCDInstance {
+	return ETCDInstance{
+		Name:    cluster.clusterKey.Name,
+		Failure: newDiscoveryFailure(err, fallback),
+
+		cluster: &cluster,
+	}
+}
+
+func hasInstance(instances []ETCDInstance, name string) bool {
+	for _, i := range instances {
+		if i.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
 func stringVersionCmp(versionStr string, def *semver.Version, reference *semver.Version) (bool, error) {
 	var version *semver.Version
 	var err error
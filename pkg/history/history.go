@@ -0,0 +1,276 @@
+// Package history keeps track of the latest backup attempt and the latest
+// successful backup of every cluster. Contrary to the ETCDBackup CRs, which
+// are deleted after a few days, the history survives as long as the cluster
+// is backed up. It is persisted in a ConfigMap so it survives restarts of the
+// operator too.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	configMapKey = "history.json"
+
+	defaultMaxAge          = 30 * 24 * time.Hour
+	defaultPersistInterval = time.Minute
+)
+
+type Config struct {
+	Client client.Client
+	Logger micrologger.Logger
+
+	// Name and Namespace of the ConfigMap the history is persisted in.
+	Name      string
+	Namespace string
+
+	// MaxAge is the age after which the records of a cluster which is not
+	// backed up anymore are dropped. Defaults to 30 days.
+	MaxAge time.Duration
+	// PersistInterval is how often changes are written to the ConfigMap.
+	// Defaults to one minute.
+	PersistInterval time.Duration
+}
+
+type History struct {
+	client client.Client
+	logger micrologger.Logger
+
+	name            string
+	namespace       string
+	maxAge          time.Duration
+	persistInterval time.Duration
+
+	mutex   sync.RWMutex
+	dirty   bool
+	records map[recordKey]Record
+}
+
+func New(config Config) (*History, error) {
+	if config.Client == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Client must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Name == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Name must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = defaultMaxAge
+	}
+	if config.PersistInterval == 0 {
+		config.PersistInterval = defaultPersistInterval
+	}
+
+	h := &History{
+		client: config.Client,
+		logger: config.Logger,
+
+		name:            config.Name,
+		namespace:       config.Namespace,
+		maxAge:          config.MaxAge,
+		persistInterval: config.PersistInterval,
+
+		records: map[recordKey]Record{},
+	}
+
+	return h, nil
+}
+
+// Observe records the outcome of an instance backup. Skipped backups are
+// ignored, every other terminal state counts as an attempt, and completed
+// backups also count as a success. Entries older than the ones already
+// recorded are ignored, so the history can be seeded in any order.
+func (h *History) Observe(cluster string, version string, entry Entry) {
+	if entry.Status == entryStatusSkipped {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	k := recordKey{cluster: cluster, version: version}
+	r, ok := h.records[k]
+	if !ok {
+		r = Record{Cluster: cluster, Version: version}
+	}
+
+	changed := false
+	if r.LatestAttempt == nil || !entry.FinishedTimestamp.Before(r.LatestAttempt.FinishedTimestamp) {
+		e := entry
+		r.LatestAttempt = &e
+		changed = true
+	}
+	if entry.Status == entryStatusCompleted && (r.LatestSuccess == nil || !entry.FinishedTimestamp.Before(r.LatestSuccess.FinishedTimestamp)) {
+		e := entry
+		r.LatestSuccess = &e
+		changed = true
+	}
+
+	if changed {
+		h.records[k] = r
+		h.dirty = true
+	}
+}
+
+// Records returns a copy of all records sorted by cluster and version.
+func (h *History) Records() []Record {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	records := make([]Record, 0, len(h.records))
+	for _, r := range h.records {
+		records = append(records, r)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Cluster != records[j].Cluster {
+			return records[i].Cluster < records[j].Cluster
+		}
+		return records[i].Version < records[j].Version
+	})
+
+	return records
+}
+
+// Empty tells whether nothing has been recorded yet.
+func (h *History) Empty() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return len(h.records) == 0
+}
+
+// Load reads the history persisted in the ConfigMap. A missing ConfigMap is
+// not an error, the history simply starts empty.
+func (h *History) Load(ctx context.Context) error {
+	cm := &corev1.ConfigMap{}
+	err := h.client.Get(ctx, client.ObjectKey{Name: h.name, Namespace: h.namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var records []Record
+	if data := cm.Data[configMapKey]; data != "" {
+		err = json.Unmarshal([]byte(data), &records)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for _, r := range records {
+		if r.LatestAttempt != nil {
+			h.Observe(r.Cluster, r.Version, *r.LatestAttempt)
+		}
+		if r.LatestSuccess != nil {
+			h.Observe(r.Cluster, r.Version, *r.LatestSuccess)
+		}
+	}
+
+	return nil
+}
+
+// Persist writes the history to the ConfigMap if it changed since it was
+// last persisted. Records of clusters which have not been backed up for
+// longer than MaxAge are dropped.
+func (h *History) Persist(ctx context.Context) error {
+	h.mutex.Lock()
+	if !h.dirty {
+		h.mutex.Unlock()
+		return nil
+	}
+	for k, r := range h.records {
+		if time.Since(r.lastSeen()) > h.maxAge {
+			delete(h.records, k)
+		}
+	}
+	h.dirty = false
+	h.mutex.Unlock()
+
+	data, err := json.Marshal(h.Records())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = h.writeConfigMap(ctx, string(data))
+	if err != nil {
+		h.mutex.Lock()
+		h.dirty = true
+		h.mutex.Unlock()
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Boot persists the history periodically until ctx is done.
+func (h *History) Boot(ctx context.Context) {
+	ticker := time.NewTicker(h.persistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := h.Persist(ctx)
+			if err != nil {
+				h.logger.LogCtx(ctx, "level", "error", "message", "failed to persist backup history", "stack", microerror.JSON(err))
+			}
+		}
+	}
+}
+
+func (h *History) writeConfigMap(ctx context.Context, data string) error {
+	cm := &corev1.ConfigMap{}
+	err := h.client.Get(ctx, client.ObjectKey{Name: h.name, Namespace: h.namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      h.name,
+				Namespace: h.namespace,
+			},
+			Data: map[string]string{
+				configMapKey: data,
+			},
+		}
+
+		err = h.client.Create(ctx, cm)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[configMapKey] = data
+
+	err = h.client.Update(ctx, cm)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package history
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_History_Observe(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	completed := func(age time.Duration) Entry {
+		return Entry{Status: "Completed", FinishedTimestamp: now.Add(-age), Filename: "backup-" + age.String()}
+	}
+	failed := func(age time.Duration) Entry {
+		return Entry{Status: "Failed", FinishedTimestamp: now.Add(-age), LatestError: "failed " + age.String()}
+	}
+
+	testCases := []struct {
+		name     string
+		observed []Entry
+		expected Record
+	}{
+		{
+			name:     "case 0: single success is both latest attempt and latest success",
+			observed: []Entry{completed(time.Hour)},
+			expected: Record{Cluster: "c", Version: "V3", LatestAttempt: ptr(completed(time.Hour)), LatestSuccess: ptr(completed(time.Hour))},
+		},
+		{
+			name:     "case 1: failure after success keeps the success",
+			observed: []Entry{completed(2 * time.Hour), failed(time.Hour)},
+			expected: Record{Cluster: "c", Version: "V3", LatestAttempt: ptr(failed(time.Hour)), LatestSuccess: ptr(completed(2 * time.Hour))},
+		},
+		{
+			name:     "case 2: older entries observed later are ignored",
+			observed: []Entry{failed(time.Hour), completed(3 * time.Hour), completed(2 * time.Hour)},
+			expected: Record{Cluster: "c", Version: "V3", LatestAttempt: ptr(failed(time.Hour)), LatestSuccess: ptr(completed(2 * time.Hour))},
+		},
+		{
+			name:     "case 3: skipped backups are ignored",
+			observed: []Entry{completed(2 * time.Hour), {Status: "Skipped", FinishedTimestamp: now}},
+			expected: Record{Cluster: "c", Version: "V3", LatestAttempt: ptr(completed(2 * time.Hour)), LatestSuccess: ptr(completed(2 * time.Hour))},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			h := newTestHistory(t)
+			for _, e := range tc.observed {
+				h.Observe("c", "V3", e)
+			}
+
+			records := h.Records()
+			if len(records) != 1 {
+				t.Fatalf("expected 1 record, got %d", len(records))
+			}
+			if !cmp.Equal(records[0], tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, records[0]))
+			}
+		})
+	}
+}
+
+func Test_History_PersistAndLoad(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().Build()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	h := newTestHistoryWithClient(t, c)
+	h.Observe("a", "V3", Entry{Status: "Completed", FinishedTimestamp: now, BackupFileSize: 42})
+	h.Observe("b", "V3", Entry{Status: "Failed", FinishedTimestamp: now, LatestError: "timeout error: boom", ErrorClass: "timeout"})
+	// Records of clusters not backed up for longer than the max age are dropped.
+	h.Observe("gone", "V3", Entry{Status: "Completed", FinishedTimestamp: now.Add(-60 * 24 * time.Hour)})
+
+	err := h.Persist(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	loaded := newTestHistoryWithClient(t, c)
+	err = loaded.Load(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	if !cmp.Equal(loaded.Records(), h.Records()) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(h.Records(), loaded.Records()))
+	}
+	if len(loaded.Records()) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(loaded.Records()))
+	}
+
+	// Persisting again updates the existing ConfigMap.
+	h.Observe("a", "V3", Entry{Status: "Failed", FinishedTimestamp: now.Add(time.Minute)})
+	err = h.Persist(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+}
+
+func newTestHistory(t *testing.T) *History {
+	return newTestHistoryWithClient(t, fake.NewClientBuilder().Build())
+}
+
+func newTestHistoryWithClient(t *testing.T, c client.Client) *History {
+	h, err := New(Config{
+		Client:    c,
+		Logger:    microloggertest.New(),
+		Name:      "etcd-backup-operator-history",
+		Namespace: "giantswarm",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	return h
+}
+
+func ptr(e Entry) *Entry {
+	return &e
+}
@@ -0,0 +1,51 @@
+package history
+
+import "time"
+
+const (
+	entryStatusCompleted = "Completed"
+	entryStatusSkipped   = "Skipped"
+)
+
+// Entry is the outcome of a single instance backup, as reported in the
+// ETCDBackup status.
+type Entry struct {
+	Status            string    `json:"status"`
+	StartedTimestamp  time.Time `json:"startedTimestamp"`
+	FinishedTimestamp time.Time `json:"finishedTimestamp"`
+	LatestError       string    `json:"latestError,omitempty"`
+	CreationTime      int64     `json:"creationTime,omitempty"`
+	EncryptionTime    int64     `json:"encryptionTime,omitempty"`
+	UploadTime        int64     `json:"uploadTime,omitempty"`
+	BackupFileSize    int64     `json:"backupFileSize,omitempty"`
+	Filename          string    `json:"filename,omitempty"`
+	// ErrorClass is the class of LatestError as exposed in metrics, e.g.
+	// "timeout". It is set when the backup failed.
+	ErrorClass string `json:"errorClass,omitempty"`
+}
+
+// Record holds the latest attempt and the latest success of the backups of a
+// cluster for a given etcd version.
+type Record struct {
+	Cluster       string `json:"cluster"`
+	Version       string `json:"version"`
+	LatestAttempt *Entry `json:"latestAttempt,omitempty"`
+	LatestSuccess *Entry `json:"latestSuccess,omitempty"`
+}
+
+type recordKey struct {
+	cluster string
+	version string
+}
+
+func (r Record) lastSeen() time.Time {
+	var t time.Time
+	if r.LatestAttempt != nil {
+		t = r.LatestAttempt.FinishedTimestamp
+	}
+	if r.LatestSuccess != nil && r.LatestSuccess.FinishedTimestamp.After(t) {
+		t = r.LatestSuccess.FinishedTimestamp
+	}
+
+	return t
+}
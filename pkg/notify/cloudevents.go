@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsTypePrefix  = "io.giantswarm.etcdbackup."
+	cloudEventsSource      = "etcd-backup-operator"
+)
+
+// cloudEvents posts the event as a CloudEvent in binary content mode, i.e.
+// the attributes are sent as ce-* headers and the body is the event data.
+type cloudEvents struct {
+	client  *http.Client
+	payload payload
+	url     string
+}
+
+func (c *cloudEvents) Name() string {
+	return SinkTypeCloudEvents
+}
+
+func (c *cloudEvents) Send(ctx context.Context, event Event) error {
+	body, err := c.payload.render(event)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	subject := event.Backup
+	if event.Cluster != "" {
+		subject = event.Cluster
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("ce-specversion", cloudEventsSpecVersion)
+	header.Set("ce-id", hex.EncodeToString(id))
+	header.Set("ce-type", cloudEventsTypePrefix+event.Type)
+	header.Set("ce-source", cloudEventsSource+"/"+event.Installation)
+	header.Set("ce-subject", subject)
+	header.Set("ce-time", event.Time.UTC().Format(time.RFC3339))
+
+	err = post(ctx, c.client, c.url, header, body)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
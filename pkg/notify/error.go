@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var deliveryFailedError = &microerror.Error{
+	Kind: "deliveryFailedError",
+}
+
+// IsDeliveryFailed asserts deliveryFailedError.
+func IsDeliveryFailed(err error) bool {
+	return microerror.Cause(err) == deliveryFailedError
+}
@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+)
+
+func post(ctx context.Context, client *http.Client, url string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return microerror.Maskf(deliveryFailedError, "POST %s returned status %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+// Package notify delivers backup outcomes to humans through pluggable sinks:
+// generic webhooks signed with HMAC, Slack-compatible incoming webhooks and
+// CloudEvents over HTTP.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+const (
+	defaultDedupWindow = time.Hour
+	defaultTimeout     = 10 * time.Second
+)
+
+type Config struct {
+	Logger micrologger.Logger
+	Sinks  []SinkConfig
+
+	// DedupWindow is the period in which identical events are only sent
+	// once. Defaults to one hour.
+	DedupWindow time.Duration
+	// HTTPClient is used by all sinks. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// States are the global states of an ETCDBackup whose transitions are
+	// notified. Empty means all transitions are notified.
+	States []string
+	// Timeout bounds the delivery to a single sink. Defaults to ten seconds.
+	Timeout time.Duration
+}
+
+type Notifier struct {
+	logger micrologger.Logger
+	sinks  []Sink
+
+	dedupWindow time.Duration
+	states      map[string]bool
+	timeout     time.Duration
+
+	mutex sync.Mutex
+	sent  map[string]time.Time
+}
+
+func New(config Config) (*Notifier, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.DedupWindow == 0 {
+		config.DedupWindow = defaultDedupWindow
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	var sinks []Sink
+	for i, c := range config.Sinks {
+		if c.URL == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Sinks[%d].URL must not be empty", config, i)
+		}
+
+		p, err := newPayload(fmt.Sprintf("%s-%d", c.Type, i), c.Template)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		switch c.Type {
+		case SinkTypeWebhook:
+			sinks = append(sinks, &webhook{client: config.HTTPClient, payload: p, secret: []byte(c.Secret), url: c.URL})
+		case SinkTypeSlack:
+			if c.Template == "" {
+				p, _ = newPayload(fmt.Sprintf("%s-%d", c.Type, i), defaultSlackTemplate)
+			}
+			sinks = append(sinks, &slack{client: config.HTTPClient, payload: p, url: c.URL})
+		case SinkTypeCloudEvents:
+			sinks = append(sinks, &cloudEvents{client: config.HTTPClient, payload: p, url: c.URL})
+		default:
+			return nil, microerror.Maskf(invalidConfigError, "%T.Sinks[%d].Type %#q is unknown", config, i, c.Type)
+		}
+	}
+
+	states := map[string]bool{}
+	for _, s := range config.States {
+		states[s] = true
+	}
+
+	n := &Notifier{
+		logger: config.Logger,
+		sinks:  sinks,
+
+		dedupWindow: config.DedupWindow,
+		states:      states,
+		timeout:     config.Timeout,
+
+		sent: map[string]time.Time{},
+	}
+
+	return n, nil
+}
+
+// ParseSinks decodes the JSON list of sink configurations.
+func ParseSinks(data string) ([]SinkConfig, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	var sinks []SinkConfig
+	err := json.Unmarshal([]byte(data), &sinks)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "sinks must be a JSON list: %s", err)
+	}
+
+	return sinks, nil
+}
+
+// Notify sends the event to all sinks unless it is filtered or an identical
+// event was sent within the deduplication window. Delivery failures are
+// logged, they must never fail a backup.
+func (n *Notifier) Notify(ctx context.Context, event Event) {
+	if len(n.sinks) == 0 {
+		return
+	}
+	if event.Type == EventTypeStateChanged && len(n.states) > 0 && !n.states[event.State] {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if n.isDuplicate(event) {
+		n.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("skipping duplicate %s notification", event.Type))
+		return
+	}
+
+	delivered := false
+	for _, s := range n.sinks {
+		err := n.send(ctx, s, event)
+		if err != nil {
+			n.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to send %s notification to %s sink", event.Type, s.Name()), "stack", microerror.JSON(err))
+			continue
+		}
+		delivered = true
+	}
+
+	// Events no sink received are sent again when they happen again.
+	if delivered {
+		n.markSent(event)
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, s Sink, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	err := s.Send(ctx, event)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// isDuplicate tells whether an identical event was sent within the
+// deduplication window.
+func (n *Notifier) isDuplicate(event Event) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for k, t := range n.sent {
+		if event.Time.Sub(t) > n.dedupWindow {
+			delete(n.sent, k)
+		}
+	}
+
+	_, ok := n.sent[dedupKey(event)]
+
+	return ok
+}
+
+// markSent remembers that the event was delivered to at least one sink.
+func (n *Notifier) markSent(event Event) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.sent[dedupKey(event)] = event.Time
+}
+
+// dedupKey identifies identical events. Failures of a cluster are identical
+// regardless of the ETCDBackup they happened in, so a cluster failing the
+// same way in every scheduled backup does not flood the sinks.
+func dedupKey(event Event) string {
+	if event.Type == EventTypeInstanceFailed {
+		return fmt.Sprintf("%s/%s/%s", event.Type, event.Cluster, event.Error)
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s", event.Type, event.Backup, event.PreviousState, event.State)
+}
@@ -0,0 +1,236 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+type receiver struct {
+	mutex    sync.Mutex
+	requests []request
+	// failures is the number of requests answered with an error before
+	// requests are accepted.
+	failures int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.requests = append(r.requests, request{header: req.Header, body: body})
+	if len(r.requests) <= r.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func newReceiver(t *testing.T) (*receiver, string) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return r, server.URL
+}
+
+func testEvent() Event {
+	return Event{
+		Type:         EventTypeInstanceFailed,
+		Installation: "gauss",
+		Backup:       "etcd-backup-20240501120000",
+		State:        "Failed",
+		Cluster:      "abc12",
+		Error:        "timeout error: upload exceeded 30m0s",
+		Time:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func Test_Notifier_Sinks(t *testing.T) {
+	testCases := []struct {
+		name   string
+		sink   SinkConfig
+		verify func(t *testing.T, r request)
+	}{
+		{
+			name: "case 0: webhook signs the JSON encoded event",
+			sink: SinkConfig{Type: SinkTypeWebhook, Secret: "s3cr3t"},
+			verify: func(t *testing.T, r request) {
+				if r.header.Get(SignatureHeader) != Sign([]byte("s3cr3t"), r.body) {
+					t.Fatalf("signature == %q, want %q", r.header.Get(SignatureHeader), Sign([]byte("s3cr3t"), r.body))
+				}
+				if r.header.Get(EventTypeHeader) != EventTypeInstanceFailed {
+					t.Fatalf("event type == %q, want %q", r.header.Get(EventTypeHeader), EventTypeInstanceFailed)
+				}
+				var e Event
+				err := json.Unmarshal(r.body, &e)
+				if err != nil {
+					t.Fatalf("error == %#v, want nil", err)
+				}
+				if e.Cluster != "abc12" {
+					t.Fatalf("cluster == %q, want %q", e.Cluster, "abc12")
+				}
+			},
+		},
+		{
+			name: "case 1: webhook renders the template",
+			sink: SinkConfig{Type: SinkTypeWebhook, Template: `{"summary":"{{ .Cluster }} {{ .State }}"}`},
+			verify: func(t *testing.T, r request) {
+				if string(r.body) != `{"summary":"abc12 Failed"}` {
+					t.Fatalf("body == %q, want %q", r.body, `{"summary":"abc12 Failed"}`)
+				}
+				if r.header.Get(SignatureHeader) != "" {
+					t.Fatalf("signature == %q, want none", r.header.Get(SignatureHeader))
+				}
+			},
+		},
+		{
+			name: "case 2: slack sends the default text",
+			sink: SinkConfig{Type: SinkTypeSlack},
+			verify: func(t *testing.T, r request) {
+				var m map[string]string
+				err := json.Unmarshal(r.body, &m)
+				if err != nil {
+					t.Fatalf("error == %#v, want nil", err)
+				}
+				expected := ":x: etcd backup of cluster *abc12* failed on *gauss*: timeout error: upload exceeded 30m0s"
+				if m["text"] != expected {
+					t.Fatalf("text == %q, want %q", m["text"], expected)
+				}
+			},
+		},
+		{
+			name: "case 3: cloudevents sets the binary mode attributes",
+			sink: SinkConfig{Type: SinkTypeCloudEvents},
+			verify: func(t *testing.T, r request) {
+				for h, expected := range map[string]string{
+					"ce-specversion": "1.0",
+					"ce-type":        "io.giantswarm.etcdbackup.backup.instance_failed",
+					"ce-source":      "etcd-backup-operator/gauss",
+					"ce-subject":     "abc12",
+					"ce-time":        "2024-05-01T12:00:00Z",
+				} {
+					if r.header.Get(h) != expected {
+						t.Fatalf("%s == %q, want %q", h, r.header.Get(h), expected)
+					}
+				}
+				if r.header.Get("ce-id") == "" {
+					t.Fatalf("ce-id is empty")
+				}
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			r, url := newReceiver(t)
+			tc.sink.URL = url
+
+			n, err := New(Config{Logger: microloggertest.New(), Sinks: []SinkConfig{tc.sink}})
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			n.Notify(context.Background(), testEvent())
+
+			if len(r.requests) != 1 {
+				t.Fatalf("requests == %d, want 1", len(r.requests))
+			}
+			tc.verify(t, r.requests[0])
+		})
+	}
+}
+
+func Test_Notifier_Filtering(t *testing.T) {
+	r, url := newReceiver(t)
+
+	n, err := New(Config{
+		Logger:      microloggertest.New(),
+		Sinks:       []SinkConfig{{Type: SinkTypeWebhook, URL: url}},
+		DedupWindow: time.Hour,
+		States:      []string{"Completed", "Failed"},
+	})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	e := testEvent()
+	n.Notify(context.Background(), e)
+
+	// Same failure of the cluster in a later backup within the window.
+	e.Backup = "etcd-backup-20240501123000"
+	e.Time = e.Time.Add(30 * time.Minute)
+	n.Notify(context.Background(), e)
+
+	// Same failure after the window.
+	e.Time = e.Time.Add(2 * time.Hour)
+	n.Notify(context.Background(), e)
+
+	// Transition into a state which is not notified.
+	n.Notify(context.Background(), Event{Type: EventTypeStateChanged, Backup: "etcd-backup-20240501120000", PreviousState: "Pending", State: "V3BackupRunning"})
+
+	// Transition into a notified state.
+	n.Notify(context.Background(), Event{Type: EventTypeStateChanged, Backup: "etcd-backup-20240501120000", PreviousState: "V3BackupCompleted", State: "Completed"})
+
+	if len(r.requests) != 3 {
+		t.Fatalf("requests == %d, want 3", len(r.requests))
+	}
+}
+
+func Test_Notifier_FailedDelivery(t *testing.T) {
+	r, url := newReceiver(t)
+	r.failures = 1
+
+	n, err := New(Config{
+		Logger:      microloggertest.New(),
+		Sinks:       []SinkConfig{{Type: SinkTypeWebhook, URL: url}},
+		DedupWindow: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	// The failure is not delivered to any sink.
+	e := testEvent()
+	n.Notify(context.Background(), e)
+
+	// The same failure within the window is sent again, and is a duplicate
+	// once delivered.
+	e.Time = e.Time.Add(30 * time.Minute)
+	n.Notify(context.Background(), e)
+	e.Time = e.Time.Add(time.Minute)
+	n.Notify(context.Background(), e)
+
+	if len(r.requests) != 2 {
+		t.Fatalf("requests == %d, want 2", len(r.requests))
+	}
+}
+
+func Test_New_InvalidConfig(t *testing.T) {
+	for i, sink := range []SinkConfig{
+		{Type: "pager", URL: "http://localhost"},
+		{Type: SinkTypeWebhook},
+		{Type: SinkTypeSlack, URL: "http://localhost", Template: "{{ .Cluster "},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := New(Config{Logger: microloggertest.New(), Sinks: []SinkConfig{sink}})
+			if !IsInvalidConfig(err) {
+				t.Fatalf("error == %#v, want invalid config error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+)
+
+// slack posts the event to a Slack-compatible incoming webhook. The template
+// renders the message text.
+type slack struct {
+	client  *http.Client
+	payload payload
+	url     string
+}
+
+func (s *slack) Name() string {
+	return SinkTypeSlack
+}
+
+func (s *slack) Send(ctx context.Context, event Event) error {
+	text, err := s.payload.render(event)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	body, err := json.Marshal(map[string]string{"text": string(text)})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	err = post(ctx, s.client, s.url, header, body)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+
+	"github.com/giantswarm/microerror"
+)
+
+const defaultSlackTemplate = `{{ if eq .Type "backup.instance_failed" }}:x: etcd backup of cluster *{{ .Cluster }}* failed on *{{ .Installation }}*: {{ .Error }}{{ else }}etcd backup *{{ .Backup }}* on *{{ .Installation }}* is now *{{ .State }}*{{ if .PreviousState }} (was {{ .PreviousState }}){{ end }}{{ end }}`
+
+type payload struct {
+	template *template.Template
+}
+
+func newPayload(name string, text string) (payload, error) {
+	if text == "" {
+		return payload{}, nil
+	}
+
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return payload{}, microerror.Maskf(invalidConfigError, "template of sink %#q is invalid: %s", name, err)
+	}
+
+	return payload{template: t}, nil
+}
+
+// render renders the template with the event, or encodes the event as JSON
+// when no template is configured.
+func (p payload) render(event Event) ([]byte, error) {
+	if p.template == nil {
+		b, err := json.Marshal(event)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return b, nil
+	}
+
+	var buf bytes.Buffer
+	err := p.template.Execute(&buf, event)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return buf.Bytes(), nil
+}
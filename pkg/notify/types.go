@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// EventTypeStateChanged is sent when the global state of an ETCDBackup
+	// changes.
+	EventTypeStateChanged = "backup.state_changed"
+	// EventTypeInstanceFailed is sent when the backup of a single cluster
+	// failed.
+	EventTypeInstanceFailed = "backup.instance_failed"
+
+	SinkTypeCloudEvents = "cloudevents"
+	SinkTypeSlack       = "slack"
+	SinkTypeWebhook     = "webhook"
+)
+
+// Event describes a backup outcome. It is the data templates are rendered
+// with and the default payload of webhooks and CloudEvents.
+type Event struct {
+	Type          string    `json:"type"`
+	Installation  string    `json:"installation"`
+	Backup        string    `json:"backup"`
+	State         string    `json:"state"`
+	PreviousState string    `json:"previousState,omitempty"`
+	Cluster       string    `json:"cluster,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// Sink delivers events to a single destination.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event Event) error
+}
+
+// SinkConfig configures a sink. Secret is the HMAC key of webhooks and is
+// ignored by the other sinks. Template is a text/template rendered with the
+// Event; it replaces the request body of webhooks and CloudEvents and the
+// message text of Slack.
+type SinkConfig struct {
+	Type     string `json:"type"`
+	URL      string `json:"url"`
+	Secret   string `json:"secret,omitempty"`
+	Template string `json:"template,omitempty"`
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body
+	// prefixed with "sha256=".
+	SignatureHeader = "X-Etcd-Backup-Signature-256"
+	// EventTypeHeader carries the type of the event.
+	EventTypeHeader = "X-Etcd-Backup-Event"
+)
+
+// webhook posts the event to a generic HTTP endpoint. When a secret is
+// configured the body is signed so the receiver can authenticate it.
+type webhook struct {
+	client  *http.Client
+	payload payload
+	secret  []byte
+	url     string
+}
+
+func (w *webhook) Name() string {
+	return SinkTypeWebhook
+}
+
+func (w *webhook) Send(ctx context.Context, event Event) error {
+	body, err := w.payload.render(event)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(EventTypeHeader, event.Type)
+	if len(w.secret) > 0 {
+		header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+
+	err = post(ctx, w.client, w.url, header, body)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Sign returns the value of SignatureHeader for the given body.
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,41 @@
+package restore
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidMemberError = &microerror.Error{
+	Kind: "invalidMemberError",
+}
+
+// IsInvalidMember asserts invalidMemberError.
+func IsInvalidMember(err error) bool {
+	return microerror.Cause(err) == invalidMemberError
+}
+
+var notRestoredError = &microerror.Error{
+	Kind: "notRestoredError",
+}
+
+// IsNotRestored asserts notRestoredError.
+func IsNotRestored(err error) bool {
+	return microerror.Cause(err) == notRestoredError
+}
+
+var timeoutError = &microerror.Error{
+	Kind: "timeoutError",
+}
+
+// IsTimeout asserts timeoutError.
+func IsTimeout(err error) bool {
+	return microerror.Cause(err) == timeoutError
+}
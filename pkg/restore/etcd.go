@@ -0,0 +1,40 @@
+package restore
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/proxy"
+)
+
+// Status reads the status of the member running in pod through the
+// port-forward proxy.
+func Status(ctx context.Context, tlsConfig *tls.Config, p *proxy.Proxy, pod string) (MemberStatus, error) {
+	c, err := etcd.NewV3Client(pod, tlsConfig, p)
+	if err != nil {
+		return MemberStatus{}, microerror.Mask(err)
+	}
+	defer c.Close() //nolint:errcheck
+
+	s, err := c.Status(ctx, pod)
+	if err != nil {
+		return MemberStatus{}, microerror.Mask(err)
+	}
+
+	members, err := c.MemberList(ctx)
+	if err != nil {
+		return MemberStatus{}, microerror.Mask(err)
+	}
+
+	status := MemberStatus{
+		Pod:       pod,
+		ClusterID: s.Header.ClusterId,
+		Leader:    s.Leader,
+		Members:   len(members.Members),
+	}
+
+	return status, nil
+}
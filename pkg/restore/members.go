@@ -0,0 +1,112 @@
+package restore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	etcdContainer = "etcd"
+
+	defaultDataDir = "/var/lib/etcd"
+)
+
+// Member is an etcd member of a kubeadm control plane, running as a static
+// pod on a control plane node.
+type Member struct {
+	Name    string
+	Node    string
+	Pod     string
+	PeerURL string
+	DataDir string
+}
+
+// MembersFromPods reads the members from the flags of the etcd static pods
+// kubeadm generates. Members are sorted by name.
+func MembersFromPods(pods []corev1.Pod) ([]Member, error) {
+	var members []Member
+	for _, p := range pods {
+		m, err := memberFromPod(p)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		members = append(members, m)
+	}
+
+	if len(members) == 0 {
+		return nil, microerror.Maskf(invalidMemberError, "no etcd pods found")
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+
+	return members, nil
+}
+
+func memberFromPod(p corev1.Pod) (Member, error) {
+	var container *corev1.Container
+	for i := range p.Spec.Containers {
+		if p.Spec.Containers[i].Name == etcdContainer {
+			container = &p.Spec.Containers[i]
+		}
+	}
+	if container == nil {
+		return Member{}, microerror.Maskf(invalidMemberError, "pod %#q has no %#q container", p.Name, etcdContainer)
+	}
+	if p.Spec.NodeName == "" {
+		return Member{}, microerror.Maskf(invalidMemberError, "pod %#q is not scheduled to a node", p.Name)
+	}
+
+	flags := parseFlags(append(container.Command, container.Args...))
+
+	m := Member{
+		Name:    flags["name"],
+		Node:    p.Spec.NodeName,
+		Pod:     p.Name,
+		PeerURL: flags["initial-advertise-peer-urls"],
+		DataDir: flags["data-dir"],
+	}
+	if m.DataDir == "" {
+		m.DataDir = defaultDataDir
+	}
+	if m.Name == "" || m.PeerURL == "" {
+		return Member{}, microerror.Maskf(invalidMemberError, "pod %#q does not set --name and --initial-advertise-peer-urls", p.Name)
+	}
+	if strings.Contains(m.PeerURL, ",") {
+		return Member{}, microerror.Maskf(invalidMemberError, "pod %#q advertises more than one peer URL", p.Name)
+	}
+
+	return m, nil
+}
+
+// parseFlags reads the --flag=value arguments of a command.
+func parseFlags(args []string) map[string]string {
+	flags := map[string]string{}
+	for _, a := range args {
+		if !strings.HasPrefix(a, "--") {
+			continue
+		}
+
+		k, v, _ := strings.Cut(strings.TrimPrefix(a, "--"), "=")
+		flags[k] = v
+	}
+
+	return flags
+}
+
+// InitialCluster returns the --initial-cluster value of the restored
+// members.
+func InitialCluster(members []Member) string {
+	var pairs []string
+	for _, m := range members {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", m.Name, m.PeerURL))
+	}
+
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,80 @@
+package restore
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func etcdPod(node string, command ...string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd-" + node, Namespace: metav1.NamespaceSystem},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{
+				{Name: "etcd", Command: append([]string{"etcd"}, command...)},
+			},
+		},
+	}
+}
+
+func Test_MembersFromPods(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		pods                   []corev1.Pod
+		expectedMembers        []Member
+		expectedInitialCluster string
+		errorMatcher           func(error) bool
+	}{
+		{
+			name: "case 0: kubeadm pods, sorted by member name",
+			pods: []corev1.Pod{
+				etcdPod("cp-b", "--name=cp-b", "--initial-advertise-peer-urls=https://10.0.0.2:2380", "--data-dir=/var/lib/etcd"),
+				etcdPod("cp-a", "--name=cp-a", "--initial-advertise-peer-urls=https://10.0.0.1:2380"),
+			},
+			expectedMembers: []Member{
+				{Name: "cp-a", Node: "cp-a", Pod: "etcd-cp-a", PeerURL: "https://10.0.0.1:2380", DataDir: "/var/lib/etcd"},
+				{Name: "cp-b", Node: "cp-b", Pod: "etcd-cp-b", PeerURL: "https://10.0.0.2:2380", DataDir: "/var/lib/etcd"},
+			},
+			expectedInitialCluster: "cp-a=https://10.0.0.1:2380,cp-b=https://10.0.0.2:2380",
+		},
+		{
+			name:         "case 1: missing peer URL",
+			pods:         []corev1.Pod{etcdPod("cp-a", "--name=cp-a")},
+			errorMatcher: IsInvalidMember,
+		},
+		{
+			name:         "case 2: no pods",
+			errorMatcher: IsInvalidMember,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			members, err := MembersFromPods(tc.pods)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !cmp.Equal(members, tc.expectedMembers) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedMembers, members))
+			}
+			if ic := InitialCluster(members); ic != tc.expectedInitialCluster {
+				t.Fatalf("initial cluster == %q, want %q", ic, tc.expectedInitialCluster)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package restore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Plan describes how a backup is restored into the etcd members of a
+// cluster.
+type Plan struct {
+	// Restore is the name of the ETCDRestore.
+	Restore string
+	// Cluster is the name of the cluster.
+	Cluster string
+	// Backup is the key of the backup.
+	Backup string
+	// ClusterToken is the new initial cluster token.
+	ClusterToken string
+	// Members are the members to restore.
+	Members []Member
+	// SwapDelay is the time all members get between the creation of the
+	// swap pods and stopping etcd, so every pod is created before the API
+	// server of the cluster becomes unavailable.
+	SwapDelay time.Duration
+}
+
+// NewPlan plans the restore of backup into the members. The cluster token is
+// derived from the restore, so it is stable across reconciliations.
+func NewPlan(restore string, uid types.UID, cluster string, backup string, members []Member, swapDelay time.Duration) (Plan, error) {
+	if restore == "" {
+		return Plan{}, microerror.Maskf(invalidConfigError, "restore must not be empty")
+	}
+	if len(uid) < 8 {
+		return Plan{}, microerror.Maskf(invalidConfigError, "uid %#q is too short", uid)
+	}
+	if cluster == "" {
+		return Plan{}, microerror.Maskf(invalidConfigError, "cluster must not be empty")
+	}
+	if backup == "" {
+		return Plan{}, microerror.Maskf(invalidConfigError, "backup must not be empty")
+	}
+	if len(members) == 0 {
+		return Plan{}, microerror.Maskf(invalidConfigError, "members must not be empty")
+	}
+
+	p := Plan{
+		Restore:      restore,
+		Cluster:      cluster,
+		Backup:       backup,
+		ClusterToken: fmt.Sprintf("%s-%s", cluster, uid[:8]),
+		Members:      members,
+		SwapDelay:    swapDelay,
+	}
+
+	return p, nil
+}
+
+// RestoredDataDir is the directory the backup is restored into on the node
+// of the member, next to its data directory.
+func (p Plan) RestoredDataDir(m Member) string {
+	return m.DataDir + "-" + p.ClusterToken
+}
+
+// Steps describes the plan in human readable steps.
+func (p Plan) Steps() []string {
+	steps := []string{
+		fmt.Sprintf("Restore backup %s into the %d etcd members of cluster %s with the new initial cluster token %s.", p.Backup, len(p.Members), p.Cluster, p.ClusterToken),
+	}
+
+	for _, m := range p.Members {
+		steps = append(steps, fmt.Sprintf("Prepare member %s on node %s: download and verify a copy of the backup encrypted with a passphrase generated for the restore and restore it into %s with etcdutl, while etcd keeps running.", m.Name, m.Node, p.RestoredDataDir(m)))
+	}
+
+	steps = append(steps, fmt.Sprintf("Once all members are prepared, delete the copy of the backup and its passphrase, and create a swap pod on every node, which waits %s so all of them exist before the API server becomes unavailable.", p.SwapDelay))
+
+	for _, m := range p.Members {
+		steps = append(steps, fmt.Sprintf("Swap member %s on node %s: move the etcd static pod manifest out of the manifests directory, wait for etcd to stop, keep %s as %s, move %s to %s and move the manifest back.", m.Name, m.Node, m.DataDir, m.DataDir+"."+p.ClusterToken, p.RestoredDataDir(m), m.DataDir))
+	}
+
+	steps = append(steps,
+		"Wait for the API server to come back and verify through the port-forward proxy that all members are healthy, have a leader and formed a new etcd cluster.",
+		"Delete the pods created in the cluster. The previous data directories are kept on the nodes.",
+	)
+
+	return steps
+}
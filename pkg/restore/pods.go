@@ -0,0 +1,166 @@
+package restore
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Namespace is the namespace of the workload cluster the restore pods
+	// and secret are created in.
+	Namespace = metav1.NamespaceSystem
+
+	// LabelRestore is set to the name of the ETCDRestore on everything
+	// created in the workload cluster.
+	LabelRestore = "backup.giantswarm.io/restore"
+	// LabelStep is set to StepPrepare or StepSwap on the restore pods.
+	LabelStep = "backup.giantswarm.io/restore-step"
+
+	StepPrepare = "prepare"
+	StepSwap    = "swap"
+
+	// ManifestPath is the etcd static pod manifest kubeadm writes.
+	ManifestPath = "/etc/kubernetes/manifests/etcd.yaml"
+
+	// EnvEncryptionPassword is read by the restore command to decrypt the
+	// copy of the backup. It holds the passphrase generated for the restore,
+	// never the encryption password of the operator.
+	EnvEncryptionPassword = "ENCRYPTION_PASSWORD" // nolint: gosec
+
+	// hostRoot is where the host paths are mounted in the restore pods.
+	hostRoot = "/host"
+	// binary is the operator binary in its image.
+	binary = "/etcd-backup-operator"
+)
+
+// PodConfig configures the pods running the restore on the control plane
+// nodes.
+type PodConfig struct {
+	// Image is the image of the operator, which contains etcdutl.
+	Image string
+	// Secret is the name of the secret holding the passphrase of the copy
+	// of the backup.
+	Secret string
+}
+
+// SecretName returns the name of the secret holding the passphrase of the
+// copy of the backup in the workload cluster.
+func SecretName(restore string) string {
+	return "etcd-restore-" + restore
+}
+
+// ArtifactKey returns the key of the copy of the backup restored by the
+// ETCDRestore restore in namespace. The copy holds the etcd snapshot of the
+// backup, encrypted with a passphrase generated for the restore.
+func ArtifactKey(namespace string, restore string) string {
+	return fmt.Sprintf("etcd-restore-%s-%s.db.gz.enc", namespace, restore)
+}
+
+// PodName returns the name of the pod running a step of the restore on the
+// node of a member.
+func PodName(restore string, step string, m Member) string {
+	return fmt.Sprintf("etcd-restore-%s-%s-%s", restore, step, m.Node)
+}
+
+// PreparePod returns the pod which downloads the copy of the backup from
+// backupURL and restores it next to the data directory of the member, using
+// the restore command of the operator.
+func (p Plan) PreparePod(c PodConfig, m Member, backupURL string) *corev1.Pod {
+	pod := p.pod(c, StepPrepare, m)
+	pod.Spec.Containers[0].Args = []string{
+		"restore",
+		"--from", backupURL,
+		"--data-dir", hostRoot + p.RestoredDataDir(m),
+		"--name", m.Name,
+		"--initial-cluster", InitialCluster(p.Members),
+		"--initial-cluster-token", p.ClusterToken,
+		"--initial-advertise-peer-urls", m.PeerURL,
+	}
+	pod.Spec.Containers[0].Env = []corev1.EnvVar{
+		{
+			Name: EnvEncryptionPassword,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: c.Secret},
+					Key:                  EnvEncryptionPassword,
+				},
+			},
+		},
+	}
+
+	return pod
+}
+
+// SwapPod returns the pod which stops etcd on the node of a member at
+// notBefore and replaces its data directory with the restored one.
+func (p Plan) SwapPod(c PodConfig, m Member, notBefore time.Time) *corev1.Pod {
+	pod := p.pod(c, StepSwap, m)
+	pod.Spec.Containers[0].Args = []string{
+		"restore-member",
+		"--manifest", hostRoot + ManifestPath,
+		"--data-dir", hostRoot + m.DataDir,
+		"--restored-data-dir", hostRoot + p.RestoredDataDir(m),
+		"--suffix", p.ClusterToken,
+		"--not-before", notBefore.UTC().Format(time.RFC3339),
+	}
+	// The pod probes whether etcd stopped on the loopback interface of the
+	// node.
+	pod.Spec.HostNetwork = true
+
+	return pod
+}
+
+func (p Plan) pod(c PodConfig, step string, m Member) *corev1.Pod {
+	hostPathType := corev1.HostPathDirectory
+	root := int64(0)
+
+	volumes := []corev1.Volume{}
+	mounts := []corev1.VolumeMount{}
+	for i, dir := range []string{filepath.Dir(filepath.Dir(ManifestPath)), filepath.Dir(m.DataDir)} {
+		name := fmt.Sprintf("host-%d", i)
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: dir, Type: &hostPathType},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: hostRoot + dir})
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PodName(p.Restore, step, m),
+			Namespace: Namespace,
+			Labels: map[string]string{
+				LabelRestore: p.Restore,
+				LabelStep:    step,
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      m.Node,
+			RestartPolicy: corev1.RestartPolicyNever,
+			// Control plane nodes are tainted and the pods must run even if
+			// the node is not ready while etcd is down.
+			Tolerations:       []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			PriorityClassName: "system-node-critical",
+			Containers: []corev1.Container{
+				{
+					Name:                     step,
+					Image:                    c.Image,
+					Command:                  []string{binary},
+					VolumeMounts:             mounts,
+					TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					SecurityContext: &corev1.SecurityContext{
+						RunAsUser:  &root,
+						RunAsGroup: &root,
+					},
+				},
+			},
+			Volumes: volumes,
+		},
+	}
+}
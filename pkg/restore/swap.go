@@ -0,0 +1,129 @@
+package restore
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+// SwapOptions configure replacing the data directory of a member with the
+// restored one on its node.
+type SwapOptions struct {
+	// Manifest is the etcd static pod manifest.
+	Manifest string
+	// DataDir is the data directory of the member.
+	DataDir string
+	// RestoredDataDir is the directory the backup was restored into.
+	RestoredDataDir string
+	// Suffix is appended to the previous data directory and to the manifest
+	// while it is moved out of the manifests directory.
+	Suffix string
+	// NotBefore is the earliest time etcd is stopped.
+	NotBefore time.Time
+	// ClientAddress is probed until etcd stopped listening on it.
+	ClientAddress string
+	// StopTimeout bounds the time etcd takes to stop.
+	StopTimeout time.Duration
+}
+
+// Swap stops etcd by moving its static pod manifest out of the manifests
+// directory, replaces the data directory with the restored one and starts
+// etcd again by moving the manifest back. Every step is skipped when it
+// already happened, so Swap can be retried.
+func Swap(ctx context.Context, o SwapOptions) error {
+	if o.Manifest == "" || o.DataDir == "" || o.RestoredDataDir == "" || o.Suffix == "" {
+		return microerror.Maskf(invalidConfigError, "manifest, data directory, restored data directory and suffix must not be empty")
+	}
+
+	err := sleepUntil(ctx, o.NotBefore)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The kubelet runs every file in the manifests directory, so the
+	// manifest is parked next to it.
+	parked := filepath.Join(filepath.Dir(filepath.Dir(o.Manifest)), filepath.Base(o.Manifest)+"."+o.Suffix)
+	if exists(o.Manifest) {
+		err = os.Rename(o.Manifest, parked)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if o.ClientAddress != "" {
+		err = waitUntilClosed(ctx, o.ClientAddress, o.StopTimeout)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if exists(o.RestoredDataDir) {
+		if exists(o.DataDir) {
+			err = os.Rename(o.DataDir, o.DataDir+"."+o.Suffix)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		err = os.Rename(o.RestoredDataDir, o.DataDir)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	} else if !exists(o.DataDir + "." + o.Suffix) {
+		return microerror.Maskf(notRestoredError, "restored data directory %#q does not exist", o.RestoredDataDir)
+	}
+
+	if exists(parked) {
+		err = os.Rename(parked, o.Manifest)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return microerror.Mask(ctx.Err())
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// waitUntilClosed waits until nothing accepts connections on address.
+func waitUntilClosed(ctx context.Context, address string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := (&net.Dialer{Timeout: time.Second}).DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil
+		}
+		_ = conn.Close()
+
+		if time.Now().After(deadline) {
+			return microerror.Maskf(timeoutError, "etcd still listens on %s after %s", address, timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return microerror.Mask(ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
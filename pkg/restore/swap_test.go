@@ -0,0 +1,54 @@
+package restore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_Swap(t *testing.T) {
+	root := t.TempDir()
+	o := SwapOptions{
+		Manifest:        filepath.Join(root, "etc/kubernetes/manifests/etcd.yaml"),
+		DataDir:         filepath.Join(root, "var/lib/etcd"),
+		RestoredDataDir: filepath.Join(root, "var/lib/etcd-foo-1234abcd"),
+		Suffix:          "foo-1234abcd",
+		NotBefore:       time.Now().Add(-time.Minute),
+	}
+
+	for _, f := range []string{o.Manifest, filepath.Join(o.DataDir, "member/old"), filepath.Join(o.RestoredDataDir, "member/new")} {
+		err := os.MkdirAll(filepath.Dir(f), 0750)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(f, nil, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second run must be a no-op.
+	for i := 0; i < 2; i++ {
+		err := Swap(context.Background(), o)
+		if err != nil {
+			t.Fatalf("run %d: error == %#v, want nil", i, err)
+		}
+
+		for _, f := range []string{o.Manifest, filepath.Join(o.DataDir, "member/new"), filepath.Join(o.DataDir+".foo-1234abcd", "member/old")} {
+			if !exists(f) {
+				t.Fatalf("run %d: %s does not exist", i, f)
+			}
+		}
+		if exists(o.RestoredDataDir) {
+			t.Fatalf("run %d: %s still exists", i, o.RestoredDataDir)
+		}
+	}
+
+	o.Suffix = "bar-5678abcd"
+	err := Swap(context.Background(), o)
+	if !IsNotRestored(err) {
+		t.Fatalf("error == %#v, want matching", err)
+	}
+}
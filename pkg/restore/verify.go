@@ -0,0 +1,47 @@
+package restore
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+)
+
+// MemberStatus is the status etcd reports for a restored member.
+type MemberStatus struct {
+	Pod       string
+	ClusterID uint64
+	Leader    uint64
+	Members   int
+}
+
+// ClusterID formats an etcd cluster ID the way etcdctl prints it.
+func ClusterID(id uint64) string {
+	return fmt.Sprintf("%x", id)
+}
+
+// Verify checks that all members reported their status, agree on a new
+// cluster and on its leader, and see all members of the plan.
+func (p Plan) Verify(previousClusterID string, statuses []MemberStatus) error {
+	if len(statuses) != len(p.Members) {
+		return microerror.Maskf(notRestoredError, "%d of %d members reported their status", len(statuses), len(p.Members))
+	}
+
+	first := statuses[0]
+	for _, s := range statuses {
+		if s.ClusterID != first.ClusterID {
+			return microerror.Maskf(notRestoredError, "member %s is in cluster %s, member %s in cluster %s", s.Pod, ClusterID(s.ClusterID), first.Pod, ClusterID(first.ClusterID))
+		}
+		if s.Leader == 0 || s.Leader != first.Leader {
+			return microerror.Maskf(notRestoredError, "members %s and %s do not agree on a leader", s.Pod, first.Pod)
+		}
+		if s.Members != len(p.Members) {
+			return microerror.Maskf(notRestoredError, "member %s sees %d members, want %d", s.Pod, s.Members, len(p.Members))
+		}
+	}
+
+	if ClusterID(first.ClusterID) == previousClusterID {
+		return microerror.Maskf(notRestoredError, "members still run cluster %s, which existed before the restore", previousClusterID)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package restore
+
+import (
+	"strconv"
+	"testing"
+)
+
+func Test_Verify(t *testing.T) {
+	plan := Plan{Members: []Member{{Name: "a"}, {Name: "b"}}}
+
+	testCases := []struct {
+		name         string
+		statuses     []MemberStatus
+		errorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: new cluster with a leader",
+			statuses: []MemberStatus{
+				{Pod: "etcd-a", ClusterID: 0xbeef, Leader: 1, Members: 2},
+				{Pod: "etcd-b", ClusterID: 0xbeef, Leader: 1, Members: 2},
+			},
+		},
+		{
+			name: "case 1: member missing",
+			statuses: []MemberStatus{
+				{Pod: "etcd-a", ClusterID: 0xbeef, Leader: 1, Members: 2},
+			},
+			errorMatcher: IsNotRestored,
+		},
+		{
+			name: "case 2: members in different clusters",
+			statuses: []MemberStatus{
+				{Pod: "etcd-a", ClusterID: 0xbeef, Leader: 1, Members: 2},
+				{Pod: "etcd-b", ClusterID: 0xcafe, Leader: 1, Members: 2},
+			},
+			errorMatcher: IsNotRestored,
+		},
+		{
+			name: "case 3: no leader",
+			statuses: []MemberStatus{
+				{Pod: "etcd-a", ClusterID: 0xbeef, Members: 2},
+				{Pod: "etcd-b", ClusterID: 0xbeef, Members: 2},
+			},
+			errorMatcher: IsNotRestored,
+		},
+		{
+			name: "case 4: previous cluster still running",
+			statuses: []MemberStatus{
+				{Pod: "etcd-a", ClusterID: 0xdead, Leader: 1, Members: 2},
+				{Pod: "etcd-b", ClusterID: 0xdead, Leader: 1, Members: 2},
+			},
+			errorMatcher: IsNotRestored,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			err := plan.Verify("dead", tc.statuses)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
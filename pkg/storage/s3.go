@@ -1,8 +1,12 @@
 package storage
 
 import (
+	"context"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -59,33 +63,12 @@ func NewS3Upload(config S3Config) (*S3Upload, error) {
 	}, nil
 }
 
-func (upload S3Upload) Upload(fpath string) (int64, error) {
-	// Configure AWS session
-	awsConfig := &aws.Config{
-		Region: &upload.region,
-	}
-
-	// Set credentials based on authentication method
-	if !upload.enableIRSA {
-		// Use static credentials if IRSA is not enabled
-		creds := credentials.NewStaticCredentials(upload.accessKeyID, upload.secretAccessKey, "")
-		awsConfig.Credentials = creds
-	}
-
-	if upload.endpoint != "" {
-		awsConfig.Endpoint = aws.String(upload.endpoint)
-	}
-	if upload.forcePathStyle {
-		awsConfig.S3ForcePathStyle = aws.Bool(true)
-	}
-
-	sess, err := session.NewSession(awsConfig)
+func (upload S3Upload) Upload(ctx context.Context, fpath string) (int64, error) {
+	svc, err := upload.client()
 	if err != nil {
 		return -1, microerror.Mask(err)
 	}
 
-	svc := s3.New(sess)
-
 	// Upload.
 	file, err := os.Open(fpath) //nolint:gosec
 	if err != nil {
@@ -112,10 +95,167 @@ func (upload S3Upload) Upload(fpath string) (int64, error) {
 	}
 
 	// Put object to S3Upload.
-	_, err = svc.PutObject(params)
+	_, err = svc.PutObjectWithContext(ctx, params)
+	if err != nil {
+		return -1, microerror.Mask(err)
+	}
+
+	return size, nil
+}
+
+// Download writes the object with the given key to fpath.
+func (upload S3Upload) Download(ctx context.Context, key string, fpath string) (int64, error) {
+	svc, err := upload.client()
+	if err != nil {
+		return -1, microerror.Mask(err)
+	}
+
+	out, err := svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(upload.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return -1, microerror.Mask(err)
+	}
+	defer out.Body.Close() //nolint:errcheck
+
+	file, err := os.OpenFile(fpath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(0600)) // #nosec G304
+	if err != nil {
+		return -1, microerror.Mask(err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	size, err := io.Copy(file, out.Body)
+	if err != nil {
+		return -1, microerror.Mask(err)
+	}
+
+	err = file.Close()
 	if err != nil {
 		return -1, microerror.Mask(err)
 	}
 
 	return size, nil
 }
+
+// List returns all objects whose key starts with prefix, oldest first.
+func (upload S3Upload) List(ctx context.Context, prefix string) ([]Object, error) {
+	svc, err := upload.client()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var objects []Object
+	params := &s3.ListObjectsV2Input{
+		Bucket: aws.String(upload.bucket),
+		Prefix: aws.String(prefix),
+	}
+	err = svc.ListObjectsV2PagesWithContext(ctx, params, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, o := range page.Contents {
+			objects = append(objects, Object{
+				Key:          aws.StringValue(o.Key),
+				Size:         aws.Int64Value(o.Size),
+				LastModified: aws.TimeValue(o.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].LastModified.Before(objects[j].LastModified)
+	})
+
+	return objects, nil
+}
+
+// Delete removes the object with the given key. Deleting an object which
+// does not exist succeeds.
+func (upload S3Upload) Delete(ctx context.Context, key string) error {
+	svc, err := upload.client()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = svc.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(upload.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Presign returns a URL which allows downloading the object with the given key
+// for ttl.
+func (upload S3Upload) Presign(key string, ttl time.Duration) (string, error) {
+	svc, err := upload.client()
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(upload.bucket),
+		Key:    aws.String(key),
+	})
+
+	url, err := req.Presign(ttl)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return url, nil
+}
+
+// PresignUpload returns a URL which allows uploading the object with the given
+// key for ttl, see PutURL.
+func (upload S3Upload) PresignUpload(key string, ttl time.Duration) (string, error) {
+	svc, err := upload.client()
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	req, _ := svc.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(upload.bucket),
+		Key:    aws.String(key),
+	})
+
+	url, err := req.Presign(ttl)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return url, nil
+}
+
+func (upload S3Upload) client() (*s3.S3, error) {
+	// Configure AWS session
+	awsConfig := &aws.Config{
+		Region: &upload.region,
+	}
+
+	// Set credentials based on authentication method
+	if !upload.enableIRSA {
+		// Use static credentials if IRSA is not enabled
+		creds := credentials.NewStaticCredentials(upload.accessKeyID, upload.secretAccessKey, "")
+		awsConfig.Credentials = creds
+	}
+
+	if upload.endpoint != "" {
+		awsConfig.Endpoint = aws.String(upload.endpoint)
+	}
+	if upload.forcePathStyle {
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return s3.New(sess), nil
+}
@@ -1,5 +1,45 @@
 package storage
 
+import (
+	"context"
+	"time"
+)
+
 type Uploader interface {
-	Upload(string) (int64, error)
+	Upload(ctx context.Context, fpath string) (int64, error)
+}
+
+// Downloader fetches backups, e.g. to verify or restore them.
+type Downloader interface {
+	Download(ctx context.Context, key string, fpath string) (int64, error)
+}
+
+// Deleter removes objects from the storage, e.g. copies of backups made
+// for a restore.
+type Deleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Lister lists the backups in the storage.
+type Lister interface {
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// Presigner creates URLs which allow downloading a backup without
+// credentials for a limited time, e.g. from a workload cluster node.
+type Presigner interface {
+	Presign(key string, ttl time.Duration) (string, error)
+}
+
+// UploadPresigner creates URLs which allow uploading an object without
+// credentials for a limited time, e.g. from a workload cluster node.
+type UploadPresigner interface {
+	PresignUpload(key string, ttl time.Duration) (string, error)
+}
+
+// Object describes a backup in the storage.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
 }
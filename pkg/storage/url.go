@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/giantswarm/microerror"
+)
+
+// PutURL uploads the file at fpath to the URL returned by PresignUpload and
+// returns its size. Only the scheme, host and path of the URL are put into
+// errors, as its query holds the signature.
+func PutURL(ctx context.Context, location string, fpath string) (int64, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return -1, microerror.Maskf(invalidConfigError, "upload URL is not valid")
+	}
+
+	file, err := os.Open(fpath) //nolint:gosec
+	if err != nil {
+		return -1, microerror.Mask(err)
+	}
+	defer file.Close() //nolint:errcheck
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return -1, microerror.Mask(err)
+	}
+	size := fileInfo.Size()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, location, file)
+	if err != nil {
+		return -1, microerror.Maskf(invalidConfigError, "upload URL %s://%s%s is not valid", u.Scheme, u.Host, u.Path)
+	}
+	req.ContentLength = size
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return -1, microerror.Maskf(executionFailedError, "uploading to %s://%s%s failed: %s", u.Scheme, u.Host, u.Path, err)
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return -1, microerror.Maskf(executionFailedError, "uploading to %s://%s%s returned %s", u.Scheme, u.Host, u.Path, res.Status)
+	}
+
+	return size, nil
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_PutURL(t *testing.T) {
+	testCases := []struct {
+		name         string
+		status       int
+		expectedSize int64
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: uploaded",
+			status:       http.StatusOK,
+			expectedSize: 6,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: rejected",
+			status:       http.StatusForbidden,
+			expectedSize: -1,
+			errorMatcher: IsExecutionFailed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut || r.ContentLength != 6 {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			fpath := filepath.Join(t.TempDir(), "backup")
+			err := os.WriteFile(fpath, []byte("backup"), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			size, err := PutURL(context.Background(), server.URL+"/backup?X-Amz-Signature=secret", fpath)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			case strings.Contains(err.Error(), "secret"):
+				t.Fatalf("error %q contains the signature of the URL", err)
+			}
+
+			if size != tc.expectedSize {
+				t.Fatalf("size == %d, want %d", size, tc.expectedSize)
+			}
+			if string(body) != "backup" {
+				t.Fatalf("body == %q, want %q", body, "backup")
+			}
+		})
+	}
+}
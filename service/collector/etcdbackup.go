@@ -2,9 +2,9 @@ package collector
 
 import (
 	"context"
-	"sort"
+	"sync"
+	"time"
 
-	"github.com/giantswarm/apiextensions-backup/api/v1alpha1"
 	"github.com/giantswarm/apiextensions/v6/pkg/apis/infrastructure/v1alpha3"
 	providerv1alpha1 "github.com/giantswarm/apiextensions/v6/pkg/apis/provider/v1alpha1"
 	"github.com/giantswarm/k8sclient/v8/pkg/k8sclient"
@@ -13,15 +13,20 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
 
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/history"
 	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
 )
 
 const (
 	labelTenantClusterId = "tenant_cluster_id"
 	labelETCDVersion     = "etcd_version"
+	labelErrorClass      = "error_class"
 
-	backupStateCompleted = "Completed"
-	backupStateSkipped   = "Skipped"
+	backupStateFailed = "Failed"
+
+	clusterIDsTTL     = 5 * time.Minute
+	clusterIDsTimeout = 30 * time.Second
 )
 
 var (
@@ -69,19 +74,37 @@ var (
 		labels,
 		nil,
 	)
+
+	latestAttemptFailedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "latest_attempt_failed"),
+		"Gauge set to 1 when the latest backup attempt failed, labelled by the class of the error.",
+		append(labels, labelErrorClass),
+		nil,
+	)
 )
 
 type ETCDBackupConfig struct {
+	History   *history.History
 	K8sClient k8sclient.Interface
 	Logger    micrologger.Logger
 }
 
 type ETCDBackup struct {
+	history   *history.History
 	k8sClient k8sclient.Interface
 	logger    micrologger.Logger
+
+	// Listing the clusters is expensive on big installations, so the list is
+	// cached for clusterIDsTTL and reused if refreshing it fails.
+	clusterIDsMutex  sync.Mutex
+	clusterIDs       []string
+	clusterIDsExpiry time.Time
 }
 
 func NewETCDBackup(config ETCDBackupConfig) (*ETCDBackup, error) {
+	if config.History == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.History must not be empty", config)
+	}
 	if config.K8sClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
 	}
@@ -90,6 +113,7 @@ func NewETCDBackup(config ETCDBackupConfig) (*ETCDBackup, error) {
 	}
 
 	d := &ETCDBackup{
+		history:   config.History,
 		k8sClient: config.K8sClient,
 		logger:    config.Logger,
 	}
@@ -98,113 +122,96 @@ func NewETCDBackup(config ETCDBackupConfig) (*ETCDBackup, error) {
 }
 
 func (d *ETCDBackup) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
-
-	// Get a list of all ETCDBackup objects.
-	backupListResult := v1alpha1.ETCDBackupList{}
-	err := d.k8sClient.CtrlClient().List(ctx, &backupListResult)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	// Sort backups by Status.FinishedTimestamp.
-	backups := backupListResult.Items
-	sort.Slice(backups, func(i, j int) bool {
-		t1 := backups[i].Status.FinishedTimestamp.Time
-		t2 := backups[j].Status.FinishedTimestamp.Time
-		return t1.Before(t2)
-	})
-
 	// Get a list of current tenant clusters.
-	tenantClusterIds, err := d.getTenantClusterIDs(ctx)
+	tenantClusterIds, err := d.cachedTenantClusterIDs()
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
 	tenantClusterIds = append(tenantClusterIds, key.ManagementCluster)
 
-	// Iterate over all ETCDBackup objects and select the most recent backup from each cluster.
-	latestV3SuccessMetrics := map[string]v1alpha1.ETCDInstanceBackupStatus{}
-	latestV3AttemptMetrics := map[string]v1alpha1.ETCDInstanceBackupStatus{}
-
-	for _, backup := range backups {
-		for _, instanceStatus := range backup.Status.Instances {
-			// The cluster this instance status is referring to does not exist anymore.
-			// We simply ignore this metric otherwise we get paged for deleted clusters.
-			if !inSlice(instanceStatus.Name, tenantClusterIds) {
-				continue
-			}
-
-			if instanceStatus.V3 != nil && instanceStatus.V3.Status == backupStateCompleted {
-				latestV3SuccessMetrics[instanceStatus.Name] = *instanceStatus.V3
-			}
-
-			if instanceStatus.V3 != nil && instanceStatus.V3.Status != backupStateSkipped {
-				latestV3AttemptMetrics[instanceStatus.Name] = *instanceStatus.V3
-			}
-		}
-	}
-
-	sendAttemptMetricsForVersion := func(tenantClusterID string, status v1alpha1.ETCDInstanceBackupStatus, version string) {
+	sendAttemptMetricsForVersion := func(tenantClusterID string, entry history.Entry, version string) {
 		ch <- prometheus.MustNewConstMetric(
 			latestAttemptTimestampDesc,
 			prometheus.GaugeValue,
-			float64(status.FinishedTimestamp.Unix()),
+			float64(entry.FinishedTimestamp.Unix()),
 			tenantClusterID,
 			version,
 		)
-	}
 
-	sendSuccessMetricsForVersion := func(tenantClusterID string, status v1alpha1.ETCDInstanceBackupStatus, version string) {
-		if status.Status == backupStateCompleted {
-			ch <- prometheus.MustNewConstMetric(
-				creationTimeDesc,
-				prometheus.GaugeValue,
-				float64(status.CreationTime),
-				tenantClusterID,
-				version,
-			)
+		if entry.Status == backupStateFailed {
+			// Failures seeded from the CRs or persisted by former
+			// versions have no class.
+			errorClass := entry.ErrorClass
+			if errorClass == "" {
+				errorClass = key.ErrorClassFailure
+			}
 
 			ch <- prometheus.MustNewConstMetric(
-				encryptionTimeDesc,
+				latestAttemptFailedDesc,
 				prometheus.GaugeValue,
-				float64(status.EncryptionTime),
+				1,
 				tenantClusterID,
 				version,
+				errorClass,
 			)
+		}
+	}
 
-			ch <- prometheus.MustNewConstMetric(
-				uploadTimeDesc,
-				prometheus.GaugeValue,
-				float64(status.UploadTime),
-				tenantClusterID,
-				version,
-			)
+	sendSuccessMetricsForVersion := func(tenantClusterID string, entry history.Entry, version string) {
+		ch <- prometheus.MustNewConstMetric(
+			creationTimeDesc,
+			prometheus.GaugeValue,
+			float64(entry.CreationTime),
+			tenantClusterID,
+			version,
+		)
 
-			ch <- prometheus.MustNewConstMetric(
-				backupSizeDesc,
-				prometheus.GaugeValue,
-				float64(status.BackupFileSize),
-				tenantClusterID,
-				version,
-			)
+		ch <- prometheus.MustNewConstMetric(
+			encryptionTimeDesc,
+			prometheus.GaugeValue,
+			float64(entry.EncryptionTime),
+			tenantClusterID,
+			version,
+		)
 
-			ch <- prometheus.MustNewConstMetric(
-				latestSuccessTimestampDesc,
-				prometheus.GaugeValue,
-				float64(status.FinishedTimestamp.Unix()),
-				tenantClusterID,
-				version,
-			)
-		}
-	}
+		ch <- prometheus.MustNewConstMetric(
+			uploadTimeDesc,
+			prometheus.GaugeValue,
+			float64(entry.UploadTime),
+			tenantClusterID,
+			version,
+		)
 
-	for clusterName, status := range latestV3SuccessMetrics {
-		sendSuccessMetricsForVersion(clusterName, status, "V3")
+		ch <- prometheus.MustNewConstMetric(
+			backupSizeDesc,
+			prometheus.GaugeValue,
+			float64(entry.BackupFileSize),
+			tenantClusterID,
+			version,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			latestSuccessTimestampDesc,
+			prometheus.GaugeValue,
+			float64(entry.FinishedTimestamp.Unix()),
+			tenantClusterID,
+			version,
+		)
 	}
 
-	for clusterName, status := range latestV3AttemptMetrics {
-		sendAttemptMetricsForVersion(clusterName, status, "V3")
+	for _, record := range d.history.Records() {
+		// The cluster this record is referring to does not exist anymore.
+		if !inSlice(record.Cluster, tenantClusterIds) {
+			continue
+		}
+
+		if record.LatestSuccess != nil {
+			sendSuccessMetricsForVersion(record.Cluster, *record.LatestSuccess, record.Version)
+		}
+		if record.LatestAttempt != nil {
+			sendAttemptMetricsForVersion(record.Cluster, *record.LatestAttempt, record.Version)
+		}
 	}
 
 	return nil
@@ -217,10 +224,71 @@ func (d *ETCDBackup) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- backupSizeDesc
 	ch <- latestAttemptTimestampDesc
 	ch <- latestSuccessTimestampDesc
+	ch <- latestAttemptFailedDesc
 	return nil
 }
 
+func (d *ETCDBackup) cachedTenantClusterIDs() ([]string, error) {
+	d.clusterIDsMutex.Lock()
+	defer d.clusterIDsMutex.Unlock()
+
+	if d.clusterIDs != nil && time.Now().Before(d.clusterIDsExpiry) {
+		return append([]string{}, d.clusterIDs...), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clusterIDsTimeout)
+	defer cancel()
+
+	ids, err := d.getTenantClusterIDs(ctx)
+	if err != nil && d.clusterIDs != nil {
+		d.logger.Log("level", "warning", "message", "failed to refresh list of tenant clusters, using stale list", "stack", microerror.JSON(err))
+		return append([]string{}, d.clusterIDs...), nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if ids == nil {
+		ids = []string{}
+	}
+	d.clusterIDs = ids
+	d.clusterIDsExpiry = time.Now().Add(clusterIDsTTL)
+
+	return append([]string{}, d.clusterIDs...), nil
+}
+
+// getTenantClusterIDs returns the names of the workload clusters, of the
+// instances backing up etcd shared by several of them and of the
+// ETCDEndpoints.
 func (d *ETCDBackup) getTenantClusterIDs(ctx context.Context) ([]string, error) {
+	ret := d.getWorkloadClusterIDs(ctx)
+
+	utils, err := giantnetes.NewUtils(d.logger, d.k8sClient)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	clusters, err := utils.ListClusters(ctx)
+	if giantnetes.IsUnableToGetTenantClusters(err) {
+		d.logger.Log("level", "debug", "message", "failed to list workload clusters", "reason", err)
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, c := range clusters {
+		if !inSlice(c.Name, ret) {
+			ret = append(ret, c.Name)
+		}
+		if c.Instance != "" && !inSlice(c.Instance, ret) {
+			ret = append(ret, c.Instance)
+		}
+	}
+
+	return ret, nil
+}
+
+// getWorkloadClusterIDs returns the names of the workload clusters which are
+// not deleted, whether they are backed up or not.
+func (d *ETCDBackup) getWorkloadClusterIDs(ctx context.Context) []string {
 	crdClient := d.k8sClient.CtrlClient()
 	var ret []string
 
@@ -294,7 +362,7 @@ func (d *ETCDBackup) getTenantClusterIDs(ctx context.Context) ([]string, error)
 		}
 	}
 
-	return ret, nil
+	return ret
 }
 
 func inSlice(a string, list []string) bool {
@@ -5,9 +5,12 @@ import (
 	"github.com/giantswarm/k8sclient/v8/pkg/k8sclient"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/history"
 )
 
 type SetConfig struct {
+	History   *history.History
 	K8sClient k8sclient.Interface
 	Logger    micrologger.Logger
 }
@@ -25,6 +28,7 @@ func NewSet(config SetConfig) (*Set, error) {
 	var etcdBackupCollector *ETCDBackup
 	{
 		c := ETCDBackupConfig{ //nolint
+			History:   config.History,
 			K8sClient: config.K8sClient,
 			Logger:    config.Logger,
 		}
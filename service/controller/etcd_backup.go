@@ -10,21 +10,34 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/backupjob"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/history"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/notify"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/project"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/storage"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/resource/etcdbackup"
 )
 
 type ETCDBackupConfig struct {
+	History                     *history.History
 	K8sClient                   k8sclient.Interface
 	Logger                      micrologger.Logger
+	Notifier                    *notify.Notifier
 	ETCDv3Settings              giantnetes.ETCDv3Settings
+	Encrypt                     bool
 	EncryptionPwd               string
 	Installation                string
 	SentryDSN                   string
 	Uploader                    storage.Uploader
 	SkipManagementClusterBackup bool
 	BackupDestination           string
+	Timeouts                    giantnetes.Timeouts
+	DRBundle                    bool
+	Inventory                   bool
+	AgentSigningKey             []byte
+	AgentStorage                etcdbackup.AgentStorage
+	Jobs                        *backupjob.Config
 }
 
 type ETCDBackup struct {
@@ -32,6 +45,12 @@ type ETCDBackup struct {
 }
 
 func validateETCDBackupConfig(config ETCDBackupConfig) error {
+	if config.History == nil {
+		return microerror.Maskf(invalidConfigError, "%T.History must be defined", config)
+	}
+	if config.Notifier == nil {
+		return microerror.Maskf(invalidConfigError, "%T.Notifier must be defined", config)
+	}
 	if !config.SkipManagementClusterBackup && !config.ETCDv3Settings.AreComplete() {
 		return microerror.Maskf(invalidConfigError, "%T.ETCDv3Settings must be defined", config)
 	}
@@ -98,14 +117,23 @@ func newETCDBackupResourceSets(config ETCDBackupConfig) ([]resource.Interface, e
 	var resources []resource.Interface
 	{
 		c := ETCDBackupConfig{
+			History:                     config.History,
 			K8sClient:                   config.K8sClient,
 			Logger:                      config.Logger,
+			Notifier:                    config.Notifier,
 			ETCDv3Settings:              config.ETCDv3Settings,
+			Encrypt:                     config.Encrypt,
 			EncryptionPwd:               config.EncryptionPwd,
 			Installation:                config.Installation,
 			Uploader:                    config.Uploader,
 			SkipManagementClusterBackup: config.SkipManagementClusterBackup,
 			BackupDestination:           config.BackupDestination,
+			Timeouts:                    config.Timeouts,
+			DRBundle:                    config.DRBundle,
+			Inventory:                   config.Inventory,
+			AgentSigningKey:             config.AgentSigningKey,
+			AgentStorage:                config.AgentStorage,
+			Jobs:                        config.Jobs,
 		}
 		resources, err = newETCDBackupResourceSet(c)
 		if err != nil {
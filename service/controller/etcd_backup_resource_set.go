@@ -32,13 +32,22 @@ func newETCDBackupResourceSet(config ETCDBackupConfig) ([]resource.Interface, er
 	var etcdBackupResource resource.Interface
 	{
 		c := etcdbackup.Config{
+			History:                     config.History,
 			K8sClient:                   config.K8sClient,
 			Logger:                      config.Logger,
+			Notifier:                    config.Notifier,
 			ETCDv3Settings:              config.ETCDv3Settings,
+			Encrypt:                     config.Encrypt,
 			EncryptionPwd:               config.EncryptionPwd,
 			Installation:                config.Installation,
 			Uploader:                    config.Uploader,
 			SkipManagementClusterBackup: config.SkipManagementClusterBackup,
+			Timeouts:                    config.Timeouts,
+			DRBundle:                    config.DRBundle,
+			Inventory:                   config.Inventory,
+			AgentSigningKey:             config.AgentSigningKey,
+			AgentStorage:                config.AgentStorage,
+			Jobs:                        config.Jobs,
 		}
 
 		etcdBackupResource, err = etcdbackup.New(c)
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/giantswarm/k8sclient/v8/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource/wrapper/metricsresource"
+	"github.com/giantswarm/operatorkit/v7/pkg/resource/wrapper/retryresource"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/project"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/resource/etcdrestore"
+)
+
+type ETCDRestoreConfig struct {
+	K8sClient         k8sclient.Interface
+	Logger            micrologger.Logger
+	Storage           etcdrestore.Storage
+	EncryptionPwd     string
+	Image             string
+	SentryDSN         string
+	BackupDestination string
+	SwapDelay         time.Duration
+	Timeout           time.Duration
+}
+
+type ETCDRestore struct {
+	*controller.Controller
+}
+
+func validateETCDRestoreConfig(config ETCDRestoreConfig) error {
+	if config.Storage == nil {
+		return microerror.Maskf(invalidConfigError, "%T.Storage must be defined", config)
+	}
+	if config.BackupDestination == "" {
+		return microerror.Maskf(invalidConfigError, "%T.BackupDestination must be defined", config)
+	}
+	return nil
+}
+
+func NewETCDRestore(config ETCDRestoreConfig) (*ETCDRestore, error) {
+	var err error
+	err = validateETCDRestoreConfig(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	resources, err := newETCDRestoreResourceSet(config)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var operatorkitController *controller.Controller
+	{
+		c := controller.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+			Resources: resources,
+			NewRuntimeObjectFunc: func() client.Object {
+				return new(restorev1alpha1.ETCDRestore)
+			},
+			Name:      project.Name() + "-etcd-restore-controller",
+			SentryDSN: config.SentryDSN,
+			// Restores are handled by the operator owning the bucket the
+			// backups are in.
+			Selector: labels.SelectorFromSet(labels.Set{
+				"backup.giantswarm.io/destination": config.BackupDestination,
+			}),
+		}
+
+		operatorkitController, err = controller.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	c := &ETCDRestore{
+		Controller: operatorkitController,
+	}
+
+	return c, nil
+}
+
+func newETCDRestoreResourceSet(config ETCDRestoreConfig) ([]resource.Interface, error) {
+	var err error
+
+	var etcdRestoreResource resource.Interface
+	{
+		c := etcdrestore.Config{
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+			Storage:       config.Storage,
+			EncryptionPwd: config.EncryptionPwd,
+			Image:         config.Image,
+			SwapDelay:     config.SwapDelay,
+			Timeout:       config.Timeout,
+		}
+
+		etcdRestoreResource, err = etcdrestore.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	resources := []resource.Interface{
+		etcdRestoreResource,
+	}
+
+	{
+		c := retryresource.WrapConfig{
+			Logger: config.Logger,
+		}
+
+		resources, err = retryresource.Wrap(resources, c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		c := metricsresource.WrapConfig{}
+
+		resources, err = metricsresource.Wrap(resources, c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return resources, nil
+}
@@ -15,15 +15,31 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	kcfg "sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/history"
 )
 
 const (
 	ManagementCluster = "ManagementCluster"
 
+	// ETCDVersionV3 is the etcd version backups are reported with in metrics.
+	ETCDVersionV3 = "V3"
+
+	// Execution modes of the backups of the instances.
+	ExecutionModeInline = "inline"
+	ExecutionModeJob    = "job"
+
 	// Environment variables.
 	EnvAWSAccessKeyID     = "AWS_ACCESS_KEY_ID"
 	EnvAWSSecretAccessKey = "AWS_SECRET_ACCESS_KEY" // nolint: gosec
 	EncryptionPassword    = "ENCRYPTION_PASSWORD"
+	EnvNotificationSinks  = "NOTIFICATION_SINKS" // nolint: gosec
+	EnvAgentSigningKey    = "AGENT_SIGNING_KEY"  // nolint: gosec
+
+	// Classes of backup errors as exposed in metrics.
+	ErrorClassFailure = "failure"
+	ErrorClassTimeout = "timeout"
 )
 
 func ToCustomObject(v interface{}) (backupv1alpha1.ETCDBackup, error) {
@@ -40,10 +56,45 @@ func ToCustomObject(v interface{}) (backupv1alpha1.ETCDBackup, error) {
 	return customObject, nil
 }
 
+func ToETCDRestore(v interface{}) (restorev1alpha1.ETCDRestore, error) {
+	if v == nil {
+		return restorev1alpha1.ETCDRestore{}, microerror.Maskf(executionFailedError, "expected '%T', got '%T'", &restorev1alpha1.ETCDRestore{}, v)
+	}
+
+	customObjectPointer, ok := v.(*restorev1alpha1.ETCDRestore)
+	if !ok {
+		return restorev1alpha1.ETCDRestore{}, microerror.Maskf(executionFailedError, "expected '%T', got '%T'", &restorev1alpha1.ETCDRestore{}, v)
+	}
+	customObject := *customObjectPointer.DeepCopy()
+
+	return customObject, nil
+}
+
+// HistoryEntry converts the backup status of an instance to an entry of the
+// backup history. errorClass is the class of the latest error of failed
+// backups, which is not kept in the status.
+func HistoryEntry(status backupv1alpha1.ETCDInstanceBackupStatus, errorClass string) history.Entry {
+	return history.Entry{
+		Status:            status.Status,
+		StartedTimestamp:  status.StartedTimestamp.Time,
+		FinishedTimestamp: status.FinishedTimestamp.Time,
+		LatestError:       status.LatestError,
+		ErrorClass:        errorClass,
+		CreationTime:      status.CreationTime,
+		EncryptionTime:    status.EncryptionTime,
+		UploadTime:        status.UploadTime,
+		BackupFileSize:    status.BackupFileSize,
+		Filename:          status.Filename,
+	}
+}
+
 func FilenamePrefix(installationName string, clusterName string) string {
 	return fmt.Sprintf("%s-%s", installationName, clusterName)
 }
 
+// PrepareTLSConfig returns a client TLS configuration presenting the given
+// certificate and verifying the server certificate against caData. The name
+// dialed is expected in the server certificate unless ServerName is set.
 func PrepareTLSConfig(caData []byte, crtData []byte, keyData []byte) (*tls.Config, error) {
 	clientCert, err := tls.X509KeyPair(crtData, keyData)
 	if err != nil {
@@ -51,13 +102,14 @@ func PrepareTLSConfig(caData []byte, crtData []byte, keyData []byte) (*tls.Confi
 	}
 
 	caPool := x509.NewCertPool()
-	caPool.AppendCertsFromPEM(caData)
+	if !caPool.AppendCertsFromPEM(caData) {
+		return nil, microerror.Maskf(executionFailedError, "CA bundle contains no certificate")
+	}
 	tlsConfig := &tls.Config{
 		RootCAs:      caPool,
 		Certificates: []tls.Certificate{clientCert},
 		MinVersion:   tls.VersionTLS12,
 	}
-	tlsConfig.InsecureSkipVerify = true
 
 	return tlsConfig, nil
 }
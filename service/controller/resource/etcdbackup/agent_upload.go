@@ -0,0 +1,248 @@
+package etcdbackup
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/agent"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/metrics"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+)
+
+const (
+	// agentJobSlack is the time a backup job may take on top of the
+	// timeouts of its stages, e.g. to probe the agents and poll the job.
+	agentJobSlack = 2 * time.Minute
+	// maxAgentUploadURLTTL is how long the upload URLs of jobs without
+	// timeouts are valid.
+	maxAgentUploadURLTTL = 24 * time.Hour
+	// agentPassphraseLength is the number of random bytes of the passphrase
+	// of a job.
+	agentPassphraseLength = 32
+)
+
+// performAgentUpload has a node agent take and upload the backup of an
+// instance, retrying like performBackup.
+func (r *Runner) performAgentUpload(ctx context.Context, u etcd.AgentUpload, instanceName string, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, error) {
+	return r.retryBackup(ctx, instanceName, metricsVersion(u), func() (*metrics.BackupAttemptResult, error) {
+		return r.agentUploadAttempt(ctx, u, instanceName, timeouts)
+	})
+}
+
+func (r *Runner) agentUploadAttempt(ctx context.Context, u etcd.AgentUpload, instanceName string, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, error) {
+	version := u.Version()
+	labelVersion := metricsVersion(u)
+
+	attemptsTotal.WithLabelValues(instanceName, labelVersion).Inc()
+
+	// The agent uploads the backup with a URL presigned for its key, it
+	// holds no credentials of the storage. The encryption password decrypts
+	// the backups of all clusters, so it is not given to the agents either:
+	// they encrypt the backup with a passphrase generated for the job and
+	// upload it next to its final key, and the operator encrypts it with
+	// its password.
+	filename := u.Filename(r.encrypt)
+	job := agent.Job{
+		Key: filename,
+		Timeouts: agent.JobTimeouts{
+			Create:  timeouts.Create,
+			Encrypt: timeouts.Encrypt,
+			Upload:  timeouts.Upload,
+		},
+	}
+	if r.encrypt {
+		passphrase, err := agentPassphrase()
+		if err != nil {
+			return metrics.NewFailedBackupAttemptResult(stageAgent), stageFailed(ctx, instanceName, version, stageAgent, 0, err)
+		}
+		job.Key = agentUploadKey(filename)
+		job.Passphrase = passphrase
+		defer r.deleteAgentUpload(ctx, job.Key)
+	}
+
+	timeout := agentJobTimeout(timeouts)
+	ttl := timeout
+	if ttl == 0 {
+		ttl = maxAgentUploadURLTTL
+	}
+	uploadURL, err := r.agentStorage.PresignUpload(job.Key, ttl)
+	if err != nil {
+		return metrics.NewFailedBackupAttemptResult(stageAgent), stageFailed(ctx, instanceName, version, stageAgent, 0, err)
+	}
+	job.UploadURL = uploadURL
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "Dispatching backup job to node agent")
+	var status agent.JobStatus
+	err = runStage(ctx, timeout, func(ctx context.Context) error {
+		var err error
+		status, err = u.Run(ctx, job)
+		return err
+	})
+	if err != nil {
+		return metrics.NewFailedBackupAttemptResult(stageAgent), stageFailed(ctx, instanceName, version, stageAgent, timeout, err)
+	}
+	if status.State != agent.JobSucceeded {
+		err = microerror.Maskf(executionFailedError, "node agent failed with error %#q", status.Error)
+		if status.TimedOut {
+			err = microerror.Mask(context.DeadlineExceeded)
+		}
+		return metrics.NewFailedBackupAttemptResult(status.Stage), stageFailed(ctx, instanceName, version, status.Stage, stageTimeout(timeouts, status.Stage), err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Verifying backup file %s uploaded by node agent", status.Filename))
+	err = r.verifyAgentUpload(ctx, job.Key, status)
+	if err != nil {
+		return metrics.NewFailedBackupAttemptResult(stageVerification), stageFailed(ctx, instanceName, version, stageVerification, 0, err)
+	}
+
+	if r.encrypt {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Encrypting backup file %s uploaded by node agent", status.Filename))
+		stage, err := r.reencryptAgentUpload(ctx, &status, filename, job.Passphrase, timeouts)
+		if err != nil {
+			return metrics.NewFailedBackupAttemptResult(stage), stageFailed(ctx, instanceName, version, stage, stageTimeout(timeouts, stage), err)
+		}
+	}
+
+	stageDuration.WithLabelValues(instanceName, labelVersion, stageCreation).Observe(status.CreationTime.Seconds())
+	stageDuration.WithLabelValues(instanceName, labelVersion, stageEncryption).Observe(status.EncryptionTime.Seconds())
+	stageDuration.WithLabelValues(instanceName, labelVersion, stageUpload).Observe(status.UploadTime.Seconds())
+	if status.UploadTime > 0 {
+		uploadThroughput.WithLabelValues(instanceName, labelVersion).Observe(float64(status.Size) / status.UploadTime.Seconds())
+	}
+
+	successesTotal.WithLabelValues(instanceName, labelVersion).Inc()
+
+	return metrics.NewSuccessfulBackupAttemptResult(status.Size, status.CreationTime.Milliseconds(), status.EncryptionTime.Milliseconds(), status.UploadTime.Milliseconds(), status.Filename), nil
+}
+
+// verifyAgentUpload checks that the backup reported by a node agent is in
+// the storage, with the key of its job and the reported size.
+func (r *Runner) verifyAgentUpload(ctx context.Context, key string, status agent.JobStatus) error {
+	if status.Filename != key {
+		return microerror.Maskf(executionFailedError, "node agent uploaded backup file %#q, expected %#q", status.Filename, key)
+	}
+
+	objects, err := r.agentStorage.List(ctx, status.Filename)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, o := range objects {
+		if o.Key != status.Filename {
+			continue
+		}
+		if o.Size != status.Size {
+			return microerror.Maskf(executionFailedError, "backup file %#q has %d bytes in the storage, the node agent uploaded %d bytes", o.Key, o.Size, status.Size)
+		}
+
+		return nil
+	}
+
+	return microerror.Maskf(executionFailedError, "backup file %#q uploaded by node agent is not in the storage", status.Filename)
+}
+
+// reencryptAgentUpload encrypts the backup a node agent uploaded encrypted
+// with the passphrase of its job with the encryption password instead, and
+// uploads it as filename. The times it takes are added to status. The stage
+// which failed is returned with the error.
+func (r *Runner) reencryptAgentUpload(ctx context.Context, status *agent.JobStatus, filename string, passphrase string, timeouts giantnetes.Timeouts) (string, error) {
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return stageEncryption, microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	src := filepath.Join(dir, status.Filename)
+	dst := filepath.Join(dir, filename)
+
+	start := time.Now()
+	err = runStage(ctx, timeouts.Encrypt, func(ctx context.Context) error {
+		_, err := r.agentStorage.Download(ctx, status.Filename, src)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return etcd.ReencryptAgentUpload(ctx, src, dst, passphrase, r.encryptionPwd)
+	})
+	if err != nil {
+		return stageEncryption, microerror.Mask(err)
+	}
+	status.EncryptionTime += time.Since(start)
+
+	start = time.Now()
+	var size int64
+	err = runStage(ctx, timeouts.Upload, func(ctx context.Context) error {
+		var err error
+		size, err = r.uploader.Upload(ctx, dst)
+		return err
+	})
+	if err != nil {
+		return stageUpload, microerror.Mask(err)
+	}
+	status.UploadTime += time.Since(start)
+
+	status.Filename = filename
+	status.Size = size
+
+	return "", nil
+}
+
+// deleteAgentUpload deletes the backup a node agent uploaded encrypted with
+// the passphrase of its job, which is only needed until it is encrypted
+// with the encryption password.
+func (r *Runner) deleteAgentUpload(ctx context.Context, key string) {
+	err := r.agentStorage.Delete(ctx, key)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Failed to delete backup file %s uploaded by node agent", key), "reason", err)
+	}
+}
+
+// agentUploadKey returns the key a node agent uploads the backup named
+// filename to when it is encrypted with the passphrase of its job. It is not
+// named like a backup, so that it is not listed as one.
+func agentUploadKey(filename string) string {
+	return filename + ".agent"
+}
+
+// agentPassphrase returns a random passphrase of a job.
+func agentPassphrase() (string, error) {
+	b := make([]byte, agentPassphraseLength)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// agentJobTimeout bounds a backup job by the timeouts of its stages. Jobs
+// are unbounded when any of their stages is.
+func agentJobTimeout(timeouts giantnetes.Timeouts) time.Duration {
+	if timeouts.Create == 0 || timeouts.Encrypt == 0 || timeouts.Upload == 0 {
+		return 0
+	}
+
+	return timeouts.Create + timeouts.Encrypt + timeouts.Upload + agentJobSlack
+}
+
+// stageTimeout returns the timeout of the stage a node agent reported.
+func stageTimeout(timeouts giantnetes.Timeouts, stage string) time.Duration {
+	switch stage {
+	case stageCreation:
+		return timeouts.Create
+	case stageEncryption:
+		return timeouts.Encrypt
+	case stageUpload:
+		return timeouts.Upload
+	}
+
+	return 0
+}
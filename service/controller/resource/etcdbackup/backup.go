@@ -2,8 +2,10 @@ package etcdbackup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/giantswarm/backoff/v2"
@@ -11,18 +13,71 @@ import (
 
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/metrics"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
 )
 
-func (r *Resource) performBackup(ctx context.Context, backupper etcd.Backupper, instanceName string) (*metrics.BackupAttemptResult, error) {
+// newBackup prepares the backup of an instance and returns the function
+// taking it. Instances whose node agents upload the backups themselves are
+// backed up with AgentUpload, all others with the Backupper of their
+// datastore.
+func (r *Runner) newBackup(settings giantnetes.ETCDv3Settings, instanceName string) (func(ctx context.Context, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, error), error) {
+	prefix := key.FilenamePrefix(r.installation, instanceName)
+
+	if settings.Proxy != nil && settings.Proxy.Upload {
+		u, err := etcd.NewAgentUpload(settings.TLSConfig, settings.Proxy, settings.Endpoints, r.logger, prefix, r.agentSigningKey)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		backup := func(ctx context.Context, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, error) {
+			return r.performAgentUpload(ctx, u, instanceName, timeouts)
+		}
+
+		return backup, nil
+	}
+
+	backupper, err := etcd.NewBackupper(settings.Datastore, settings.TLSConfig, settings.Proxy, r.encryptionPwd, settings.Endpoints, r.logger, prefix)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	backup := func(ctx context.Context, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, error) {
+		return r.performBackup(ctx, backupper, instanceName, timeouts)
+	}
+
+	return backup, nil
+}
+
+func (r *Runner) performBackup(ctx context.Context, backupper etcd.Backupper, instanceName string, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, error) {
+	return r.retryBackup(ctx, instanceName, metricsVersion(backupper), func() (*metrics.BackupAttemptResult, error) {
+		return r.backupAttempt(ctx, backupper, instanceName, timeouts)
+	})
+}
+
+// retryBackup runs attempt until it succeeds or maxBackupAttempts failed. It
+// stops retrying once ctx is done.
+func (r *Runner) retryBackup(ctx context.Context, instanceName string, labelVersion string, attempt func() (*metrics.BackupAttemptResult, error)) (*metrics.BackupAttemptResult, error) {
 	attempts := 0
 	var err error
 	var latestMetrics *metrics.BackupAttemptResult
 
 	o := func() error {
+		if ctx.Err() != nil {
+			return backoff.Permanent(microerror.Mask(ctx.Err()))
+		}
+
 		attempts = attempts + 1
 		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Attempt number %d for %s", attempts, instanceName))
 
-		latestMetrics, err = r.backupAttempt(ctx, backupper)
+		if attempts > 1 {
+			retriesTotal.WithLabelValues(instanceName, labelVersion).Inc()
+		}
+
+		latestMetrics, err = attempt()
+		if ctx.Err() != nil {
+			return backoff.Permanent(microerror.Mask(ctx.Err()))
+		}
 		if err != nil {
 			r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Backup attempt #%d failed for %s. Latest error was: %s", attempts, instanceName, err))
 			return microerror.Mask(err)
@@ -35,6 +90,13 @@ func (r *Resource) performBackup(ctx context.Context, backupper etcd.Backupper,
 	b := backoff.NewMaxRetries(uint64(maxBackupAttempts), 20*time.Second)
 
 	err = backoff.Retry(o, b)
+	if latestMetrics != nil {
+		latestMetrics.Attempts = attempts
+	}
+	if ctx.Err() != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Backup of %s was canceled after %d attempts", instanceName, attempts))
+		return latestMetrics, microerror.Mask(ctx.Err())
+	}
 	if err != nil {
 		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("All backup attempts failed for %s. Latest error was: %s", instanceName, err))
 		return latestMetrics, err
@@ -43,36 +105,109 @@ func (r *Resource) performBackup(ctx context.Context, backupper etcd.Backupper,
 	return latestMetrics, nil
 }
 
-func (r *Resource) backupAttempt(ctx context.Context, b etcd.Backupper) (*metrics.BackupAttemptResult, error) {
+func (r *Runner) backupAttempt(ctx context.Context, b etcd.Backupper, instanceName string, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, error) {
 	var err error
 	version := b.Version()
+	labelVersion := metricsVersion(b)
+
+	attemptsTotal.WithLabelValues(instanceName, labelVersion).Inc()
+
+	// The files of failed attempts are removed as well, so that they do
+	// not pile up in the temporary directory.
+	defer func() {
+		r.logger.LogCtx(ctx, "level", "debug", "message", "Cleaning up")
+		b.Cleanup()
+	}()
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", "Creating backup file")
 	start := time.Now()
-	_, err = b.Create()
+	err = runStage(ctx, timeouts.Create, func(ctx context.Context) error {
+		_, err := b.Create(ctx)
+		return err
+	})
 	if err != nil {
-		return metrics.NewFailedBackupAttemptResult(), microerror.Maskf(executionFailedError, "etcd %#q creation failed with error %#q", version, err)
+		return metrics.NewFailedBackupAttemptResult(stageCreation), stageFailed(ctx, instanceName, version, stageCreation, timeouts.Create, err)
 	}
-	creationTime := time.Since(start).Milliseconds()
+	creationTime := time.Since(start)
+	stageDuration.WithLabelValues(instanceName, labelVersion, stageCreation).Observe(creationTime.Seconds())
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", "Encrypting backup file")
 	start = time.Now()
-	path, err := b.Encrypt()
+	var path string
+	err = runStage(ctx, timeouts.Encrypt, func(ctx context.Context) error {
+		var err error
+		path, err = b.Encrypt(ctx)
+		return err
+	})
 	if err != nil {
-		return metrics.NewFailedBackupAttemptResult(), microerror.Maskf(executionFailedError, "etcd %#q encryption failed with error %#q", version, err)
+		return metrics.NewFailedBackupAttemptResult(stageEncryption), stageFailed(ctx, instanceName, version, stageEncryption, timeouts.Encrypt, err)
 	}
-	encryptionTime := time.Since(start).Milliseconds()
+	encryptionTime := time.Since(start)
+	stageDuration.WithLabelValues(instanceName, labelVersion, stageEncryption).Observe(encryptionTime.Seconds())
 
 	r.logger.LogCtx(ctx, "level", "debug", "message", "Uploading backup file")
 	start = time.Now()
-	backupSize, err := r.uploader.Upload(path)
+	var backupSize int64
+	err = runStage(ctx, timeouts.Upload, func(ctx context.Context) error {
+		var err error
+		backupSize, err = r.uploader.Upload(ctx, path)
+		return err
+	})
 	if err != nil {
-		return metrics.NewFailedBackupAttemptResult(), microerror.Maskf(executionFailedError, "etcd %#q upload failed with error %#q", version, err)
+		return metrics.NewFailedBackupAttemptResult(stageUpload), stageFailed(ctx, instanceName, version, stageUpload, timeouts.Upload, err)
+	}
+	uploadTime := time.Since(start)
+	stageDuration.WithLabelValues(instanceName, labelVersion, stageUpload).Observe(uploadTime.Seconds())
+	if uploadTime > 0 {
+		uploadThroughput.WithLabelValues(instanceName, labelVersion).Observe(float64(backupSize) / uploadTime.Seconds())
 	}
-	uploadTime := time.Since(start).Milliseconds()
 
-	r.logger.LogCtx(ctx, "level", "debug", "message", "Cleaning up")
-	b.Cleanup()
+	info := b.Info()
+	snapshotRevision.WithLabelValues(instanceName, labelVersion).Set(float64(info.Revision))
+	if info.ArchiveSize > 0 {
+		compressionRatio.WithLabelValues(instanceName, labelVersion).Set(float64(info.Size) / float64(info.ArchiveSize))
+	}
+	successesTotal.WithLabelValues(instanceName, labelVersion).Inc()
+
+	return metrics.NewSuccessfulBackupAttemptResult(backupSize, creationTime.Milliseconds(), encryptionTime.Milliseconds(), uploadTime.Milliseconds(), filepath.Base(path)), nil
+}
+
+// runStage executes a single backup stage bounded by the given timeout. A zero
+// timeout only bounds the stage by the lifetime of ctx.
+func runStage(ctx context.Context, timeout time.Duration, stage func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := stage(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return microerror.Mask(context.DeadlineExceeded)
+	}
+
+	return err
+}
+
+// stageFailed accounts the failure of the given stage and returns the error
+// to be reported for the attempt. Stages interrupted because ctx is done are
+// not accounted as failures.
+func stageFailed(ctx context.Context, instanceName string, version string, stage string, timeout time.Duration, err error) error {
+	if ctx.Err() != nil {
+		return microerror.Mask(ctx.Err())
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		failuresTotal.WithLabelValues(instanceName, strings.ToUpper(version), stage, key.ErrorClassTimeout).Inc()
+		return microerror.Maskf(timeoutError, "etcd %#q %s exceeded timeout of %s", version, stage, timeout)
+	}
+
+	failuresTotal.WithLabelValues(instanceName, strings.ToUpper(version), stage, key.ErrorClassFailure).Inc()
+	return microerror.Maskf(executionFailedError, "etcd %#q %s failed with error %#q", version, stage, err)
+}
 
-	return metrics.NewSuccessfulBackupAttemptResult(backupSize, creationTime, encryptionTime, uploadTime, filepath.Base(path)), nil
+// metricsVersion returns the etcd version label the way the collector
+// reports it, e.g. "V3".
+func metricsVersion(b etcd.Backupper) string {
+	return strings.ToUpper(b.Version())
 }
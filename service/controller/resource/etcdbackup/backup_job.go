@@ -0,0 +1,213 @@
+package etcdbackup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/apiextensions-backup/api/v1alpha1"
+	"github.com/giantswarm/microerror"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/backupjob"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/metrics"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+// backupJobSlack is the time a backup Job may take on top of its attempts,
+// e.g. to be scheduled and to discover the instance.
+const backupJobSlack = 10 * time.Minute
+
+// backupJobCreated returns whether the Job backing up an instance for backup
+// exists. It is always false when backups are run inline.
+func (r *Resource) backupJobCreated(ctx context.Context, backup v1alpha1.ETCDBackup, instanceName string) (bool, error) {
+	if r.jobs == nil {
+		return false, nil
+	}
+
+	_, err := r.getBackupJob(ctx, backup, instanceName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
+
+// backupInJob runs the backup of an instance in a Job. The Job is created
+// and false returned until it finished. Then the result of the backup is
+// read from the pod of the Job. The Job uploads the attachments of the backup
+// itself.
+func (r *Resource) backupInJob(ctx context.Context, backup v1alpha1.ETCDBackup, instanceName string, attachments Attachments, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, bool, error) {
+	job, err := r.getBackupJob(ctx, backup, instanceName)
+	if apierrors.IsNotFound(err) {
+		spec := backupjob.Spec{
+			Backup: backup.Name,
+			Owner: metav1.OwnerReference{
+				APIVersion: v1alpha1.GroupVersion.String(),
+				Kind:       "ETCDBackup",
+				Name:       backup.Name,
+				UID:        backup.UID,
+			},
+			Instance:       instanceName,
+			Installation:   r.installation,
+			Encrypt:        r.encrypt,
+			DRBundle:       attachments.DRBundle,
+			Inventory:      attachments.Inventory,
+			CreateTimeout:  timeouts.Create,
+			EncryptTimeout: timeouts.Encrypt,
+			UploadTimeout:  timeouts.Upload,
+			Deadline:       backupJobDeadline(timeouts),
+		}
+
+		err = r.k8sClient.CtrlClient().Create(ctx, r.jobs.Job(spec))
+		if apierrors.IsAlreadyExists(err) {
+			return nil, false, nil
+		}
+		// The Job is the attempt of the operator, its retries are reported
+		// with its result.
+		attemptsTotal.WithLabelValues(instanceName, key.ETCDVersionV3).Inc()
+		if err != nil {
+			// The Job is rejected, e.g. because of an invalid template, and
+			// would be rejected again.
+			r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("Failed to create backup job of instance %s", instanceName), "reason", microerror.Pretty(err, true))
+			failuresTotal.WithLabelValues(instanceName, key.ETCDVersionV3, stageJob, key.ErrorClassFailure).Inc()
+			return metrics.NewFailedBackupAttemptResult(stageJob), true, microerror.Maskf(executionFailedError, "creating backup job failed with error %#q", err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Created backup job %s/%s for instance %s", r.jobs.Namespace, r.jobs.Name(backup.Name, instanceName), instanceName))
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, microerror.Mask(err)
+	}
+
+	finished, succeeded := backupjob.Finished(job)
+	if !finished {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Backup job %s/%s of instance %s is running", job.Namespace, job.Name, instanceName))
+		return nil, false, nil
+	}
+
+	if !succeeded && backupjob.FailureReason(job) == batchv1.JobReasonDeadlineExceeded {
+		// The pod of the Job was killed, whatever it reported.
+		r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("Backup job %s/%s of instance %s exceeded its deadline", job.Namespace, job.Name, instanceName))
+		failuresTotal.WithLabelValues(instanceName, key.ETCDVersionV3, stageJob, key.ErrorClassTimeout).Inc()
+		return metrics.NewFailedBackupAttemptResult(stageJob), true, microerror.Maskf(timeoutError, "backup job %s failed: %s", job.Name, backupjob.FailureMessage(job))
+	}
+
+	result, err := r.backupJobResult(ctx, job)
+	if err != nil {
+		// The pod of the Job was killed before it could write its result,
+		// e.g. because it ran out of memory.
+		r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("Backup job %s/%s of instance %s finished without result", job.Namespace, job.Name, instanceName), "reason", err)
+		failuresTotal.WithLabelValues(instanceName, key.ETCDVersionV3, stageJob, key.ErrorClassFailure).Inc()
+		return metrics.NewFailedBackupAttemptResult(stageJob), true, microerror.Maskf(executionFailedError, "backup job %s failed: %s", job.Name, err)
+	}
+	retriesTotal.WithLabelValues(instanceName, key.ETCDVersionV3).Add(float64(result.Retries))
+	if result.Error != "" {
+		stage := result.Stage
+		if stage == "" {
+			// The backup failed before its first attempt, e.g. because
+			// the instance could not be discovered.
+			stage = stageJob
+		}
+		failuresTotal.WithLabelValues(instanceName, key.ETCDVersionV3, stage, result.ErrorClass).Inc()
+		// The snapshot of a backup whose DR bundle or inventory failed is
+		// uploaded nonetheless.
+		res := metrics.NewFailedBackupAttemptResult(stage)
+		res.Filename = result.Filename
+		if result.ErrorClass == key.ErrorClassTimeout {
+			return res, true, microerror.Maskf(timeoutError, "backup job %s failed: %s", job.Name, result.Error)
+		}
+		return res, true, microerror.Maskf(executionFailedError, "backup job %s failed: %s", job.Name, result.Error)
+	}
+
+	creationTime := time.Duration(result.CreationTime) * time.Millisecond
+	encryptionTime := time.Duration(result.EncryptionTime) * time.Millisecond
+	uploadTime := time.Duration(result.UploadTime) * time.Millisecond
+	stageDuration.WithLabelValues(instanceName, key.ETCDVersionV3, stageCreation).Observe(creationTime.Seconds())
+	stageDuration.WithLabelValues(instanceName, key.ETCDVersionV3, stageEncryption).Observe(encryptionTime.Seconds())
+	stageDuration.WithLabelValues(instanceName, key.ETCDVersionV3, stageUpload).Observe(uploadTime.Seconds())
+	if uploadTime > 0 {
+		uploadThroughput.WithLabelValues(instanceName, key.ETCDVersionV3).Observe(float64(result.Size) / uploadTime.Seconds())
+	}
+	successesTotal.WithLabelValues(instanceName, key.ETCDVersionV3).Inc()
+
+	return metrics.NewSuccessfulBackupAttemptResult(result.Size, result.CreationTime, result.EncryptionTime, result.UploadTime, result.Filename), true, nil
+}
+
+func (r *Resource) getBackupJob(ctx context.Context, backup v1alpha1.ETCDBackup, instanceName string) (*batchv1.Job, error) {
+	job := &batchv1.Job{}
+	err := r.k8sClient.CtrlClient().Get(ctx, client.ObjectKey{Namespace: r.jobs.Namespace, Name: r.jobs.Name(backup.Name, instanceName)}, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// backupJobResult reads the result of a finished Job from the termination
+// message of its pod.
+func (r *Resource) backupJobResult(ctx context.Context, job *batchv1.Job) (backupjob.Result, error) {
+	pods := &corev1.PodList{}
+	err := r.k8sClient.CtrlClient().List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{batchv1.ControllerUidLabel: string(job.UID)})
+	if err != nil {
+		return backupjob.Result{}, microerror.Mask(err)
+	}
+	if len(pods.Items) == 0 {
+		return backupjob.Result{}, microerror.Maskf(executionFailedError, "job has no pod: %s", backupjob.FailureMessage(job))
+	}
+
+	// Jobs run a single pod, as they are not retried.
+	result, err := backupjob.ResultFromPod(&pods.Items[0])
+	if err != nil {
+		return backupjob.Result{}, microerror.Mask(err)
+	}
+
+	return result, nil
+}
+
+// deleteSucceededBackupJobs deletes the Jobs of backup which succeeded once
+// the results of all instances are persisted. Failed Jobs are kept for
+// debugging and deleted with the ETCDBackup.
+func (r *Resource) deleteSucceededBackupJobs(ctx context.Context, backup v1alpha1.ETCDBackup) error {
+	if r.jobs == nil {
+		return nil
+	}
+
+	jobs := &batchv1.JobList{}
+	err := r.k8sClient.CtrlClient().List(ctx, jobs, client.InNamespace(r.jobs.Namespace), client.MatchingLabels{backupjob.LabelBackup: backup.Name})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if _, succeeded := backupjob.Finished(job); !succeeded {
+			continue
+		}
+
+		err = r.k8sClient.CtrlClient().Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !apierrors.IsNotFound(err) {
+			return microerror.Mask(err)
+		}
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Deleted backup job %s/%s", job.Namespace, job.Name))
+	}
+
+	return nil
+}
+
+// backupJobDeadline bounds a backup Job by the timeouts of the stages of all
+// its attempts. Jobs are unbounded when any of their stages is.
+func backupJobDeadline(timeouts giantnetes.Timeouts) time.Duration {
+	if timeouts.Create == 0 || timeouts.Encrypt == 0 || timeouts.Upload == 0 {
+		return 0
+	}
+
+	return time.Duration(maxBackupAttempts)*(timeouts.Create+timeouts.Encrypt+timeouts.Upload) + backupJobSlack
+}
@@ -0,0 +1,87 @@
+package etcdbackup
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/metrics"
+)
+
+func Test_retryBackup(t *testing.T) {
+	testCases := []struct {
+		name             string
+		canceled         bool
+		attempt          func(cancel context.CancelFunc) (*metrics.BackupAttemptResult, error)
+		expectedAttempts int
+		errorMatcher     func(error) bool
+	}{
+		{
+			name: "case 0: successful attempt",
+			attempt: func(cancel context.CancelFunc) (*metrics.BackupAttemptResult, error) {
+				return metrics.NewSuccessfulBackupAttemptResult(1, 1, 1, 1, "backup"), nil
+			},
+			expectedAttempts: 1,
+		},
+		{
+			name:     "case 1: canceled before the first attempt",
+			canceled: true,
+			attempt: func(cancel context.CancelFunc) (*metrics.BackupAttemptResult, error) {
+				return metrics.NewSuccessfulBackupAttemptResult(1, 1, 1, 1, "backup"), nil
+			},
+			expectedAttempts: 0,
+			errorMatcher:     isCanceled,
+		},
+		{
+			name: "case 2: canceled during an attempt",
+			attempt: func(cancel context.CancelFunc) (*metrics.BackupAttemptResult, error) {
+				cancel()
+				return metrics.NewFailedBackupAttemptResult(stageCreation), microerror.Mask(context.Canceled)
+			},
+			expectedAttempts: 1,
+			errorMatcher:     isCanceled,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			r := &Runner{logger: microloggertest.New()}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.canceled {
+				cancel()
+			}
+
+			attempts := 0
+			_, err := r.retryBackup(ctx, "test", "V3", func() (*metrics.BackupAttemptResult, error) {
+				attempts++
+				return tc.attempt(cancel)
+			})
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if attempts != tc.expectedAttempts {
+				t.Fatalf("attempts == %d, want %d", attempts, tc.expectedAttempts)
+			}
+		})
+	}
+}
+
+func isCanceled(err error) bool {
+	return microerror.Cause(err) == context.Canceled
+}
@@ -3,13 +3,14 @@ package etcdbackup
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/giantswarm/apiextensions-backup/api/v1alpha1"
 	"github.com/giantswarm/microerror"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/metrics"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
 	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
 	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/resource/etcdbackup/internal/state"
@@ -29,7 +30,7 @@ func (r *Resource) backupRunningV3BackupRunningTransition(ctx context.Context, o
 	return backupStateRunningV3BackupCompleted, nil
 }
 
-func (r *Resource) doV3Backup(ctx context.Context, etcdInstance giantnetes.ETCDInstance, instanceStatus *v1alpha1.ETCDInstanceBackupStatusIndex) bool {
+func (r *Resource) doV3Backup(ctx context.Context, backup v1alpha1.ETCDBackup, etcdInstance giantnetes.ETCDInstance, instanceStatus *v1alpha1.ETCDInstanceBackupStatusIndex) bool {
 	// If state is terminal, there's nothing else we can do on this instance, so just skip to next one.
 	if isTerminalInstaceState(instanceStatus.V3.Status) {
 		return false
@@ -42,33 +43,43 @@ func (r *Resource) doV3Backup(ctx context.Context, etcdInstance giantnetes.ETCDI
 		return true
 	}
 
-	etcdSettings := etcdInstance.ETCDv3
-
-	if etcdSettings.AreComplete() {
-		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Starting v3 backup on instance %s", instanceStatus.Name))
+	// A backup running in a Job is followed until the Job finished, even
+	// when the instance can not be reached or is deferred since.
+	var errorClass string
+	jobCreated, err := r.backupJobCreated(ctx, backup, instanceStatus.Name)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Failed to get backup job of instance %s", instanceStatus.Name), "reason", err)
+		return true
+	}
 
-		backupper, err := etcd.NewV3Backup(etcdSettings.TLSConfig, etcdSettings.Proxy, r.encryptionPwd, etcdSettings.Endpoints, r.logger, key.FilenamePrefix(r.installation, instanceStatus.Name))
-		if err != nil {
-			r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("Failed to prepare v3 backup instance %s", instanceStatus.Name), "reason", microerror.Pretty(err, true))
-			instanceStatus.V3.LatestError = err.Error()
-			instanceStatus.V3.Status = instanceBackupStateFailed
+	if jobCreated {
+		var finished bool
+		errorClass, finished = r.backupInstance(ctx, backup, etcdInstance, instanceStatus)
+		if !finished {
 			return true
 		}
+	} else if etcdInstance.Failure != nil {
+		r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("V3 backup failed for %s because it can not be reached.", instanceStatus.Name), "reason", etcdInstance.Failure.Reason, "details", etcdInstance.Failure.Message)
+		failuresTotal.WithLabelValues(instanceStatus.Name, key.ETCDVersionV3, stageDiscovery, key.ErrorClassFailure).Inc()
+		discoveryFailuresTotal.WithLabelValues(instanceStatus.Name, etcdInstance.Failure.Reason).Inc()
+		instanceStatus.Error = etcdInstance.Failure.String()
+		instanceStatus.V3.LatestError = etcdInstance.Failure.String()
+		instanceStatus.V3.Status = instanceBackupStateFailed
+		errorClass = key.ErrorClassFailure
+	} else if deferral := r.controlPlaneDeferral(ctx, etcdInstance); deferral != nil {
+		r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("V3 backup skipped for %s because its control plane is not ready.", instanceStatus.Name), "reason", deferral.Reason, "details", deferral.Message)
+		deferralsTotal.WithLabelValues(instanceStatus.Name, deferral.Reason).Inc()
+		instanceStatus.V3.LatestError = deferral.String()
+		instanceStatus.V3.Status = instanceBackupStateSkipped
+	} else if etcdInstance.ETCDv3.AreComplete() {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Starting v3 backup on instance %s", instanceStatus.Name), "tenants", strings.Join(etcdInstance.Tenants, ","))
 
-		backupAttemptResult, err := r.performBackup(ctx, backupper, instanceStatus.Name)
-		if err == nil {
-			// Backup was successful.
-			instanceStatus.V3.LatestError = ""
-			instanceStatus.V3.Status = instanceBackupStateCompleted
-			instanceStatus.V3.CreationTime = backupAttemptResult.CreationTimeMeasurement
-			instanceStatus.V3.EncryptionTime = backupAttemptResult.EncryptionTimeMeasurement
-			instanceStatus.V3.UploadTime = backupAttemptResult.UploadTimeMeasurement
-			instanceStatus.V3.BackupFileSize = backupAttemptResult.BackupSizeMeasurement
-			instanceStatus.V3.Filename = backupAttemptResult.Filename
-		} else {
-			// Backup was unsuccessful.
-			instanceStatus.V3.LatestError = err.Error()
-			instanceStatus.V3.Status = instanceBackupStateFailed
+		var finished bool
+		errorClass, finished = r.backupInstance(ctx, backup, etcdInstance, instanceStatus)
+		if !finished {
+			// Instances are backed up one after the other, the Job is
+			// checked again on the next reconciliation.
+			return true
 		}
 	} else {
 		r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("V3 backup skipped for %s because ETCD V3 settings are not set.", instanceStatus.Name))
@@ -76,6 +87,86 @@ func (r *Resource) doV3Backup(ctx context.Context, etcdInstance giantnetes.ETCDI
 	}
 
 	instanceStatus.V3.FinishedTimestamp = metav1.Time{Time: time.Now().UTC()}
+	r.history.Observe(instanceStatus.Name, key.ETCDVersionV3, key.HistoryEntry(*instanceStatus.V3, errorClass))
 
 	return true
 }
+
+// backupInstance takes the backup of an instance, sets its outcome in
+// instanceStatus and returns the class of the error of failed backups.
+// Backups run in Jobs are only started or checked, it returns false until
+// their Job finished.
+func (r *Resource) backupInstance(ctx context.Context, backup v1alpha1.ETCDBackup, etcdInstance giantnetes.ETCDInstance, instanceStatus *v1alpha1.ETCDInstanceBackupStatusIndex) (string, bool) {
+	timeouts := r.timeouts.Merge(etcdInstance.Timeouts)
+
+	attachments := Attachments{
+		DRBundle:  r.drBundleEnabled(etcdInstance),
+		Inventory: r.inventory && etcdInstance.Name == key.ManagementCluster,
+	}
+
+	var backupAttemptResult *metrics.BackupAttemptResult
+	var err error
+	if r.jobs != nil {
+		var finished bool
+		backupAttemptResult, finished, err = r.backupInJob(ctx, backup, instanceStatus.Name, attachments, timeouts)
+		if !finished {
+			return "", false
+		}
+	} else {
+		backupAttemptResult, err = r.runner.Run(ctx, etcdInstance.ETCDv3, instanceStatus.Name, timeouts)
+		if err == nil {
+			// Without its DR bundle or inventory the backup may not be
+			// enough to rebuild the cluster, so the backup is reported as
+			// failed. The uploaded snapshot is kept.
+			_, err = r.runner.UploadAttachments(ctx, etcdInstance, backupAttemptResult.Filename, attachments, timeouts)
+		}
+	}
+	if err == nil {
+		// Backup was successful.
+		instanceStatus.V3.LatestError = ""
+		instanceStatus.V3.Status = instanceBackupStateCompleted
+		instanceStatus.V3.CreationTime = backupAttemptResult.CreationTimeMeasurement
+		instanceStatus.V3.EncryptionTime = backupAttemptResult.EncryptionTimeMeasurement
+		instanceStatus.V3.UploadTime = backupAttemptResult.UploadTimeMeasurement
+		instanceStatus.V3.BackupFileSize = backupAttemptResult.BackupSizeMeasurement
+		instanceStatus.V3.Filename = backupAttemptResult.Filename
+	} else {
+		// Backup was unsuccessful. The snapshot of a backup whose DR
+		// bundle or inventory failed is uploaded nonetheless.
+		if backupAttemptResult != nil && backupAttemptResult.Filename != "" {
+			instanceStatus.V3.Filename = backupAttemptResult.Filename
+		}
+		instanceStatus.V3.LatestError = err.Error()
+		instanceStatus.V3.Status = instanceBackupStateFailed
+		return ErrorClass(err), true
+	}
+
+	return "", true
+}
+
+// controlPlaneDeferral returns why the backup of a workload cluster is
+// deferred, or nil when it can be taken or is forced. Backups are taken when
+// the control plane can not be checked.
+func (r *Resource) controlPlaneDeferral(ctx context.Context, etcdInstance giantnetes.ETCDInstance) *giantnetes.Deferral {
+	if !etcdInstance.IsWorkloadCluster() {
+		return nil
+	}
+
+	utils, err := giantnetes.NewUtils(r.logger, r.k8sClient)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Failed to check control plane of instance %s", etcdInstance.Name), "reason", err)
+		return nil
+	}
+
+	deferral, err := utils.ControlPlaneDeferral(ctx, etcdInstance)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Failed to check control plane of instance %s", etcdInstance.Name), "reason", err)
+		return nil
+	}
+	if deferral != nil && etcdInstance.Force {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Forcing v3 backup of instance %s whose control plane is not ready", etcdInstance.Name), "reason", deferral.Reason, "details", deferral.Message)
+		return nil
+	}
+
+	return deferral
+}
@@ -26,6 +26,12 @@ func (r *Resource) backupRunningV3BackupCompletedTransition(ctx context.Context,
 		return "", microerror.Mask(err)
 	}
 
+	// The results of the Jobs are persisted, so that they can go.
+	err = r.deleteSucceededBackupJobs(ctx, customObject)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "Failed to delete succeeded backup jobs", "reason", err)
+	}
+
 	for _, i := range customObject.Status.Instances {
 		if i.Error != "" || i.V3.Status == instanceBackupStateFailed {
 			return backupStateFailed, nil
@@ -0,0 +1,74 @@
+package etcdbackup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/drbundle"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+// drBundleEnabled returns whether the DR bundle of the instance is uploaded
+// with its backup. Only workload clusters have a DR bundle. Annotations take
+// precedence over the global setting.
+func (r *Resource) drBundleEnabled(etcdInstance giantnetes.ETCDInstance) bool {
+	if !etcdInstance.IsWorkloadCluster() {
+		return false
+	}
+	if etcdInstance.DRBundle != nil {
+		return *etcdInstance.DRBundle
+	}
+
+	return r.drBundle
+}
+
+// uploadDRBundle collects the management cluster objects of the workload
+// cluster and uploads them encrypted next to the backup named backup.
+func (r *Runner) uploadDRBundle(ctx context.Context, etcdInstance giantnetes.ETCDInstance, backup string, timeouts giantnetes.Timeouts) error {
+	name, ok := drbundle.Filename(backup)
+	if !ok {
+		return microerror.Maskf(executionFailedError, "backup %#q is not named like a backup", backup)
+	}
+
+	utils, err := giantnetes.NewUtils(r.logger, r.k8sClient)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	fpath := filepath.Join(dir, name)
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Creating DR bundle %s", name))
+	err = runStage(ctx, timeouts.Create, func(ctx context.Context) error {
+		objs, err := utils.DRBundleObjects(ctx, etcdInstance)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return drbundle.Write(ctx, fpath, objs, r.encryptionPwd)
+	})
+	if err != nil {
+		return stageFailed(ctx, etcdInstance.Name, key.ETCDVersionV3, stageDRBundle, timeouts.Create, err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Uploading DR bundle %s", name))
+	err = runStage(ctx, timeouts.Upload, func(ctx context.Context) error {
+		_, err := r.uploader.Upload(ctx, fpath)
+		return err
+	})
+	if err != nil {
+		return stageFailed(ctx, etcdInstance.Name, key.ETCDVersionV3, stageDRBundle, timeouts.Upload, err)
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package etcdbackup
 
 import (
 	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
 )
 
 // executionFailedError should never be matched against and therefore there is
@@ -20,3 +22,22 @@ var invalidConfigError = &microerror.Error{
 func IsInvalidConfig(err error) bool {
 	return microerror.Cause(err) == invalidConfigError
 }
+
+var timeoutError = &microerror.Error{
+	Kind: "timeoutError",
+}
+
+// IsTimeout asserts timeoutError.
+func IsTimeout(err error) bool {
+	return microerror.Cause(err) == timeoutError
+}
+
+// ErrorClass returns the class of the error of a failed backup as exposed in
+// metrics.
+func ErrorClass(err error) string {
+	if IsTimeout(err) {
+		return key.ErrorClassTimeout
+	}
+
+	return key.ErrorClassFailure
+}
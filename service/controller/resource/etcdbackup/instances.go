@@ -13,7 +13,7 @@ import (
 	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
 )
 
-func (r *Resource) runBackupOnAllInstances(ctx context.Context, obj interface{}, handler func(context.Context, giantnetes.ETCDInstance, *v1alpha1.ETCDInstanceBackupStatusIndex) bool) (bool, error) {
+func (r *Resource) runBackupOnAllInstances(ctx context.Context, obj interface{}, handler func(context.Context, v1alpha1.ETCDBackup, giantnetes.ETCDInstance, *v1alpha1.ETCDInstanceBackupStatusIndex) bool) (bool, error) {
 	customObject, err := key.ToCustomObject(obj)
 	if err != nil {
 		return false, microerror.Mask(err)
@@ -102,10 +102,35 @@ func (r *Resource) runBackupOnAllInstances(ctx context.Context, obj interface{},
 		}
 	}
 
+	// Timeouts annotated on the CR take precedence over the ones of the cluster.
+	crTimeouts, err := giantnetes.TimeoutsFromAnnotations(customObject.Annotations)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "Ignoring timeout annotations of the ETCDBackup", "reason", err)
+		crTimeouts = giantnetes.Timeouts{}
+	}
+
+	// So does the DR bundle annotation.
+	crDRBundle, err := giantnetes.DRBundleFromAnnotations(customObject.Annotations)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "Ignoring DR bundle annotation of the ETCDBackup", "reason", err)
+		crDRBundle = nil
+	}
+
+	force, err := giantnetes.ForceBackupFromAnnotations(customObject.Annotations)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "Ignoring force annotation of the ETCDBackup", "reason", err)
+		force = false
+	}
+
 	for _, etcdInstance := range instances {
+		etcdInstance.Timeouts = etcdInstance.Timeouts.Merge(crTimeouts)
+		if crDRBundle != nil {
+			etcdInstance.DRBundle = crDRBundle
+		}
+		etcdInstance.Force = force
 		instanceStatus := r.findOrInitializeInstanceStatus(ctx, customObject, etcdInstance.Name)
 
-		doneSomething := handler(ctx, etcdInstance, &instanceStatus)
+		doneSomething := handler(ctx, customObject, etcdInstance, &instanceStatus)
 
 		if doneSomething {
 			customObject.Status.Instances[etcdInstance.Name] = instanceStatus
@@ -115,6 +140,7 @@ func (r *Resource) runBackupOnAllInstances(ctx context.Context, obj interface{},
 				return false, microerror.Mask(err)
 			}
 			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("set resource status for instance '%s'", etcdInstance.Name))
+			r.notifyInstanceFailed(ctx, customObject, instanceStatus)
 			r.logger.LogCtx(ctx, "level", "debug", "message", "canceling reconciliation")
 			reconciliationcanceledcontext.SetCanceled(ctx)
 			return true, nil
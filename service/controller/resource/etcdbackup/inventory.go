@@ -0,0 +1,67 @@
+package etcdbackup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/inventory"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+// uploadInventory exports the CAPI inventory of the management cluster and
+// uploads it encrypted next to the backup named backup.
+func (r *Runner) uploadInventory(ctx context.Context, etcdInstance giantnetes.ETCDInstance, backup string, timeouts giantnetes.Timeouts) error {
+	name, ok := inventory.Filename(backup)
+	if !ok {
+		return microerror.Maskf(executionFailedError, "backup %#q is not named like a backup", backup)
+	}
+
+	utils, err := giantnetes.NewUtils(r.logger, r.k8sClient)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	fpath := filepath.Join(dir, name)
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Creating inventory %s", name))
+	err = runStage(ctx, timeouts.Create, func(ctx context.Context) error {
+		objs, err := utils.Inventory(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		m := inventory.Manifest{
+			Installation:      r.installation,
+			CreationTimestamp: time.Now().UTC(),
+			Backup:            backup,
+		}
+
+		return inventory.Write(ctx, fpath, m, objs, r.encryptionPwd)
+	})
+	if err != nil {
+		return stageFailed(ctx, etcdInstance.Name, key.ETCDVersionV3, stageInventory, timeouts.Create, err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Uploading inventory %s", name))
+	err = runStage(ctx, timeouts.Upload, func(ctx context.Context) error {
+		_, err := r.uploader.Upload(ctx, fpath)
+		return err
+	})
+	if err != nil {
+		return stageFailed(ctx, etcdInstance.Name, key.ETCDVersionV3, stageInventory, timeouts.Upload, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package etcdbackup
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "etcd_backup"
+
+	labelTenantClusterID = "tenant_cluster_id"
+	labelETCDVersion     = "etcd_version"
+	labelStage           = "stage"
+	labelErrorClass      = "error_class"
+	labelReason          = "reason"
+
+	// Stages of a backup attempt.
+	stageCreation   = "creation"
+	stageEncryption = "encryption"
+	stageUpload     = "upload"
+	// stageDiscovery prepares the connection to etcd before the attempt.
+	// The reasons of its failures are counted by discoveryFailuresTotal.
+	stageDiscovery = "discovery"
+	// stageDRBundle creates and uploads the DR bundle of a workload
+	// cluster after its backup was uploaded.
+	stageDRBundle = "dr_bundle"
+	// stageInventory exports and uploads the CAPI inventory of the
+	// management cluster after its backup was uploaded.
+	stageInventory = "inventory"
+	// stageAgent dispatches the backup to a node agent and follows it. The
+	// stages run by the agent are labelled like the ones of the operator.
+	stageAgent = "agent"
+	// stageVerification checks the backup uploaded by a node agent in the
+	// storage.
+	stageVerification = "verification"
+	// stageJob runs the backup in a Kubernetes Job. Jobs which could not be
+	// created or did not report the stage they failed in are labelled with
+	// it.
+	stageJob = "job"
+)
+
+var (
+	attemptsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "attempts_total",
+			Help:      "Number of backup attempts.",
+		},
+		[]string{labelTenantClusterID, labelETCDVersion},
+	)
+
+	retriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "retries_total",
+			Help:      "Number of backup attempts which were retries of a previously failed attempt.",
+		},
+		[]string{labelTenantClusterID, labelETCDVersion},
+	)
+
+	successesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "successes_total",
+			Help:      "Number of successful backup attempts.",
+		},
+		[]string{labelTenantClusterID, labelETCDVersion},
+	)
+
+	failuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "failures_total",
+			Help:      "Number of failed backup attempts by the stage that failed and the class of the error.",
+		},
+		[]string{labelTenantClusterID, labelETCDVersion, labelStage, labelErrorClass},
+	)
+
+	discoveryFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "discovery_failures_total",
+			Help:      "Number of backups failed because the cluster could not be reached, by reason.",
+		},
+		[]string{labelTenantClusterID, labelReason},
+	)
+
+	deferralsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "deferrals_total",
+			Help:      "Number of backups skipped because the control plane of the cluster was not ready, by reason.",
+		},
+		[]string{labelTenantClusterID, labelReason},
+	)
+
+	stageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "stage_duration_seconds",
+			Help:      "Duration of the successful stages of backup attempts.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 13),
+		},
+		[]string{labelTenantClusterID, labelETCDVersion, labelStage},
+	)
+
+	uploadThroughput = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "upload_throughput_bytes_per_second",
+			Help:      "Throughput of the backup uploads.",
+			Buckets:   prometheus.ExponentialBuckets(64*1024, 2, 15),
+		},
+		[]string{labelTenantClusterID, labelETCDVersion},
+	)
+
+	snapshotRevision = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "snapshot_revision",
+			Help:      "etcd revision of the latest successful snapshot.",
+		},
+		[]string{labelTenantClusterID, labelETCDVersion},
+	)
+
+	compressionRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "compression_ratio",
+			Help:      "Ratio between the size of the raw snapshot and the size of its compressed archive for the latest successful snapshot.",
+		},
+		[]string{labelTenantClusterID, labelETCDVersion},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		attemptsTotal,
+		retriesTotal,
+		successesTotal,
+		failuresTotal,
+		discoveryFailuresTotal,
+		deferralsTotal,
+		stageDuration,
+		uploadThroughput,
+		snapshotRevision,
+		compressionRatio,
+	)
+}
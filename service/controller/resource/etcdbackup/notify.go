@@ -0,0 +1,34 @@
+package etcdbackup
+
+import (
+	"context"
+
+	"github.com/giantswarm/apiextensions-backup/api/v1alpha1"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/notify"
+)
+
+func (r *Resource) notifyStateChanged(ctx context.Context, customObject v1alpha1.ETCDBackup, previousState string, newState string) {
+	r.notifier.Notify(ctx, notify.Event{
+		Type:          notify.EventTypeStateChanged,
+		Installation:  r.installation,
+		Backup:        customObject.Name,
+		State:         newState,
+		PreviousState: previousState,
+	})
+}
+
+func (r *Resource) notifyInstanceFailed(ctx context.Context, customObject v1alpha1.ETCDBackup, instanceStatus v1alpha1.ETCDInstanceBackupStatusIndex) {
+	if instanceStatus.V3 == nil || instanceStatus.V3.Status != instanceBackupStateFailed {
+		return
+	}
+
+	r.notifier.Notify(ctx, notify.Event{
+		Type:         notify.EventTypeInstanceFailed,
+		Installation: r.installation,
+		Backup:       customObject.Name,
+		State:        instanceStatus.V3.Status,
+		Cluster:      instanceStatus.Name,
+		Error:        instanceStatus.V3.LatestError,
+	})
+}
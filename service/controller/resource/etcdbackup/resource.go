@@ -5,7 +5,10 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/backupjob"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/history"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/notify"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/storage"
 	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/resource/etcdbackup/internal/state"
 )
@@ -15,34 +18,69 @@ const (
 )
 
 type Config struct {
+	History                     *history.History
 	K8sClient                   k8sclient.Interface
 	Logger                      micrologger.Logger
+	Notifier                    *notify.Notifier
 	ETCDv3Settings              giantnetes.ETCDv3Settings
 	EncryptionPwd               string
 	Installation                string
 	Uploader                    storage.Uploader
 	SkipManagementClusterBackup bool
+	Timeouts                    giantnetes.Timeouts
+	// Encrypt encrypts the backups. Inline backups are encrypted with
+	// EncryptionPwd, which must be set then, backups run in Jobs with the
+	// encryption password of their template.
+	Encrypt bool
+	// DRBundle uploads a DR bundle with the backups of workload clusters
+	// unless they are annotated otherwise. It requires Encrypt.
+	DRBundle bool
+	// Inventory uploads the CAPI inventory of the management cluster with
+	// its backups. It requires Encrypt.
+	Inventory bool
+	// AgentSigningKey signs the backup jobs dispatched to the node agents
+	// of clusters with the agent-upload access mode.
+	AgentSigningKey []byte
+	// AgentStorage is where node agents upload backups to. It must be set
+	// with AgentSigningKey.
+	AgentStorage AgentStorage
+	// Jobs runs the backups of the instances as Kubernetes Jobs, together
+	// with their DR bundles and inventories. Backups are run inline when it
+	// is nil.
+	Jobs *backupjob.Config
 }
 
 type Resource struct {
+	history      *history.History
 	logger       micrologger.Logger
 	k8sClient    k8sclient.Interface
+	notifier     *notify.Notifier
 	stateMachine state.Machine
 
 	etcdV3Settings              giantnetes.ETCDv3Settings
-	encryptionPwd               string
+	encrypt                     bool
 	installation                string
-	uploader                    storage.Uploader
 	skipManagementClusterBackup bool
+	timeouts                    giantnetes.Timeouts
+	drBundle                    bool
+	inventory                   bool
+	runner                      *Runner
+	jobs                        *backupjob.Config
 }
 
 func New(config Config) (*Resource, error) {
+	if config.History == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.History must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
 	if config.K8sClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.k8sClient must not be empty", config)
 	}
+	if config.Notifier == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Notifier must not be empty", config)
+	}
 	if !config.SkipManagementClusterBackup && !config.ETCDv3Settings.AreComplete() {
 		return nil, microerror.Maskf(invalidConfigError, "%T.ETCDv3Settings must be defined", config)
 	}
@@ -52,15 +90,60 @@ func New(config Config) (*Resource, error) {
 	if config.Uploader == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Uploader must not be empty", config)
 	}
+	if config.Jobs == nil && !config.Encrypt && config.EncryptionPwd != "" {
+		// Backups were encrypted whenever the password was set, they must
+		// not silently be uploaded in plain text.
+		return nil, microerror.Maskf(invalidConfigError, "%T.Encrypt must be set when %T.EncryptionPwd is set", config, config)
+	}
+	if config.DRBundle && !config.Encrypt {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Encrypt must be set when %T.DRBundle is set", config, config)
+	}
+	if config.Inventory && !config.Encrypt {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Encrypt must be set when %T.Inventory is set", config, config)
+	}
+	if config.Jobs != nil {
+		err := config.Jobs.Validate()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// Backups run in Jobs are taken with the environment of the Jobs, the
+	// operator does not need the encryption password for them.
+	var runner *Runner
+	if config.Jobs == nil {
+		c := RunnerConfig{
+			Logger:          config.Logger,
+			EncryptionPwd:   config.EncryptionPwd,
+			Installation:    config.Installation,
+			Uploader:        config.Uploader,
+			Encrypt:         config.Encrypt,
+			K8sClient:       config.K8sClient,
+			AgentSigningKey: config.AgentSigningKey,
+			AgentStorage:    config.AgentStorage,
+		}
+
+		var err error
+		runner, err = NewRunner(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
 
 	r := &Resource{
+		history:                     config.History,
 		logger:                      config.Logger,
 		k8sClient:                   config.K8sClient,
+		notifier:                    config.Notifier,
 		etcdV3Settings:              config.ETCDv3Settings,
-		encryptionPwd:               config.EncryptionPwd,
+		encrypt:                     config.Encrypt,
 		installation:                config.Installation,
-		uploader:                    config.Uploader,
 		skipManagementClusterBackup: config.SkipManagementClusterBackup,
+		timeouts:                    config.Timeouts,
+		drBundle:                    config.DRBundle,
+		inventory:                   config.Inventory,
+		runner:                      runner,
+		jobs:                        config.Jobs,
 	}
 
 	r.configureStateMachine()
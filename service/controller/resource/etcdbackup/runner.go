@@ -0,0 +1,144 @@
+package etcdbackup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/k8sclient/v8/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/metrics"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/storage"
+)
+
+// AgentStorage is the storage node agents upload backups to, with URLs the
+// operator presigns. The operator verifies the backups there and encrypts
+// them with its encryption password.
+type AgentStorage interface {
+	storage.Deleter
+	storage.Downloader
+	storage.Lister
+	storage.UploadPresigner
+}
+
+type RunnerConfig struct {
+	Logger        micrologger.Logger
+	EncryptionPwd string
+	Installation  string
+	Uploader      storage.Uploader
+	// Encrypt encrypts the backups, DR bundles and inventories with
+	// EncryptionPwd, which must be set then.
+	Encrypt bool
+	// K8sClient reads the objects of DR bundles and inventories from the
+	// management cluster. It must be set to upload them.
+	K8sClient k8sclient.Interface
+	// AgentSigningKey signs the backup jobs dispatched to the node agents
+	// of clusters with the agent-upload access mode.
+	AgentSigningKey []byte
+	// AgentStorage is where node agents upload backups to. It must be set
+	// with AgentSigningKey.
+	AgentStorage AgentStorage
+}
+
+// Runner takes, encrypts and uploads the backup of an instance, retrying
+// failed attempts. The resource runs it inline, the backup Jobs run it in
+// their own pods.
+type Runner struct {
+	logger          micrologger.Logger
+	k8sClient       k8sclient.Interface
+	encrypt         bool
+	encryptionPwd   string
+	installation    string
+	uploader        storage.Uploader
+	agentStorage    AgentStorage
+	agentSigningKey []byte
+}
+
+func NewRunner(config RunnerConfig) (*Runner, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Installation == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Installation must not be empty", config)
+	}
+	if config.Uploader == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Uploader must not be empty", config)
+	}
+	if config.Encrypt && config.EncryptionPwd == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EncryptionPwd must not be empty when %T.Encrypt is set", config, config)
+	}
+	if len(config.AgentSigningKey) > 0 && config.AgentStorage == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AgentStorage must not be empty when %T.AgentSigningKey is set", config, config)
+	}
+
+	// Backups are only encrypted when configured so, whether the password
+	// is available or not.
+	encryptionPwd := config.EncryptionPwd
+	if !config.Encrypt {
+		encryptionPwd = ""
+	}
+
+	r := &Runner{
+		logger:          config.Logger,
+		k8sClient:       config.K8sClient,
+		encrypt:         config.Encrypt,
+		encryptionPwd:   encryptionPwd,
+		installation:    config.Installation,
+		uploader:        config.Uploader,
+		agentStorage:    config.AgentStorage,
+		agentSigningKey: config.AgentSigningKey,
+	}
+
+	return r, nil
+}
+
+// Run takes the backup of the instance reached with settings. The result of
+// the latest attempt is returned with the error of failed backups, and nil
+// when the backup could not be prepared.
+func (r *Runner) Run(ctx context.Context, settings giantnetes.ETCDv3Settings, instanceName string, timeouts giantnetes.Timeouts) (*metrics.BackupAttemptResult, error) {
+	backup, err := r.newBackup(settings, instanceName)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("Failed to prepare v3 backup instance %s", instanceName), "reason", microerror.Pretty(err, true))
+		return nil, microerror.Mask(err)
+	}
+
+	return backup(ctx, timeouts)
+}
+
+// Attachments selects what is uploaded next to the backup of an instance.
+type Attachments struct {
+	// DRBundle uploads the DR bundle of a workload cluster.
+	DRBundle bool
+	// Inventory uploads the CAPI inventory of the management cluster.
+	Inventory bool
+}
+
+// UploadAttachments uploads the attachments of the backup named backup. They
+// contain secrets, so they require encryption. The stage which failed is
+// returned with the error.
+func (r *Runner) UploadAttachments(ctx context.Context, etcdInstance giantnetes.ETCDInstance, backup string, attachments Attachments, timeouts giantnetes.Timeouts) (string, error) {
+	if (attachments.DRBundle || attachments.Inventory) && (!r.encrypt || r.k8sClient == nil) {
+		return stageJob, microerror.Maskf(invalidConfigError, "DR bundles and inventories require encryption and a Kubernetes client")
+	}
+
+	if attachments.DRBundle {
+		err := r.uploadDRBundle(ctx, etcdInstance, backup, timeouts)
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("Failed to upload DR bundle of instance %s", etcdInstance.Name), "reason", microerror.Pretty(err, true))
+			return stageDRBundle, microerror.Mask(err)
+		}
+	}
+	if attachments.Inventory {
+		// The inventory is taken right after the snapshot so that both
+		// describe the same state of the management cluster.
+		err := r.uploadInventory(ctx, etcdInstance, backup, timeouts)
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("Failed to upload inventory of instance %s", etcdInstance.Name), "reason", microerror.Pretty(err, true))
+			return stageInventory, microerror.Mask(err)
+		}
+	}
+
+	return "", nil
+}
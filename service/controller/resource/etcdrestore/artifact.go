@@ -0,0 +1,131 @@
+package etcdrestore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd/artifact"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/restore"
+)
+
+// passphraseLength is the number of random bytes of the passphrase of the
+// copy of a backup.
+const passphraseLength = 32
+
+// ensureArtifact makes the copy of the backup which is restored into the
+// workload cluster. The encryption password of the operator decrypts the
+// backups of all clusters, so it never leaves the management cluster: the
+// copy is encrypted with a passphrase generated for the restore, which is
+// kept in the secret of the restore in the workload cluster. The copy is
+// made again as long as the secret does not exist.
+func (r *Resource) ensureArtifact(ctx context.Context, wc client.Client, cr restorev1alpha1.ETCDRestore) error {
+	err := wc.Get(ctx, client.ObjectKey{Namespace: restore.Namespace, Name: restore.SecretName(cr.Name)}, &corev1.Secret{})
+	if err == nil {
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	b := make([]byte, passphraseLength)
+	_, err = rand.Read(b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	passphrase := hex.EncodeToString(b)
+
+	err = r.uploadArtifact(ctx, cr, passphrase)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.SecretName(cr.Name),
+			Namespace: restore.Namespace,
+			Labels: map[string]string{
+				restore.LabelRestore: cr.Name,
+			},
+		},
+		StringData: map[string]string{
+			restore.EnvEncryptionPassword: passphrase,
+		},
+	}
+
+	err = wc.Create(ctx, secret)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("uploaded copy of backup %s for the restore", cr.Spec.Backup))
+
+	return nil
+}
+
+// uploadArtifact downloads the backup, encrypts its snapshot with passphrase
+// and uploads it as restore.ArtifactKey.
+func (r *Resource) uploadArtifact(ctx context.Context, cr restorev1alpha1.ETCDRestore, passphrase string) error {
+	dir, err := os.MkdirTemp("", "etcd-restore")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	backup := filepath.Join(dir, "backup")
+	_, err = r.storage.Download(ctx, cr.Spec.Backup, backup)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	in, err := os.Open(backup) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer in.Close() //nolint:errcheck
+
+	fpath := filepath.Join(dir, restore.ArtifactKey(cr.Namespace, cr.Name))
+	out, err := os.OpenFile(fpath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) //nolint:gosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer out.Close() //nolint:errcheck
+
+	_, err = artifact.Reencrypt(ctx, in, out, artifact.Options{Passphrase: r.encryptionPwd}, passphrase)
+	if err != nil {
+		// Backups which can not be read will not become readable.
+		return microerror.Maskf(executionFailedError, "copying backup %#q failed with error %#q", cr.Spec.Backup, err)
+	}
+
+	err = out.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = r.storage.Upload(ctx, fpath)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deleteArtifact deletes the copy of the backup, which is only needed until
+// all members are prepared.
+func (r *Resource) deleteArtifact(ctx context.Context, cr restorev1alpha1.ETCDRestore) error {
+	err := r.storage.Delete(ctx, restore.ArtifactKey(cr.Namespace, cr.Name))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package etcdrestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/operatorkit/v7/pkg/controller/context/reconciliationcanceledcontext"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/restore"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+const (
+	// Restore phases.
+	phaseEmpty     = ""
+	phasePlanning  = "Planning"
+	phasePlanned   = "Planned"
+	phasePreparing = "Preparing"
+	phaseSwapping  = "Swapping"
+	phaseVerifying = "Verifying"
+	phaseCompleted = "Completed"
+	phaseFailed    = "Failed"
+
+	// Member phases.
+	memberPhasePending  = "Pending"
+	memberPhasePrepared = "Prepared"
+	memberPhaseSwapped  = "Swapped"
+	memberPhaseFailed   = "Failed"
+)
+
+// EnsureCreated drives an ETCDRestore through its phases. Every
+// reconciliation advances at most one phase; phases waiting for the workload
+// cluster are reconciled again until they are done or the restore timed out.
+func (r *Resource) EnsureCreated(ctx context.Context, obj interface{}) error {
+	cr, err := key.ToETCDRestore(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	current := cr.Status.Phase
+	if isFinalPhase(current) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("restore is %s", current))
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("current phase: %q", current))
+
+	if cr.Status.StartedTimestamp != nil && time.Since(cr.Status.StartedTimestamp.Time) > r.timeout {
+		err = microerror.Maskf(executionFailedError, "restore did not finish within %s", r.timeout)
+	} else {
+		switch current {
+		case phaseEmpty:
+			now := metav1.Now()
+			cr.Status.StartedTimestamp = &now
+			cr.Status.Phase = phasePlanning
+		case phasePlanning:
+			err = r.plan(ctx, &cr)
+		case phasePreparing:
+			err = r.prepare(ctx, &cr)
+		case phaseSwapping:
+			err = r.swap(ctx, &cr)
+		case phaseVerifying:
+			err = r.verify(ctx, &cr)
+		default:
+			err = microerror.Maskf(executionFailedError, "unknown phase %q", current)
+		}
+	}
+
+	if err != nil {
+		r.fail(ctx, &cr, err)
+	}
+
+	if isFinalPhase(cr.Status.Phase) && cr.Status.FinishedTimestamp == nil {
+		now := metav1.Now()
+		cr.Status.FinishedTimestamp = &now
+	}
+
+	err = r.persistStatus(ctx, cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if cr.Status.Phase != current {
+		r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("restore phase changed from %q to %q", current, cr.Status.Phase), "reason", cr.Status.Message)
+		reconciliationcanceledcontext.SetCanceled(ctx)
+	}
+
+	return nil
+}
+
+// fail moves the restore to the Failed phase, unless the error is expected to
+// go away, e.g. while the API server of the workload cluster is down.
+func (r *Resource) fail(ctx context.Context, cr *restorev1alpha1.ETCDRestore, err error) {
+	if isTransient(err) {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "restore step failed, retrying", "reason", err)
+		cr.Status.Message = err.Error()
+		return
+	}
+
+	r.logger.LogCtx(ctx, "level", "error", "message", "restore failed", "stack", microerror.JSON(err))
+
+	message := err.Error()
+	if cr.Status.Phase == phaseSwapping || cr.Status.Phase == phaseVerifying {
+		message = fmt.Sprintf("%s; members may be running the restored or the previous data directory, check the %s pods in %s", message, restore.StepSwap, restore.Namespace)
+	}
+
+	cr.Status.Phase = phaseFailed
+	cr.Status.Message = message
+
+	// The copy of the backup and its passphrase must not stay around, the
+	// pods are kept to debug the failure.
+	err = r.deleteSecret(ctx, *cr)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "failed to delete restore secret", "reason", err)
+	}
+	err = r.deleteArtifact(ctx, *cr)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "failed to delete copy of the backup", "reason", err)
+	}
+}
+
+func isFinalPhase(phase string) bool {
+	return phase == phasePlanned || phase == phaseCompleted || phase == phaseFailed
+}
+
+func isTransient(err error) bool {
+	return !IsInvalidConfig(err) &&
+		!IsBackupNotFound(err) &&
+		!IsPodFailed(err) &&
+		!IsExecutionFailed(err) &&
+		!restore.IsInvalidConfig(err) &&
+		!restore.IsInvalidMember(err)
+}
@@ -0,0 +1,34 @@
+package etcdrestore
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+// EnsureDeleted removes what the restore left in the workload cluster. It is
+// best effort, the workload cluster may be gone already.
+func (r *Resource) EnsureDeleted(ctx context.Context, obj interface{}) error {
+	cr, err := key.ToETCDRestore(obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if cr.Status.Phase == phaseSwapping {
+		// Deleting the swap pods now could leave members stopped.
+		r.logger.LogCtx(ctx, "level", "warning", "message", "restore deleted while swapping members, leaving the restore pods in place")
+		err = r.deleteSecret(ctx, cr)
+		if err == nil {
+			err = r.deleteArtifact(ctx, cr)
+		}
+	} else {
+		err = r.cleanup(ctx, cr)
+	}
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "warning", "message", "failed to clean up restore", "reason", err)
+	}
+
+	return nil
+}
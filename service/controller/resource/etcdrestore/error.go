@@ -0,0 +1,41 @@
+package etcdrestore
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var backupNotFoundError = &microerror.Error{
+	Kind: "backupNotFoundError",
+}
+
+// IsBackupNotFound asserts backupNotFoundError.
+func IsBackupNotFound(err error) bool {
+	return microerror.Cause(err) == backupNotFoundError
+}
+
+var podFailedError = &microerror.Error{
+	Kind: "podFailedError",
+}
+
+// IsPodFailed asserts podFailedError.
+func IsPodFailed(err error) bool {
+	return microerror.Cause(err) == podFailedError
+}
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
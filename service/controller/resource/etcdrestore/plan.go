@@ -0,0 +1,109 @@
+package etcdrestore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/restore"
+)
+
+// plan finds the etcd members of the cluster, checks that the backup exists
+// and records the plan and the current cluster ID in the status. Dry runs end
+// here.
+func (r *Resource) plan(ctx context.Context, cr *restorev1alpha1.ETCDRestore) error {
+	if cr.Spec.Cluster == "" || cr.Spec.Backup == "" {
+		return microerror.Maskf(invalidConfigError, "spec.cluster and spec.backup must not be empty")
+	}
+	err := etcd.CheckRestorable(cr.Spec.Backup)
+	if err != nil {
+		return microerror.Maskf(invalidConfigError, "%s", err)
+	}
+	if !cr.Spec.DryRun && r.image == "" {
+		return microerror.Maskf(invalidConfigError, "the operator is not configured with an image to run on the control plane nodes")
+	}
+
+	found, err := r.storage.List(ctx, cr.Spec.Backup)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	exists := false
+	for _, o := range found {
+		exists = exists || o.Key == cr.Spec.Backup
+	}
+	if !exists {
+		return microerror.Maskf(backupNotFoundError, "backup %#q does not exist", cr.Spec.Backup)
+	}
+
+	wc, err := r.workloadClient(ctx, *cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	pods := corev1.PodList{}
+	err = wc.List(ctx, &pods, client.InNamespace(restore.Namespace), client.MatchingLabels{
+		giantnetes.EtcdLabelComponentKey: giantnetes.EtcdLabelComponentValue,
+		giantnetes.EtcdLabelTierKey:      giantnetes.EtcdLabelTierValue,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	members, err := restore.MembersFromPods(pods.Items)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	settings, err := r.utils.CAPIETCDv3Settings(ctx, clusterKey(*cr))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	status, err := restore.Status(ctx, settings.TLSConfig, settings.Proxy, members[0].Pod)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if status.Members != len(members) {
+		return microerror.Maskf(executionFailedError, "etcd has %d members but %d etcd pods were found", status.Members, len(members))
+	}
+
+	p, err := restore.NewPlan(cr.Name, cr.UID, cr.Spec.Cluster, cr.Spec.Backup, members, r.swapDelay)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cr.Status.Plan = p.Steps()
+	cr.Status.ClusterToken = p.ClusterToken
+	cr.Status.PreviousClusterID = restore.ClusterID(status.ClusterID)
+	cr.Status.Members = nil
+	for _, m := range members {
+		cr.Status.Members = append(cr.Status.Members, restorev1alpha1.ETCDRestoreMemberStatus{
+			Name:    m.Name,
+			Node:    m.Node,
+			Pod:     m.Pod,
+			PeerURL: m.PeerURL,
+			DataDir: m.DataDir,
+			Phase:   memberPhasePending,
+		})
+	}
+
+	if cr.Spec.DryRun {
+		cr.Status.Phase = phasePlanned
+		cr.Status.Message = "dry run, the cluster was not touched"
+		for _, step := range cr.Status.Plan {
+			r.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("planned restore step: %s", step))
+		}
+		return nil
+	}
+
+	cr.Status.Phase = phasePreparing
+	cr.Status.Message = ""
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package etcdrestore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/restore"
+)
+
+// prepare restores the backup next to the data directory of every member,
+// while etcd keeps running.
+func (r *Resource) prepare(ctx context.Context, cr *restorev1alpha1.ETCDRestore) error {
+	wc, err := r.workloadClient(ctx, *cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.ensureArtifact(ctx, wc, *cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	p := r.planFromStatus(*cr)
+	podConfig := restore.PodConfig{Image: r.image, Secret: restore.SecretName(cr.Name)}
+
+	prepared := 0
+	for i, m := range p.Members {
+		pod, err := getPod(ctx, wc, restore.PodName(cr.Name, restore.StepPrepare, m))
+		if apierrors.IsNotFound(err) {
+			// The backup is downloaded right away, the URL only needs to
+			// outlive the restore.
+			url, err := r.storage.Presign(restore.ArtifactKey(cr.Namespace, cr.Name), r.timeout)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			err = wc.Create(ctx, p.PreparePod(podConfig, m, url))
+			if err != nil && !apierrors.IsAlreadyExists(err) {
+				return microerror.Mask(err)
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created prepare pod for member %s", m.Name))
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			cr.Status.Members[i].Phase = memberPhasePrepared
+			cr.Status.Members[i].Message = ""
+			prepared++
+		case corev1.PodFailed:
+			cr.Status.Members[i].Phase = memberPhaseFailed
+			cr.Status.Members[i].Message = terminationMessage(pod)
+			return microerror.Maskf(podFailedError, "preparing member %s failed: %s", m.Name, cr.Status.Members[i].Message)
+		}
+	}
+
+	if prepared == len(p.Members) {
+		// The copy of the backup and its passphrase are not needed to swap
+		// the members.
+		err = r.deleteSecret(ctx, *cr)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		err = r.deleteArtifact(ctx, *cr)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		cr.Status.Phase = phaseSwapping
+		cr.Status.Message = ""
+	} else {
+		cr.Status.Message = fmt.Sprintf("%d of %d members prepared", prepared, len(p.Members))
+	}
+
+	return nil
+}
+
+func getPod(ctx context.Context, c client.Client, name string) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: restore.Namespace, Name: name}, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	return pod, nil
+}
+
+// terminationMessage returns the message the restore command wrote when it
+// failed, which is the end of its log.
+func terminationMessage(pod *corev1.Pod) string {
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.State.Terminated != nil && s.State.Terminated.Message != "" {
+			return s.State.Terminated.Message
+		}
+	}
+
+	return fmt.Sprintf("pod %s failed", pod.Name)
+}
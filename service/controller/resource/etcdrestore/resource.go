@@ -0,0 +1,94 @@
+package etcdrestore
+
+import (
+	"time"
+
+	"github.com/giantswarm/k8sclient/v8/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/storage"
+)
+
+const (
+	Name = "etcd-restore"
+)
+
+// Storage is where the restored backups are fetched from. The copies of the
+// backups restored into the workload clusters are uploaded to it.
+type Storage interface {
+	storage.Deleter
+	storage.Downloader
+	storage.Lister
+	storage.Presigner
+	storage.Uploader
+}
+
+type Config struct {
+	K8sClient k8sclient.Interface
+	Logger    micrologger.Logger
+	Storage   Storage
+
+	EncryptionPwd string
+	// Image is the image of the operator, run on the control plane nodes.
+	Image string
+	// SwapDelay is the time between creating the swap pods and stopping
+	// etcd.
+	SwapDelay time.Duration
+	// Timeout bounds the duration of a restore.
+	Timeout time.Duration
+}
+
+type Resource struct {
+	k8sClient k8sclient.Interface
+	logger    micrologger.Logger
+	storage   Storage
+	utils     *giantnetes.Utils
+
+	encryptionPwd string
+	image         string
+	swapDelay     time.Duration
+	timeout       time.Duration
+}
+
+func New(config Config) (*Resource, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Storage == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Storage must not be empty", config)
+	}
+	if config.SwapDelay <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SwapDelay must be positive", config)
+	}
+	if config.Timeout <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Timeout must be positive", config)
+	}
+
+	utils, err := giantnetes.NewUtils(config.Logger, config.K8sClient)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r := &Resource{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+		storage:   config.Storage,
+		utils:     utils,
+
+		encryptionPwd: config.EncryptionPwd,
+		image:         config.Image,
+		swapDelay:     config.SwapDelay,
+		timeout:       config.Timeout,
+	}
+
+	return r, nil
+}
+
+func (r *Resource) Name() string {
+	return Name
+}
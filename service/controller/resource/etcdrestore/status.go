@@ -0,0 +1,73 @@
+package etcdrestore
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/restore"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+func (r *Resource) persistStatus(ctx context.Context, cr restorev1alpha1.ETCDRestore) error {
+	// Get the latest version from the API before updating it.
+	obj := restorev1alpha1.ETCDRestore{}
+	err := r.k8sClient.CtrlClient().Get(ctx, client.ObjectKey{Name: cr.Name, Namespace: cr.Namespace}, &obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	obj.Status = cr.Status
+
+	err = r.k8sClient.CtrlClient().Status().Update(ctx, &obj)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// planFromStatus restores the plan recorded in the status while planning.
+func (r *Resource) planFromStatus(cr restorev1alpha1.ETCDRestore) restore.Plan {
+	p := restore.Plan{
+		Restore:      cr.Name,
+		Cluster:      cr.Spec.Cluster,
+		Backup:       cr.Spec.Backup,
+		ClusterToken: cr.Status.ClusterToken,
+		SwapDelay:    r.swapDelay,
+	}
+
+	for _, m := range cr.Status.Members {
+		p.Members = append(p.Members, restore.Member{
+			Name:    m.Name,
+			Node:    m.Node,
+			Pod:     m.Pod,
+			PeerURL: m.PeerURL,
+			DataDir: m.DataDir,
+		})
+	}
+
+	return p
+}
+
+func clusterKey(cr restorev1alpha1.ETCDRestore) client.ObjectKey {
+	return client.ObjectKey{Namespace: cr.Namespace, Name: cr.Spec.Cluster}
+}
+
+// workloadClient returns a client for the API server of the workload
+// cluster.
+func (r *Resource) workloadClient(ctx context.Context, cr restorev1alpha1.ETCDRestore) (client.Client, error) {
+	restConfig, err := key.RESTConfig(ctx, r.k8sClient.CtrlClient(), clusterKey(cr))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	c, err := key.GetCtrlClient(restConfig)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,80 @@
+package etcdrestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/restore"
+)
+
+// swap creates the pods which stop etcd on all members at the same time and
+// start it again on the restored data directory. The API server of the
+// workload cluster is down while they run, errors listing the pods are
+// retried.
+func (r *Resource) swap(ctx context.Context, cr *restorev1alpha1.ETCDRestore) error {
+	if cr.Status.SwapNotBefore == nil {
+		// The time is persisted before the pods are created, so that pods
+		// created by a later reconciliation stop etcd at the same time.
+		notBefore := metav1.NewTime(time.Now().Add(r.swapDelay).Truncate(time.Second))
+		cr.Status.SwapNotBefore = &notBefore
+		cr.Status.Message = fmt.Sprintf("stopping etcd at %s", notBefore.UTC().Format(time.RFC3339))
+		return nil
+	}
+
+	wc, err := r.workloadClient(ctx, *cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	p := r.planFromStatus(*cr)
+	podConfig := restore.PodConfig{Image: r.image, Secret: restore.SecretName(cr.Name)}
+
+	swapped := 0
+	for i, m := range p.Members {
+		pod, err := getPod(ctx, wc, restore.PodName(cr.Name, restore.StepSwap, m))
+		if apierrors.IsNotFound(err) {
+			if time.Now().After(cr.Status.SwapNotBefore.Time) {
+				// Members which already stopped would wait for a member
+				// which never stops.
+				return microerror.Maskf(executionFailedError, "swap pod for member %s was not created before %s", m.Name, cr.Status.SwapNotBefore.UTC().Format(time.RFC3339))
+			}
+
+			err = wc.Create(ctx, p.SwapPod(podConfig, m, cr.Status.SwapNotBefore.Time))
+			if err != nil && !apierrors.IsAlreadyExists(err) {
+				return microerror.Mask(err)
+			}
+
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created swap pod for member %s", m.Name))
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			cr.Status.Members[i].Phase = memberPhaseSwapped
+			cr.Status.Members[i].Message = ""
+			swapped++
+		case corev1.PodFailed:
+			cr.Status.Members[i].Phase = memberPhaseFailed
+			cr.Status.Members[i].Message = terminationMessage(pod)
+			return microerror.Maskf(podFailedError, "swapping member %s failed: %s", m.Name, cr.Status.Members[i].Message)
+		}
+	}
+
+	if swapped == len(p.Members) {
+		cr.Status.Phase = phaseVerifying
+		cr.Status.Message = ""
+	} else {
+		cr.Status.Message = fmt.Sprintf("%d of %d members swapped", swapped, len(p.Members))
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package etcdrestore
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/restore"
+)
+
+// verify checks that all members run the restored cluster and cleans up the
+// restore pods and secret. Members need some time to elect a leader, errors
+// are retried until the restore times out.
+func (r *Resource) verify(ctx context.Context, cr *restorev1alpha1.ETCDRestore) error {
+	settings, err := r.utils.CAPIETCDv3Settings(ctx, clusterKey(*cr))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	p := r.planFromStatus(*cr)
+
+	var statuses []restore.MemberStatus
+	for _, m := range p.Members {
+		s, err := restore.Status(ctx, settings.TLSConfig, settings.Proxy, m.Pod)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		statuses = append(statuses, s)
+	}
+
+	err = p.Verify(cr.Status.PreviousClusterID, statuses)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.cleanup(ctx, *cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cr.Status.Phase = phaseCompleted
+	cr.Status.Message = "restored cluster " + restore.ClusterID(statuses[0].ClusterID)
+
+	return nil
+}
+
+// cleanup deletes the restore pods and secret from the workload cluster and
+// the copy of the backup.
+func (r *Resource) cleanup(ctx context.Context, cr restorev1alpha1.ETCDRestore) error {
+	err := r.deleteArtifact(ctx, cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	wc, err := r.workloadClient(ctx, cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = wc.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace(restore.Namespace), client.MatchingLabels{restore.LabelRestore: cr.Name})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.deleteSecret(ctx, cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *Resource) deleteSecret(ctx context.Context, cr restorev1alpha1.ETCDRestore) error {
+	wc, err := r.workloadClient(ctx, cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.SecretName(cr.Name),
+			Namespace: restore.Namespace,
+		},
+	}
+
+	err = wc.Delete(ctx, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+
+	backupv1alpha1 "github.com/giantswarm/apiextensions-backup/api/v1alpha1"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+// loadHistory loads the persisted backup history. When nothing was persisted
+// yet, e.g. right after upgrading from a version without history, it is
+// seeded from the ETCDBackup CRs which still exist.
+func (s *Service) loadHistory(ctx context.Context) error {
+	err := s.history.Load(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !s.history.Empty() {
+		return nil
+	}
+
+	backups := backupv1alpha1.ETCDBackupList{}
+	err = s.k8sClient.CtrlClient().List(ctx, &backups)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, backup := range backups.Items {
+		for _, instanceStatus := range backup.Status.Instances {
+			// Backups still running have nothing to tell yet.
+			if instanceStatus.V3 == nil || instanceStatus.V3.FinishedTimestamp.IsZero() {
+				continue
+			}
+
+			// The class of the errors is not kept in the CRs.
+			s.history.Observe(instanceStatus.Name, key.ETCDVersionV3, key.HistoryEntry(*instanceStatus.V3, ""))
+		}
+	}
+
+	s.logger.LogCtx(ctx, "level", "info", "message", "seeded backup history from ETCDBackup CRs")
+
+	return nil
+}
@@ -0,0 +1,200 @@
+// Package rpo evaluates whether every cluster has a successful backup which
+// is more recent than its recovery point objective (RPO).
+package rpo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/k8sclient/v8/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/history"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+)
+
+const (
+	defaultInterval = time.Minute
+
+	eventReasonViolated = "BackupRPOViolated"
+	eventReasonRestored = "BackupRPORestored"
+)
+
+type Config struct {
+	EventRecorder record.EventRecorder
+	History       *history.History
+	K8sClient     k8sclient.Interface
+	Logger        micrologger.Logger
+	Policy        *Policy
+
+	// Interval is how often the RPO of all clusters is evaluated. Defaults
+	// to one minute.
+	Interval                    time.Duration
+	SkipManagementClusterBackup bool
+}
+
+type Evaluator struct {
+	eventRecorder record.EventRecorder
+	history       *history.History
+	k8sClient     k8sclient.Interface
+	logger        micrologger.Logger
+	policy        *Policy
+
+	interval                    time.Duration
+	skipManagementClusterBackup bool
+
+	// started replaces the unknown creation time of the management cluster.
+	started time.Time
+	// violated holds the clusters found in violation by the previous
+	// evaluation, so Events are only raised on transitions.
+	violated map[string]bool
+}
+
+func New(config Config) (*Evaluator, error) {
+	if config.EventRecorder == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EventRecorder must not be empty", config)
+	}
+	if config.History == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.History must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Policy == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Policy must not be empty", config)
+	}
+	if config.Interval == 0 {
+		config.Interval = defaultInterval
+	}
+
+	e := &Evaluator{
+		eventRecorder: config.EventRecorder,
+		history:       config.History,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+		policy:        config.Policy,
+
+		interval:                    config.Interval,
+		skipManagementClusterBackup: config.SkipManagementClusterBackup,
+
+		started:  time.Now(),
+		violated: map[string]bool{},
+	}
+
+	return e, nil
+}
+
+// Boot evaluates the RPO of all clusters periodically until ctx is done.
+func (e *Evaluator) Boot(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := e.Evaluate(ctx)
+			if err != nil {
+				e.logger.LogCtx(ctx, "level", "error", "message", "failed to evaluate backup RPO", "stack", microerror.JSON(err))
+			}
+		}
+	}
+}
+
+// Evaluate evaluates the RPO of all clusters, updates the metrics and raises
+// Events on the cluster objects whose state changed.
+func (e *Evaluator) Evaluate(ctx context.Context) error {
+	utils, err := giantnetes.NewUtils(e.logger, e.k8sClient)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	clusters, err := utils.ListClusters(ctx)
+	if giantnetes.IsUnableToGetTenantClusters(err) {
+		// Keep evaluating the management cluster.
+		e.logger.LogCtx(ctx, "level", "warning", "message", "failed to list workload clusters", "reason", err)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !e.skipManagementClusterBackup {
+		clusters = append(clusters, giantnetes.ClusterInfo{
+			Name:              key.ManagementCluster,
+			CreationTimestamp: e.started,
+		})
+	}
+
+	lastSuccess := map[string]time.Time{}
+	for _, r := range e.history.Records() {
+		if r.LatestSuccess != nil && r.LatestSuccess.FinishedTimestamp.After(lastSuccess[r.Cluster]) {
+			lastSuccess[r.Cluster] = r.LatestSuccess.FinishedTimestamp
+		}
+	}
+
+	now := time.Now()
+	var statuses []Status
+	violated := map[string]bool{}
+	for _, c := range clusters {
+		rpo, err := e.policy.For(c.Name, c.Annotations)
+		if err != nil {
+			e.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("Ignoring RPO annotation of cluster %s", c.Name), "reason", err)
+			rpo, _ = e.policy.For(c.Name, nil)
+		}
+
+		// Clusters sharing their etcd are backed up by the instance of it.
+		instance := c.Name
+		if c.Instance != "" {
+			instance = c.Instance
+		}
+
+		s := evaluate(now, c.Name, rpo, c.Skipped, c.CreationTimestamp, lastSuccess[instance])
+		statuses = append(statuses, s)
+
+		if s.Violated {
+			violated[c.Name] = true
+		}
+		if s.Violated != e.violated[c.Name] {
+			e.recordTransition(ctx, c, s)
+		}
+	}
+
+	e.violated = violated
+	updateMetrics(statuses)
+
+	return nil
+}
+
+func (e *Evaluator) recordTransition(ctx context.Context, cluster giantnetes.ClusterInfo, s Status) {
+	var eventType, reason, message string
+	if s.Violated {
+		eventType = corev1.EventTypeWarning
+		reason = eventReasonViolated
+		if s.LastSuccess.IsZero() {
+			message = fmt.Sprintf("Cluster was never backed up successfully, RPO is %s", s.RPO)
+		} else {
+			message = fmt.Sprintf("Latest successful backup is %s old, RPO is %s", s.SinceLastSuccess.Round(time.Second), s.RPO)
+		}
+	} else {
+		eventType = corev1.EventTypeNormal
+		reason = eventReasonRestored
+		message = fmt.Sprintf("Cluster is no longer violating its RPO of %s", s.RPO)
+	}
+
+	e.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("Cluster %s: %s", cluster.Name, message), "reason", reason)
+
+	// The management cluster has no object to attach the Event to.
+	if cluster.Object == nil {
+		return
+	}
+
+	e.eventRecorder.Event(cluster.Object, eventType, reason, message)
+}
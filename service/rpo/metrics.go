@@ -0,0 +1,82 @@
+package rpo
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "etcd_backup"
+
+	labelCluster = "cluster"
+	labelReason  = "reason"
+)
+
+var (
+	rpoSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpo_seconds",
+			Help:      "Recovery point objective of the cluster in seconds.",
+		},
+		[]string{labelCluster},
+	)
+
+	rpoViolated = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpo_violated",
+			Help:      "Gauge set to 1 when the latest successful backup of the cluster is older than its recovery point objective.",
+		},
+		[]string{labelCluster},
+	)
+
+	rpoExempt = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpo_exempt",
+			Help:      "Gauge set to 1 when the cluster is exempt from the recovery point objective evaluation, labelled by the reason.",
+		},
+		[]string{labelCluster, labelReason},
+	)
+
+	secondsSinceLastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "seconds_since_last_success",
+			Help:      "Seconds since the latest successful backup of the cluster.",
+		},
+		[]string{labelCluster},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(rpoSeconds)
+	prometheus.MustRegister(rpoViolated)
+	prometheus.MustRegister(rpoExempt)
+	prometheus.MustRegister(secondsSinceLastSuccess)
+}
+
+func updateMetrics(statuses []Status) {
+	rpoSeconds.Reset()
+	rpoViolated.Reset()
+	rpoExempt.Reset()
+	secondsSinceLastSuccess.Reset()
+
+	for _, s := range statuses {
+		if !s.LastSuccess.IsZero() {
+			secondsSinceLastSuccess.WithLabelValues(s.Cluster).Set(s.SinceLastSuccess.Seconds())
+		}
+
+		if s.Exempt != "" {
+			rpoExempt.WithLabelValues(s.Cluster, s.Exempt).Set(1)
+			continue
+		}
+
+		rpoSeconds.WithLabelValues(s.Cluster).Set(s.RPO.Seconds())
+		if s.Violated {
+			rpoViolated.WithLabelValues(s.Cluster).Set(1)
+		} else {
+			rpoViolated.WithLabelValues(s.Cluster).Set(0)
+		}
+	}
+}
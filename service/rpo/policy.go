@@ -0,0 +1,123 @@
+package rpo
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dlclark/regexp2/v2"
+	"github.com/giantswarm/microerror"
+)
+
+// Annotation overrides the RPO of a workload cluster when set on its cluster
+// object. The value is a Go duration, e.g. "6h". "0" disables the evaluation
+// for the cluster.
+const Annotation = "giantswarm.io/etcd-backup-operator-rpo"
+
+// Rule sets the RPO of the clusters matched by a backup schedule. The regular
+// expressions have the same semantics as the ones of the ETCDBackup CRs.
+type Rule struct {
+	Clusters          string `json:"clusters"`
+	ClustersToExclude string `json:"clustersToExclude"`
+	RPO               string `json:"rpo"`
+}
+
+type rule struct {
+	include *regexp2.Regexp
+	exclude *regexp2.Regexp
+	rpo     time.Duration
+}
+
+// Policy tells the RPO of a cluster. The annotation of the cluster takes
+// precedence over the first matching rule, which takes precedence over the
+// default.
+type Policy struct {
+	defaultRPO time.Duration
+	rules      []rule
+}
+
+// NewPolicy creates a policy from the default RPO and the rules encoded as a
+// JSON list. A zero default disables the evaluation of clusters no rule
+// matches.
+func NewPolicy(defaultRPO time.Duration, rules string) (*Policy, error) {
+	if defaultRPO < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "default RPO must not be negative")
+	}
+
+	p := &Policy{
+		defaultRPO: defaultRPO,
+	}
+
+	if rules == "" {
+		return p, nil
+	}
+
+	var rs []Rule
+	err := json.Unmarshal([]byte(rules), &rs)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "rules must be a JSON list: %s", err)
+	}
+
+	for _, r := range rs {
+		if r.Clusters == "" {
+			r.Clusters = ".*"
+		}
+		if r.ClustersToExclude == "" {
+			r.ClustersToExclude = "^$"
+		}
+
+		include, err := regexp2.Compile(r.Clusters, regexp2.None)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "rule has invalid clusters regex %#q", r.Clusters)
+		}
+		exclude, err := regexp2.Compile(r.ClustersToExclude, regexp2.None)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "rule has invalid clusters to exclude regex %#q", r.ClustersToExclude)
+		}
+		rpo, err := parseRPO(r.RPO)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		p.rules = append(p.rules, rule{include: include, exclude: exclude, rpo: rpo})
+	}
+
+	return p, nil
+}
+
+// For returns the RPO of the given cluster. Zero means the cluster is not
+// evaluated.
+func (p *Policy) For(cluster string, annotations map[string]string) (time.Duration, error) {
+	if v, ok := annotations[Annotation]; ok && v != "" {
+		rpo, err := parseRPO(v)
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
+
+		return rpo, nil
+	}
+
+	for _, r := range p.rules {
+		if isMatch, _ := r.include.MatchString(cluster); !isMatch {
+			continue
+		}
+		if isMatch, _ := r.exclude.MatchString(cluster); isMatch {
+			continue
+		}
+
+		return r.rpo, nil
+	}
+
+	return p.defaultRPO, nil
+}
+
+func parseRPO(v string) (time.Duration, error) {
+	rpo, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, microerror.Maskf(invalidConfigError, "invalid RPO %#q", v)
+	}
+	if rpo < 0 {
+		return 0, microerror.Maskf(invalidConfigError, "RPO %#q must not be negative", v)
+	}
+
+	return rpo, nil
+}
@@ -0,0 +1,113 @@
+package rpo
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_Policy_For(t *testing.T) {
+	rules := `[{"clusters":"^prod-","clustersToExclude":"^prod-legacy$","rpo":"1h"},{"clusters":".*","rpo":"12h"}]`
+
+	testCases := []struct {
+		name         string
+		defaultRPO   time.Duration
+		rules        string
+		cluster      string
+		annotations  map[string]string
+		expected     time.Duration
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: default without rules",
+			defaultRPO:   6 * time.Hour,
+			cluster:      "abc12",
+			expected:     6 * time.Hour,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1: first matching rule wins",
+			defaultRPO:   6 * time.Hour,
+			rules:        rules,
+			cluster:      "prod-eu",
+			expected:     time.Hour,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 2: excluded cluster falls through to the next rule",
+			defaultRPO:   6 * time.Hour,
+			rules:        rules,
+			cluster:      "prod-legacy",
+			expected:     12 * time.Hour,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 3: annotation takes precedence over rules",
+			defaultRPO:   6 * time.Hour,
+			rules:        rules,
+			cluster:      "prod-eu",
+			annotations:  map[string]string{Annotation: "30m"},
+			expected:     30 * time.Minute,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 4: annotation disables the evaluation",
+			defaultRPO:   6 * time.Hour,
+			cluster:      "abc12",
+			annotations:  map[string]string{Annotation: "0"},
+			expected:     0,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 5: invalid annotation",
+			defaultRPO:   6 * time.Hour,
+			cluster:      "abc12",
+			annotations:  map[string]string{Annotation: "daily"},
+			expected:     0,
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			p, err := NewPolicy(tc.defaultRPO, tc.rules)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			rpo, err := p.For(tc.cluster, tc.annotations)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// Correct; carry on.
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if rpo != tc.expected {
+				t.Fatalf("rpo == %s, want %s", rpo, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_NewPolicy_InvalidRules(t *testing.T) {
+	for i, rules := range []string{
+		`{"clusters":".*"}`,
+		`[{"clusters":"(","rpo":"1h"}]`,
+		`[{"clusters":".*","rpo":"soon"}]`,
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := NewPolicy(time.Hour, rules)
+			if !IsInvalidConfig(err) {
+				t.Fatalf("error == %#v, want invalid config error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package rpo
+
+import (
+	"time"
+)
+
+const (
+	// Reasons why a cluster is exempt from the evaluation.
+	ExemptDisabled = "disabled"
+	ExemptNew      = "new"
+	ExemptSkipped  = "skipped"
+)
+
+// Status is the result of the evaluation of the RPO of a cluster.
+type Status struct {
+	Cluster string
+	RPO     time.Duration
+	// LastSuccess is the finish time of the latest successful backup. It is
+	// zero when the cluster was never backed up successfully.
+	LastSuccess      time.Time
+	SinceLastSuccess time.Duration
+	// Exempt is the reason why the cluster is not evaluated. It is empty when
+	// the cluster is evaluated.
+	Exempt   string
+	Violated bool
+}
+
+// evaluate tells whether a cluster violates its RPO. Clusters which are
+// skipped explicitly or have no RPO are exempt. Clusters never backed up
+// successfully are exempt as new until they exist for longer than their RPO,
+// counted from since, which is their creation time.
+func evaluate(now time.Time, cluster string, rpo time.Duration, skipped bool, since time.Time, lastSuccess time.Time) Status {
+	s := Status{
+		Cluster:     cluster,
+		RPO:         rpo,
+		LastSuccess: lastSuccess,
+	}
+
+	if !lastSuccess.IsZero() {
+		s.SinceLastSuccess = now.Sub(lastSuccess)
+	}
+
+	switch {
+	case skipped:
+		s.Exempt = ExemptSkipped
+	case rpo == 0:
+		s.Exempt = ExemptDisabled
+	case lastSuccess.IsZero() && now.Sub(since) <= rpo:
+		s.Exempt = ExemptNew
+	case lastSuccess.IsZero():
+		s.Violated = true
+	default:
+		s.Violated = s.SinceLastSuccess > rpo
+	}
+
+	return s
+}
@@ -0,0 +1,77 @@
+package rpo
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_evaluate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rpo := 6 * time.Hour
+
+	testCases := []struct {
+		name            string
+		rpo             time.Duration
+		skipped         bool
+		created         time.Time
+		lastSuccess     time.Time
+		expectedExempt  string
+		expectedViolate bool
+	}{
+		{
+			name:        "case 0: recent backup",
+			rpo:         rpo,
+			created:     now.Add(-30 * 24 * time.Hour),
+			lastSuccess: now.Add(-time.Hour),
+		},
+		{
+			name:            "case 1: backup older than the RPO",
+			rpo:             rpo,
+			created:         now.Add(-30 * 24 * time.Hour),
+			lastSuccess:     now.Add(-7 * time.Hour),
+			expectedViolate: true,
+		},
+		{
+			name:           "case 2: new cluster without backup",
+			rpo:            rpo,
+			created:        now.Add(-time.Hour),
+			expectedExempt: ExemptNew,
+		},
+		{
+			name:            "case 3: old cluster without backup",
+			rpo:             rpo,
+			created:         now.Add(-7 * time.Hour),
+			expectedViolate: true,
+		},
+		{
+			name:           "case 4: skipped cluster",
+			rpo:            rpo,
+			skipped:        true,
+			created:        now.Add(-30 * 24 * time.Hour),
+			lastSuccess:    now.Add(-7 * 24 * time.Hour),
+			expectedExempt: ExemptSkipped,
+		},
+		{
+			name:           "case 5: RPO disabled",
+			rpo:            0,
+			created:        now.Add(-30 * 24 * time.Hour),
+			expectedExempt: ExemptDisabled,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			s := evaluate(now, "abc12", tc.rpo, tc.skipped, tc.created, tc.lastSuccess)
+
+			if s.Exempt != tc.expectedExempt {
+				t.Fatalf("exempt == %q, want %q", s.Exempt, tc.expectedExempt)
+			}
+			if s.Violated != tc.expectedViolate {
+				t.Fatalf("violated == %t, want %t", s.Violated, tc.expectedViolate)
+			}
+		})
+	}
+}
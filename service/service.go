@@ -6,7 +6,9 @@ import (
 	"context"
 	"crypto/tls"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	backupv1alpha1 "github.com/giantswarm/apiextensions-backup/api/v1alpha1"
 	infrastructurev1alpha3 "github.com/giantswarm/apiextensions/v6/pkg/apis/infrastructure/v1alpha3"
@@ -17,16 +19,26 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
 
 	"github.com/giantswarm/etcd-backup-operator/v5/flag"
+	restorev1alpha1 "github.com/giantswarm/etcd-backup-operator/v5/pkg/apis/backup/v1alpha1"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/backupjob"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/certs"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/etcd"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/giantnetes"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/history"
+	"github.com/giantswarm/etcd-backup-operator/v5/pkg/notify"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/project"
 	"github.com/giantswarm/etcd-backup-operator/v5/pkg/storage"
 	"github.com/giantswarm/etcd-backup-operator/v5/service/collector"
 	"github.com/giantswarm/etcd-backup-operator/v5/service/controller"
 	"github.com/giantswarm/etcd-backup-operator/v5/service/controller/key"
+	"github.com/giantswarm/etcd-backup-operator/v5/service/rpo"
 )
 
 // Config represents the configuration used to create a new service.
@@ -41,9 +53,15 @@ type Service struct {
 	logger  micrologger.Logger
 	version *version.Service
 
-	bootOnce             sync.Once
-	etcdBackupController *controller.ETCDBackup
-	operatorCollector    *collector.Set
+	bootOnce              sync.Once
+	certReloader          *certs.Reloader
+	certReloadInterval    time.Duration
+	etcdBackupController  *controller.ETCDBackup
+	etcdRestoreController *controller.ETCDRestore
+	history               *history.History
+	k8sClient             k8sclient.Interface
+	operatorCollector     *collector.Set
+	rpoEvaluator          *rpo.Evaluator
 }
 
 // New creates a new configured service object.
@@ -120,6 +138,7 @@ func New(config Config) (*Service, error) {
 				infrastructurev1alpha3.AddToScheme,
 				providerv1alpha1.AddToScheme,
 				capi.AddToScheme,
+				restorev1alpha1.AddToScheme,
 			},
 			RestConfig: restConfig,
 		}
@@ -130,7 +149,51 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
+	var backupHistory *history.History
+	{
+		c := history.Config{
+			Client: k8sClient.CtrlClient(),
+			Logger: config.Logger,
+
+			Name:      config.Viper.GetString(config.Flag.Service.History.Name),
+			Namespace: config.Viper.GetString(config.Flag.Service.History.Namespace),
+		}
+
+		backupHistory, err = history.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var notifier *notify.Notifier
+	{
+		sinks, err := notify.ParseSinks(os.Getenv(key.EnvNotificationSinks))
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var states []string
+		if v := config.Viper.GetString(config.Flag.Service.Notifications.States); v != "" {
+			states = strings.Split(v, ",")
+		}
+
+		c := notify.Config{
+			Logger: config.Logger,
+			Sinks:  sinks,
+
+			DedupWindow: config.Viper.GetDuration(config.Flag.Service.Notifications.DedupWindow),
+			States:      states,
+		}
+
+		notifier, err = notify.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var certReloader *certs.Reloader
 	var etcdBackupController *controller.ETCDBackup
+	var etcdRestoreController *controller.ETCDRestore
 	{
 		s3Config := storage.S3Config{
 			Bucket:         config.Viper.GetString(config.Flag.Service.S3.Bucket),
@@ -152,42 +215,117 @@ func New(config Config) (*Service, error) {
 
 		skipMCBackup := config.Viper.GetBool(config.Flag.Service.SkipManagementClusterBackup)
 
+		var jobs *backupjob.Config
+		switch mode := config.Viper.GetString(config.Flag.Service.Execution.Mode); mode {
+		case key.ExecutionModeInline:
+		case key.ExecutionModeJob:
+			template, err := backupjob.ReadTemplate(config.Viper.GetString(config.Flag.Service.Execution.Job.Template))
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			jobs = &backupjob.Config{
+				Template:  template,
+				Namespace: config.Viper.GetString(config.Flag.Service.Execution.Job.Namespace),
+			}
+		default:
+			return nil, microerror.Maskf(invalidConfigError, "execution mode must be %#q or %#q, got %#q", key.ExecutionModeInline, key.ExecutionModeJob, mode)
+		}
+
 		var tlsConfig *tls.Config = nil
 		if !skipMCBackup {
-			tlsConfig, err = key.TLSConfigFromCertFiles(
-				config.Viper.GetString(config.Flag.Service.ETCDv3.CaCert),
-				config.Viper.GetString(config.Flag.Service.ETCDv3.Cert),
-				config.Viper.GetString(config.Flag.Service.ETCDv3.Key),
-			)
+			// The server name defaults to the host of the endpoint. Members
+			// of clusters with several endpoints are verified against the
+			// host they are dialed by.
+			var serverName certs.ServerName
+			endpoints := etcd.SplitEndpoints(config.Viper.GetString(config.Flag.Service.ETCDv3.Endpoints))
+			for _, e := range endpoints {
+				host, err := etcd.EndpointHost(e)
+				if err != nil {
+					return nil, microerror.Maskf(invalidConfigError, "invalid ETCD v3 endpoint %#q: %s", e, err)
+				}
+				if len(endpoints) == 1 {
+					serverName = certs.Fixed(host)
+				}
+			}
+			if name := config.Viper.GetString(config.Flag.Service.ETCDv3.ServerName); name != "" {
+				serverName = certs.Fixed(name)
+			}
+
+			c := certs.Config{
+				Logger: config.Logger,
+				Source: certs.FileSource{
+					CA:   config.Viper.GetString(config.Flag.Service.ETCDv3.CaCert),
+					Cert: config.Viper.GetString(config.Flag.Service.ETCDv3.Cert),
+					Key:  config.Viper.GetString(config.Flag.Service.ETCDv3.Key),
+				},
+				Name:               key.ManagementCluster,
+				ServerName:         serverName,
+				InsecureSkipVerify: config.Viper.GetBool(config.Flag.Service.ETCDv3.InsecureSkipVerify),
+			}
+
+			certReloader, err = certs.New(context.Background(), c)
 			if err != nil {
 				return nil, microerror.Mask(err)
 			}
+			tlsConfig = certReloader.TLSConfig()
 		}
 
 		c := controller.ETCDBackupConfig{
+			History:   backupHistory,
 			K8sClient: k8sClient,
 			Logger:    config.Logger,
+			Notifier:  notifier,
 			ETCDv3Settings: giantnetes.ETCDv3Settings{
 				Endpoints: config.Viper.GetString(config.Flag.Service.ETCDv3.Endpoints),
 				TLSConfig: tlsConfig,
 			},
+			Encrypt:                     config.Viper.GetBool(config.Flag.Service.Encryption.Enabled),
 			EncryptionPwd:               os.Getenv(key.EncryptionPassword),
 			Installation:                config.Viper.GetString(config.Flag.Service.Installation),
 			SentryDSN:                   config.Viper.GetString(config.Flag.Service.Sentry.DSN),
 			SkipManagementClusterBackup: skipMCBackup,
 			Uploader:                    uploader,
 			BackupDestination:           config.Viper.GetString(config.Flag.Service.BackupDestination),
+			Timeouts: giantnetes.Timeouts{
+				Create:  config.Viper.GetDuration(config.Flag.Service.Timeouts.Create),
+				Encrypt: config.Viper.GetDuration(config.Flag.Service.Timeouts.Encrypt),
+				Upload:  config.Viper.GetDuration(config.Flag.Service.Timeouts.Upload),
+			},
+			DRBundle:        config.Viper.GetBool(config.Flag.Service.DRBundle.Enabled),
+			Inventory:       config.Viper.GetBool(config.Flag.Service.Inventory.Enabled),
+			AgentSigningKey: []byte(os.Getenv(key.EnvAgentSigningKey)),
+			AgentStorage:    uploader,
+			Jobs:            jobs,
 		}
 
 		etcdBackupController, err = controller.NewETCDBackup(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
+
+		rc := controller.ETCDRestoreConfig{
+			K8sClient:         k8sClient,
+			Logger:            config.Logger,
+			Storage:           uploader,
+			EncryptionPwd:     os.Getenv(key.EncryptionPassword),
+			Image:             config.Viper.GetString(config.Flag.Service.Restore.Image),
+			SentryDSN:         config.Viper.GetString(config.Flag.Service.Sentry.DSN),
+			BackupDestination: config.Viper.GetString(config.Flag.Service.BackupDestination),
+			SwapDelay:         config.Viper.GetDuration(config.Flag.Service.Restore.SwapDelay),
+			Timeout:           config.Viper.GetDuration(config.Flag.Service.Restore.Timeout),
+		}
+
+		etcdRestoreController, err = controller.NewETCDRestore(rc)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 	}
 
 	var operatorCollector *collector.Set
 	{
 		c := collector.SetConfig{
+			History:   backupHistory,
 			K8sClient: k8sClient,
 			Logger:    config.Logger,
 		}
@@ -198,6 +336,36 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
+	var rpoEvaluator *rpo.Evaluator
+	{
+		policy, err := rpo.NewPolicy(
+			config.Viper.GetDuration(config.Flag.Service.RPO.Default),
+			config.Viper.GetString(config.Flag.Service.RPO.Rules),
+		)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		broadcaster := record.NewBroadcaster()
+		broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.K8sClient().CoreV1().Events("")})
+
+		c := rpo.Config{
+			EventRecorder: broadcaster.NewRecorder(k8sClient.Scheme(), corev1.EventSource{Component: project.Name()}),
+			History:       backupHistory,
+			K8sClient:     k8sClient,
+			Logger:        config.Logger,
+			Policy:        policy,
+
+			Interval:                    config.Viper.GetDuration(config.Flag.Service.RPO.Interval),
+			SkipManagementClusterBackup: config.Viper.GetBool(config.Flag.Service.SkipManagementClusterBackup),
+		}
+
+		rpoEvaluator, err = rpo.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var versionService *version.Service
 	{
 		c := version.Config{
@@ -218,9 +386,15 @@ func New(config Config) (*Service, error) {
 		logger:  config.Logger,
 		version: versionService,
 
-		bootOnce:             sync.Once{},
-		etcdBackupController: etcdBackupController,
-		operatorCollector:    operatorCollector,
+		bootOnce:              sync.Once{},
+		certReloader:          certReloader,
+		certReloadInterval:    config.Viper.GetDuration(config.Flag.Service.ETCDv3.ReloadInterval),
+		etcdBackupController:  etcdBackupController,
+		etcdRestoreController: etcdRestoreController,
+		history:               backupHistory,
+		k8sClient:             k8sClient,
+		operatorCollector:     operatorCollector,
+		rpoEvaluator:          rpoEvaluator,
 	}
 
 	return s, nil
@@ -228,6 +402,14 @@ func New(config Config) (*Service, error) {
 
 func (s *Service) Boot(ctx context.Context) {
 	s.bootOnce.Do(func() {
+		err := s.loadHistory(ctx)
+		if err != nil {
+			// Metrics are rebuilt from the backups done from now on, so this
+			// is not fatal.
+			s.logger.LogCtx(ctx, "level", "error", "message", "failed to load backup history", "stack", microerror.JSON(err))
+		}
+		go s.history.Boot(ctx)
+
 		go func() {
 			err := s.operatorCollector.Boot(ctx)
 			if err != nil {
@@ -237,6 +419,11 @@ func (s *Service) Boot(ctx context.Context) {
 
 		}()
 		go s.etcdBackupController.Boot(ctx)
+		go s.etcdRestoreController.Boot(ctx)
+		go s.rpoEvaluator.Boot(ctx)
+		if s.certReloader != nil && s.certReloadInterval > 0 {
+			go s.certReloader.Boot(ctx, s.certReloadInterval)
+		}
 	})
 }
 